@@ -6,11 +6,13 @@ require (
 	gio.tools/icons v0.0.0-20240708021058-44790e75e701
 	gioui.org v0.9.0
 	github.com/gin-gonic/gin v1.10.1
+	github.com/go-text/typesetting v0.3.0
 	github.com/pelletier/go-toml/v2 v2.2.4
 	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	github.com/swaggo/swag v1.16.6
 	go.bug.st/serial v1.6.4
 	golang.org/x/exp/shiny v0.0.0-20250408133849-7e4ce0ab07d0
+	golang.org/x/image v0.26.0
 	golang.org/x/tools v0.34.0
 )
 
@@ -23,10 +25,8 @@ require (
 	github.com/go-openapi/jsonreference v0.19.6 // indirect
 	github.com/go-openapi/spec v0.20.4 // indirect
 	github.com/go-openapi/swag v0.19.15 // indirect
-	github.com/go-text/typesetting v0.3.0 // indirect
 	github.com/josharian/intern v1.0.0 // indirect
 	github.com/mailru/easyjson v0.7.6 // indirect
-	golang.org/x/image v0.26.0 // indirect
 	golang.org/x/mod v0.25.0 // indirect
 	golang.org/x/sync v0.16.0 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
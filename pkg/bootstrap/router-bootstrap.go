@@ -33,7 +33,11 @@ func initRouter(svc *services) (*gin.Engine, error) {
 
 		api := root.Group("/api", apiKeyMiddleware)
 		v1 := api.Group("/v1")
-		controller.NewPrinterController(v1, svc.printerService)
+		controller.NewPrinterController(v1, svc.printerManager)
+		controller.NewJobController(v1, svc.printerManager.Jobs())
+		controller.NewTemplateController(v1, svc.printerManager.Templates())
+		controller.NewEventController(v1, svc.printerManager)
+		controller.NewWebhookController(v1, svc.webhooks)
 	}
 
 	return router, nil
@@ -3,13 +3,14 @@ package bootstrap
 import (
 	"fmt"
 
+	"github.com/jonasclaes/go-thermal-printer/pkg/controller"
 	"github.com/jonasclaes/go-thermal-printer/pkg/service"
 )
 
 type services struct {
 	configService  *service.ConfigService
-	printService   *service.PrintService
-	printerService *service.PrinterService
+	printerManager *service.PrinterManager
+	webhooks       *service.WebhookDispatcher
 }
 
 func initServices() (svc *services, err error) {
@@ -20,15 +21,17 @@ func initServices() (svc *services, err error) {
 		return nil, fmt.Errorf("failed to initialize config service: %w", err)
 	}
 
-	svc.printService, err = service.NewPrintService(svc.configService)
+	svc.printerManager, err = service.NewPrinterManager(svc.configService)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize print service: %w", err)
+		return nil, fmt.Errorf("failed to initialize printers: %w", err)
 	}
 
-	svc.printerService, err = service.NewPrinterService(svc.printService)
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize printer service: %w", err)
-	}
+	// Webhooks send the same JSON as the event stream
+	svc.webhooks = service.NewWebhookDispatcher(
+		svc.configService.GetWebhookConfigs(),
+		svc.printerManager.Events(),
+		controller.EventPayload(svc.printerManager),
+	)
 
 	return svc, nil
 }
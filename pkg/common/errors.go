@@ -1,6 +1,10 @@
 package common
 
-import "net/http"
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
 
 type AppError interface {
 	error
@@ -16,3 +20,198 @@ func (e *InvalidAPIKeyError) Error() string {
 func (e *InvalidAPIKeyError) HttpStatusCode() int {
 	return http.StatusUnauthorized
 }
+
+type PrinterNotFoundError struct {
+	Name string
+}
+
+func (e *PrinterNotFoundError) Error() string {
+	return fmt.Sprintf("printer %q not found", e.Name)
+}
+
+func (e *PrinterNotFoundError) HttpStatusCode() int {
+	return http.StatusNotFound
+}
+
+type JobNotFoundError struct {
+	ID string
+}
+
+func (e *JobNotFoundError) Error() string {
+	return fmt.Sprintf("job %q not found", e.ID)
+}
+
+func (e *JobNotFoundError) HttpStatusCode() int {
+	return http.StatusNotFound
+}
+
+type StatusNotSupportedError struct {
+	Printer string
+}
+
+func (e *StatusNotSupportedError) Error() string {
+	return fmt.Sprintf("printer %q cannot report its status over the configured transport", e.Printer)
+}
+
+func (e *StatusNotSupportedError) HttpStatusCode() int {
+	return http.StatusConflict
+}
+
+type PrintQueueFullError struct {
+	Printer string
+}
+
+func (e *PrintQueueFullError) Error() string {
+	return fmt.Sprintf("print queue of printer %q is full", e.Printer)
+}
+
+func (e *PrintQueueFullError) HttpStatusCode() int {
+	return http.StatusServiceUnavailable
+}
+
+type TemplateNotFoundError struct {
+	Name string
+}
+
+func (e *TemplateNotFoundError) Error() string {
+	return fmt.Sprintf("template %q not found", e.Name)
+}
+
+func (e *TemplateNotFoundError) HttpStatusCode() int {
+	return http.StatusNotFound
+}
+
+type InvalidTemplateNameError struct {
+	Name string
+}
+
+func (e *InvalidTemplateNameError) Error() string {
+	return fmt.Sprintf("invalid template name %q: use letters, digits, '-' and '_'", e.Name)
+}
+
+func (e *InvalidTemplateNameError) HttpStatusCode() int {
+	return http.StatusBadRequest
+}
+
+// InvalidTemplateError reports a template that does not parse.
+type InvalidTemplateError struct {
+	Name string
+	Err  error
+}
+
+func (e *InvalidTemplateError) Error() string {
+	return fmt.Sprintf("template %q is invalid: %v", e.Name, e.Err)
+}
+
+func (e *InvalidTemplateError) Unwrap() error {
+	return e.Err
+}
+
+func (e *InvalidTemplateError) HttpStatusCode() int {
+	return http.StatusBadRequest
+}
+
+type SchemaNotFoundError struct {
+	Template string
+}
+
+func (e *SchemaNotFoundError) Error() string {
+	return fmt.Sprintf("template %q has no schema", e.Template)
+}
+
+func (e *SchemaNotFoundError) HttpStatusCode() int {
+	return http.StatusNotFound
+}
+
+// InvalidSchemaError reports a template schema that is not a usable JSON Schema.
+type InvalidSchemaError struct {
+	Template string
+	Err      error
+}
+
+func (e *InvalidSchemaError) Error() string {
+	return fmt.Sprintf("schema of template %q is invalid: %v", e.Template, e.Err)
+}
+
+func (e *InvalidSchemaError) Unwrap() error {
+	return e.Err
+}
+
+func (e *InvalidSchemaError) HttpStatusCode() int {
+	return http.StatusBadRequest
+}
+
+// InvalidVariablesError lists every way the variables of a print request do
+// not match the schema of the template.
+type InvalidVariablesError struct {
+	Template string
+	Problems []string
+}
+
+func (e *InvalidVariablesError) Error() string {
+	return fmt.Sprintf("variables do not match the schema of template %q: %s", e.Template, strings.Join(e.Problems, "; "))
+}
+
+func (e *InvalidVariablesError) HttpStatusCode() int {
+	return http.StatusBadRequest
+}
+
+// Details returns the problems, for the "problems" field of the error response.
+func (e *InvalidVariablesError) Details() []string {
+	return e.Problems
+}
+
+// RenderLimitError reports a template render that was stopped because it
+// exceeded one of the configured render limits.
+type RenderLimitError struct {
+	// Limit is the limit that was exceeded, such as "render time"
+	Limit string
+	// Max is the configured value with its unit, such as "5s"
+	Max string
+}
+
+func (e *RenderLimitError) Error() string {
+	return fmt.Sprintf("template exceeds the %s limit of %s", e.Limit, e.Max)
+}
+
+func (e *RenderLimitError) HttpStatusCode() int {
+	return http.StatusUnprocessableEntity
+}
+
+// UnknownEventTypeError reports an event type filter that matches no event
+// type.
+type UnknownEventTypeError struct {
+	Type string
+}
+
+func (e *UnknownEventTypeError) Error() string {
+	return fmt.Sprintf("unknown event type %q", e.Type)
+}
+
+func (e *UnknownEventTypeError) HttpStatusCode() int {
+	return http.StatusBadRequest
+}
+
+type WebhookNotFoundError struct {
+	Name string
+}
+
+func (e *WebhookNotFoundError) Error() string {
+	return fmt.Sprintf("webhook %q not found", e.Name)
+}
+
+func (e *WebhookNotFoundError) HttpStatusCode() int {
+	return http.StatusNotFound
+}
+
+type WebhookDeliveryNotFoundError struct {
+	ID string
+}
+
+func (e *WebhookDeliveryNotFoundError) Error() string {
+	return fmt.Sprintf("webhook delivery %q not found", e.ID)
+}
+
+func (e *WebhookDeliveryNotFoundError) HttpStatusCode() int {
+	return http.StatusNotFound
+}
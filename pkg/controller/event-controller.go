@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jonasclaes/go-thermal-printer/pkg/common"
+	"github.com/jonasclaes/go-thermal-printer/pkg/dto"
+	"github.com/jonasclaes/go-thermal-printer/pkg/service"
+)
+
+// eventKeepAlive is how often an idle event stream sends a comment, so
+// proxies do not close it.
+const eventKeepAlive = 15 * time.Second
+
+type EventController struct {
+	printerManager *service.PrinterManager
+}
+
+func NewEventController(group *gin.RouterGroup, printerManager *service.PrinterManager) {
+	controller := &EventController{
+		printerManager: printerManager,
+	}
+
+	group.GET("/events", controller.getEventsHandler)
+}
+
+// @Summary		Stream printer and job events
+// @Description	Stream events as Server-Sent Events until the client disconnects. Each event is named after its type, carries its ID and has an EventDto as JSON data. Types are printer.status, printer.alert, job.queued, job.started, job.completed, job.failed, job.cancelled and queue.depth. Both filters take comma-separated lists and may be repeated; a type filter may also name a category (printer, job or queue). A comment is sent every 15 seconds while nothing happens.
+// @Tags			Events
+// @Security ApiKeyAuth
+// @Produce		text/event-stream
+// @Param			printer	query	string	false	"Only events of these printers, comma-separated"
+// @Param			type	query	string	false	"Only events of these types or categories, comma-separated"
+// @Success		200	{object}	dto.EventDto
+// @Router			/api/v1/events [get]
+func (ec *EventController) getEventsHandler(c *gin.Context) {
+	filter, err := ec.eventFilter(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	subscription := ec.printerManager.Events().Subscribe(0)
+	defer subscription.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Stop nginx from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return
+			}
+			if !filter.matches(event) {
+				continue
+			}
+			data, err := json.Marshal(ec.toEventDto(event))
+			if err != nil {
+				log.Printf("events: failed to encode %s event: %v", event.Type, err)
+				continue
+			}
+			if _, err := fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := io.WriteString(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case <-c.Request.Context().Done():
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+// eventFilter selects the events a client asked for. Empty lists match
+// everything.
+type eventFilter struct {
+	printers []string
+	types    []string
+}
+
+// eventFilter reads the printer and type query parameters, rejecting
+// printers and types that do not exist.
+func (ec *EventController) eventFilter(c *gin.Context) (eventFilter, error) {
+	filter := eventFilter{
+		printers: queryList(c, "printer"),
+		types:    queryList(c, "type"),
+	}
+
+	for _, name := range filter.printers {
+		if _, err := ec.printerManager.Get(name); err != nil {
+			return eventFilter{}, err
+		}
+	}
+	for _, eventType := range filter.types {
+		if !service.KnownEventFilter(eventType) {
+			return eventFilter{}, &common.UnknownEventTypeError{Type: eventType}
+		}
+	}
+
+	return filter, nil
+}
+
+func (f eventFilter) matches(event service.Event) bool {
+	if len(f.printers) > 0 && !slices.Contains(f.printers, event.Printer) {
+		return false
+	}
+	if len(f.types) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(f.types, func(t string) bool {
+		return service.EventTypeMatches(event.Type, t)
+	})
+}
+
+// queryList returns the values of a query parameter that may be repeated and
+// hold comma-separated lists.
+func queryList(c *gin.Context, key string) []string {
+	var values []string
+	for _, value := range c.QueryArray(key) {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+	}
+	return values
+}
+
+func (ec *EventController) toEventDto(event service.Event) dto.EventDto {
+	var data any
+	switch d := event.Data.(type) {
+	case service.Job:
+		data = toJobDto(d)
+	case service.PrinterState:
+		drawerOpen := false
+		if printer, err := ec.printerManager.Get(event.Printer); err == nil {
+			drawerOpen = printer.DrawerOpen(d.PrinterStatus)
+		}
+		data = toPrinterStateDto(d, drawerOpen)
+	case service.StatusAlertEvent:
+		data = dto.PrinterAlertDto{Alert: string(d.Alert), Active: d.Active}
+	case service.QueueDepth:
+		data = dto.QueueDepthDto{Queued: d.Queued}
+	default:
+		data = d
+	}
+
+	return dto.EventDto{
+		ID:      event.ID,
+		Type:    event.Type,
+		Printer: event.Printer,
+		Time:    event.Time,
+		Data:    data,
+	}
+}
+
+func toPrinterStateDto(state service.PrinterState, drawerOpen bool) dto.PrinterStateDto {
+	status := service.StatusResponse{
+		PrinterStatus:         state.PrinterStatus,
+		OfflineStatus:         state.OfflineStatus,
+		ErrorStatus:           state.ErrorStatus,
+		ContinuousPaperStatus: state.ContinuousPaperStatus,
+	}
+
+	alerts := make([]string, 0, len(state.Alerts))
+	for _, alert := range state.Alerts {
+		alerts = append(alerts, string(alert))
+	}
+
+	return dto.PrinterStateDto{
+		Status:    printerStatusDto(status, drawerOpen),
+		Alerts:    alerts,
+		Error:     state.Error,
+		UpdatedAt: state.UpdatedAt,
+	}
+}
@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jonasclaes/go-thermal-printer/pkg/dto"
+	"github.com/jonasclaes/go-thermal-printer/pkg/service"
+)
+
+type JobController struct {
+	jobs *service.JobStore
+}
+
+func NewJobController(group *gin.RouterGroup, jobs *service.JobStore) {
+	controller := &JobController{
+		jobs: jobs,
+	}
+
+	{
+		jobGroup := group.Group("/jobs")
+		jobGroup.GET("/:id", controller.getJobHandler)
+	}
+}
+
+// @Summary		Get a print job
+// @Description	Get the state of a print job submitted with ?async=true (or any recent job).
+// @Tags			Jobs
+// @Security ApiKeyAuth
+// @Param			id	path	string	true	"Job ID"
+// @Success		200	{object}	dto.JobDto
+// @Router			/api/v1/jobs/{id} [get]
+func (jc *JobController) getJobHandler(c *gin.Context) {
+	job, err := jc.jobs.Get(c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toJobDto(job))
+}
+
+func toJobDto(job service.Job) dto.JobDto {
+	return dto.JobDto{
+		ID:         job.ID,
+		Printer:    job.Printer,
+		Status:     string(job.State),
+		Bytes:      job.Bytes,
+		Error:      job.Error,
+		CreatedAt:  job.CreatedAt,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+	}
+}
+
+// acceptedJob answers an asynchronous print request with 202 Accepted.
+func acceptedJob(c *gin.Context, job service.Job) {
+	c.Header("Location", "/api/v1/jobs/"+job.ID)
+	c.JSON(http.StatusAccepted, toJobDto(job))
+}
@@ -1,69 +1,208 @@
 package controller
 
 import (
+	"bytes"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jonasclaes/go-thermal-printer/pkg/dto"
+	"github.com/jonasclaes/go-thermal-printer/pkg/emulator"
 	"github.com/jonasclaes/go-thermal-printer/pkg/escpos"
+	"github.com/jonasclaes/go-thermal-printer/pkg/profile"
 	"github.com/jonasclaes/go-thermal-printer/pkg/service"
 )
 
 type PrinterController struct {
-	printerService *service.PrinterService
+	printerManager *service.PrinterManager
 }
 
-func NewPrinterController(group *gin.RouterGroup, printerService *service.PrinterService) {
+func NewPrinterController(group *gin.RouterGroup, printerManager *service.PrinterManager) {
 	controller := &PrinterController{
-		printerService: printerService,
+		printerManager: printerManager,
 	}
 
+	// Legacy routes, served by the default printer
 	{
 		printerGroup := group.Group("/printer")
-		printerGroup.GET("/status", controller.getPrinterStatusHandler)
-		printerGroup.POST("/print", controller.postPrinterPrintHandler)
-		printerGroup.POST("/print-template", controller.postPrinterPrintTemplateHandler)
-		printerGroup.POST("/print-image", controller.PrintImage)
+		controller.registerPrinterRoutes(printerGroup)
+	}
+
+	{
+		printersGroup := group.Group("/printers")
+		printersGroup.GET("", controller.getPrintersHandler)
+		controller.registerPrinterRoutes(printersGroup.Group("/:name"))
+	}
+}
+
+func (pc *PrinterController) registerPrinterRoutes(group *gin.RouterGroup) {
+	group.GET("/status", pc.getPrinterStatusHandler)
+	group.POST("/print", pc.postPrinterPrintHandler)
+	group.POST("/print-template", pc.postPrinterPrintTemplateHandler)
+	group.POST("/print-image", pc.PrintImage)
+	group.POST("/preview-template", pc.postPrinterPreviewTemplateHandler)
+	group.POST("/drawer", pc.postPrinterDrawerHandler)
+}
+
+// printer resolves the printer addressed by the request: the :name path
+// parameter on /printers/:name routes, otherwise the default printer.
+func (pc *PrinterController) printer(c *gin.Context) (*service.PrinterService, error) {
+	name := c.Param("name")
+	if name == "" {
+		return pc.printerManager.Default(), nil
+	}
+	return pc.printerManager.Get(name)
+}
+
+// isAsync reports whether the client asked for ?async=true.
+func isAsync(c *gin.Context) bool {
+	async, _ := strconv.ParseBool(c.Query("async"))
+	return async
+}
+
+// @Summary		List printers
+// @Description	List the configured printers with their capability profiles and the default printer used by /api/v1/printer/*.
+// @Tags			Printer
+// @Security ApiKeyAuth
+// @Success		200	{array}	dto.PrinterInfoDto
+// @Router			/api/v1/printers [get]
+func (pc *PrinterController) getPrintersHandler(c *gin.Context) {
+	defaultName := pc.printerManager.DefaultName()
+	names := pc.printerManager.Names()
 
+	printers := make([]dto.PrinterInfoDto, 0, len(names))
+	for _, name := range names {
+		printer, err := pc.printerManager.Get(name)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		printers = append(printers, dto.PrinterInfoDto{
+			Name:    name,
+			Default: name == defaultName,
+			Profile: printerProfileDto(printer.Profile()),
+		})
+	}
+
+	c.JSON(http.StatusOK, printers)
+}
+
+func printerProfileDto(p profile.Profile) dto.PrinterProfileDto {
+	codePages := make([]int, len(p.CodePages))
+	for i, codePage := range p.CodePages {
+		codePages[i] = int(codePage)
+	}
+
+	return dto.PrinterProfileDto{
+		Name:         p.Name,
+		Description:  p.Description,
+		DotsPerLine:  p.DotsPerLine,
+		ColumnsFontA: p.ColumnsFontA,
+		ColumnsFontB: p.ColumnsFontB,
+		CodePages:    codePages,
+		Cutter:       string(p.Cutter),
+		Barcodes:     p.Barcodes,
+		QRCode:       p.QRCode,
+		PDF417:       p.PDF417,
+		Buzzer:       p.Buzzer,
+
+		RasterBandHeight: p.RasterBandHeight,
 	}
 }
 
 // @Summary		Query printer status
-// @Description	Query the printer status through the configured port.
+// @Description	Query the printer status through the configured port. The raw DLE EOT bytes are returned with their decoded flags, an overall state (ready, warning or error) and messages describing what needs attention.
 // @Tags			Printer
 // @Security ApiKeyAuth
+// @Param			name	path	string	false	"Printer name (named routes only)"
 // @Success		200	{object}	dto.PrinterStatusDto
 // @Router			/api/v1/printer/status [get]
+// @Router			/api/v1/printers/{name}/status [get]
 func (pc *PrinterController) getPrinterStatusHandler(c *gin.Context) {
-	status, err := pc.printerService.GetPrinterStatus(c.Request.Context())
+	printer, err := pc.printer(c)
 	if err != nil {
 		_ = c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, dto.PrinterStatusDto{
+	status, err := printer.GetPrinterStatus(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, printerStatusDto(status, printer.DrawerOpen(status.PrinterStatus)))
+}
+
+func printerStatusDto(status service.StatusResponse, drawerOpen bool) dto.PrinterStatusDto {
+	decoded := status.Decode()
+	messages := decoded.Messages()
+	if messages == nil {
+		messages = []string{}
+	}
+
+	return dto.PrinterStatusDto{
 		PrinterStatus:         status.PrinterStatus,
 		OfflineStatus:         status.OfflineStatus,
 		ErrorStatus:           status.ErrorStatus,
 		ContinuousPaperStatus: status.ContinuousPaperStatus,
-	})
+
+		State:    string(decoded.Level()),
+		Messages: messages,
+		Flags: dto.PrinterStatusFlagsDto{
+			Offline:    decoded.Offline,
+			DrawerOpen: drawerOpen,
+
+			CoverOpen:       decoded.CoverOpen,
+			FeedButton:      decoded.FeedButton,
+			PrintingStopped: decoded.PrintingStopped,
+
+			AutocutterError:      decoded.AutocutterError,
+			UnrecoverableError:   decoded.UnrecoverableError,
+			AutoRecoverableError: decoded.AutoRecoverableError,
+
+			PaperNearEnd: decoded.PaperNearEnd,
+			PaperEnd:     decoded.PaperEnd,
+		},
+	}
 }
 
 // @Summary		Print an array of bytes
 // @Description	Print an array of bytes to the printer, with ESC/POS commands.
 // @Tags			Printer
 // @Security ApiKeyAuth
+// @Param			name	path	string	false	"Printer name (named routes only)"
 // @Param request body dto.PrinterPrintDto	true "Printer data"
+// @Param			async	query	bool	false	"Queue the job and return 202 with a job ID instead of waiting"
 // @Success		201
+// @Success		202	{object}	dto.JobDto
 // @Router			/api/v1/printer/print [post]
+// @Router			/api/v1/printers/{name}/print [post]
 func (pc *PrinterController) postPrinterPrintHandler(c *gin.Context) {
+	printer, err := pc.printer(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
 	var input dto.PrinterPrintDto
 	if err := c.ShouldBindJSON(&input); err != nil {
 		_ = c.Error(err)
 		return
 	}
 
-	err := pc.printerService.Print(c.Request.Context(), input)
+	if isAsync(c) {
+		job, err := printer.PrintAsync(input)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		acceptedJob(c, job)
+		return
+	}
+
+	err = printer.Print(c.Request.Context(), input)
 	if err != nil {
 		_ = c.Error(err)
 		return
@@ -76,17 +215,37 @@ func (pc *PrinterController) postPrinterPrintHandler(c *gin.Context) {
 // @Description	Print a template with arbitrary data.
 // @Tags			Printer
 // @Security ApiKeyAuth
+// @Param			name	path	string	false	"Printer name (named routes only)"
 // @Param request body dto.PrinterPrintTemplateDto	true "Printer data"
+// @Param			async	query	bool	false	"Queue the job and return 202 with a job ID instead of waiting"
 // @Success		201
+// @Success		202	{object}	dto.JobDto
 // @Router			/api/v1/printer/print-template [post]
+// @Router			/api/v1/printers/{name}/print-template [post]
 func (pc *PrinterController) postPrinterPrintTemplateHandler(c *gin.Context) {
+	printer, err := pc.printer(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
 	var input dto.PrinterPrintTemplateDto
 	if err := c.ShouldBindJSON(&input); err != nil {
 		_ = c.Error(err)
 		return
 	}
 
-	err := pc.printerService.PrintTemplate(c.Request.Context(), input)
+	if isAsync(c) {
+		job, err := printer.PrintTemplateAsync(input)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		acceptedJob(c, job)
+		return
+	}
+
+	err = printer.PrintTemplate(c.Request.Context(), input)
 	if err != nil {
 		_ = c.Error(err)
 		return
@@ -95,23 +254,165 @@ func (pc *PrinterController) postPrinterPrintTemplateHandler(c *gin.Context) {
 	c.Status(http.StatusCreated)
 }
 
-// PrintImage: POST /api/v1/printer/print-image
-// Body: { "imageBase64": "<...>", "maxWidthDots": 384 }
+// @Summary		Open the cash drawer
+// @Description	Pulse the drawer kick-out connector. With waitForClose the drawer status is polled until the drawer has been closed again or timeoutMs expires; this needs a transport that can read status (not USB mode). The body is optional.
+// @Tags			Printer
+// @Security ApiKeyAuth
+// @Param			name	path	string	false	"Printer name (named routes only)"
+// @Param request body dto.PrinterDrawerDto	false "Drawer pulse"
+// @Success		200	{object}	dto.PrinterDrawerResultDto
+// @Router			/api/v1/printer/drawer [post]
+// @Router			/api/v1/printers/{name}/drawer [post]
+func (pc *PrinterController) postPrinterDrawerHandler(c *gin.Context) {
+	printer, err := pc.printer(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	var input dto.PrinterDrawerDto
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload: " + err.Error()})
+			return
+		}
+	}
+
+	result, err := printer.KickDrawer(c.Request.Context(), input)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	response := dto.PrinterDrawerResultDto{Status: "ok"}
+	if input.WaitForClose {
+		response.Opened = &result.Opened
+		response.Closed = &result.Closed
+		response.WaitedMs = result.Waited.Milliseconds()
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary		Preview a template
+// @Description	Render a template the way the printer would print it, without sending anything to the printer. Returns a PNG (receipts stacked, cuts marked by a dashed line) or a PDF with one page per cut.
+// @Tags			Printer
+// @Security ApiKeyAuth
+// @Produce		png,application/pdf
+// @Param			name	path	string	false	"Printer name (named routes only)"
+// @Param request body dto.PrinterPrintTemplateDto	true "Printer data"
+// @Param			format	query	string	false	"Output format: png (default) or pdf"
+// @Success		200	{file}	file
+// @Router			/api/v1/printer/preview-template [post]
+// @Router			/api/v1/printers/{name}/preview-template [post]
+func (pc *PrinterController) postPrinterPreviewTemplateHandler(c *gin.Context) {
+	printer, err := pc.printer(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	var input dto.PrinterPrintTemplateDto
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	format := strings.ToLower(c.DefaultQuery("format", "png"))
+	if format != "png" && format != "pdf" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be png or pdf"})
+		return
+	}
+
+	pages, err := printer.PreviewTemplate(input)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	var out bytes.Buffer
+	contentType := "image/png"
+	if format == "pdf" {
+		contentType = "application/pdf"
+		err = emulator.WritePDF(&out, pages)
+	} else {
+		err = emulator.WritePNG(&out, pages)
+	}
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Data(http.StatusOK, contentType, out.Bytes())
+}
+
+// PrintImage: POST /api/v1/printer/print-image (or /api/v1/printers/{name}/print-image)
+// Body: { "imageBase64": "<...>", "maxWidthDots": 384, "dither": "atkinson",
+// "brightness": 0, "contrast": 0, "gamma": 1, "align": "center" }; only
+// imageBase64 is required and maxWidthDots defaults to the paper width of the
+// printer's profile. The image is printed as a job of its own: the printer is
+// reset first and the paper fed past the tear bar afterwards.
+// With ?async=true the job is queued and 202 Accepted is returned with the job.
 func (pc *PrinterController) PrintImage(c *gin.Context) {
+	printer, err := pc.printer(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
 	var req dto.PrintImageRequest
 	if err := c.ShouldBind(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload: " + err.Error()})
 		return
 	}
-	bytes, err := escpos.EncodeImageToRasterBytes(req.ImageBase64, req.MaxWidthDots)
+	dither, err := escpos.ParseDitherMode(req.Dither)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload: " + err.Error()})
+		return
+	}
+	align := escpos.AlignCenter
+	if req.Align != "" {
+		if align, err = escpos.ParseAlignment(req.Align); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload: " + err.Error()})
+			return
+		}
+	}
+	options := escpos.ImageOptions{
+		MaxWidthDots: printer.Profile().DotsPerLine,
+		Dither:       dither,
+		Brightness:   req.Brightness,
+		Contrast:     req.Contrast,
+		Gamma:        req.Gamma,
+		BandHeight:   printer.Profile().RasterBandHeight,
+		Align:        align,
+	}
+	if req.MaxWidthDots > 0 {
+		options.MaxWidthDots = min(req.MaxWidthDots, options.MaxWidthDots)
+	}
+	if err := options.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to convert image: " + err.Error()})
+		return
+	}
+	img, err := escpos.DecodeImage(req.ImageBase64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to convert image: " + err.Error()})
 		return
 	}
 
-	if err := pc.printerService.PrintBytes(c.Request.Context(), bytes); err != nil {
+	// The image is converted band by band while the worker prints it
+	if isAsync(c) {
+		job, err := printer.PrintImageAsync(img, options)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		acceptedJob(c, job)
+		return
+	}
+
+	written, err := printer.PrintImage(c.Request.Context(), img, options)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "print failed: " + err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"status": "ok", "bytesWritten": len(bytes)})
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "bytesWritten": written})
 }
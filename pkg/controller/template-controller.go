@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jonasclaes/go-thermal-printer/pkg/dto"
+	"github.com/jonasclaes/go-thermal-printer/pkg/service"
+)
+
+type TemplateController struct {
+	templates *service.TemplateStore
+}
+
+func NewTemplateController(group *gin.RouterGroup, templates *service.TemplateStore) {
+	controller := &TemplateController{
+		templates: templates,
+	}
+
+	{
+		templateGroup := group.Group("/templates")
+		templateGroup.GET("", controller.getTemplatesHandler)
+		templateGroup.GET("/:name", controller.getTemplateHandler)
+		templateGroup.PUT("/:name", controller.putTemplateHandler)
+		templateGroup.DELETE("/:name", controller.deleteTemplateHandler)
+		templateGroup.GET("/:name/schema", controller.getSchemaHandler)
+		templateGroup.PUT("/:name/schema", controller.putSchemaHandler)
+		templateGroup.DELETE("/:name/schema", controller.deleteSchemaHandler)
+	}
+}
+
+// @Summary		List templates
+// @Description	List the templates in the template store with their size and modification time.
+// @Tags			Templates
+// @Security ApiKeyAuth
+// @Success		200	{array}	dto.TemplateInfoDto
+// @Router			/api/v1/templates [get]
+func (tc *TemplateController) getTemplatesHandler(c *gin.Context) {
+	templates, err := tc.templates.List()
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	response := make([]dto.TemplateInfoDto, len(templates))
+	for i, info := range templates {
+		response[i] = toTemplateInfoDto(info)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary		Get a template
+// @Description	Get the source of a stored template.
+// @Tags			Templates
+// @Security ApiKeyAuth
+// @Param			name	path	string	true	"Template name"
+// @Success		200	{object}	dto.TemplateDto
+// @Router			/api/v1/templates/{name} [get]
+func (tc *TemplateController) getTemplateHandler(c *gin.Context) {
+	info, content, err := tc.templates.Get(c.Param("name"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.TemplateDto{
+		TemplateInfoDto: toTemplateInfoDto(info),
+		Content:         content,
+	})
+}
+
+// @Summary		Create or replace a template
+// @Description	Store a template under the given name. Names use letters, digits, '-' and '_'. The template must parse; it is printed with {"template": "<name>"}.
+// @Tags			Templates
+// @Security ApiKeyAuth
+// @Param			name	path	string	true	"Template name"
+// @Param request body dto.TemplatePutDto	true "Template source"
+// @Success		200	{object}	dto.TemplateInfoDto
+// @Success		201	{object}	dto.TemplateInfoDto
+// @Router			/api/v1/templates/{name} [put]
+func (tc *TemplateController) putTemplateHandler(c *gin.Context) {
+	var input dto.TemplatePutDto
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	info, created, err := tc.templates.Put(c.Param("name"), input.Content)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, toTemplateInfoDto(info))
+}
+
+// @Summary		Delete a template
+// @Description	Remove a template from the template store.
+// @Tags			Templates
+// @Security ApiKeyAuth
+// @Param			name	path	string	true	"Template name"
+// @Success		204
+// @Router			/api/v1/templates/{name} [delete]
+func (tc *TemplateController) deleteTemplateHandler(c *gin.Context) {
+	if err := tc.templates.Delete(c.Param("name")); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary		Get a template schema
+// @Description	Get the JSON Schema that describes the variables of a template: their types, which are required, defaults and examples.
+// @Tags			Templates
+// @Security ApiKeyAuth
+// @Produce		json
+// @Param			name	path	string	true	"Template name"
+// @Success		200	{object}	object
+// @Router			/api/v1/templates/{name}/schema [get]
+func (tc *TemplateController) getSchemaHandler(c *gin.Context) {
+	data, err := tc.templates.Schema(c.Param("name"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/schema+json", data)
+}
+
+// @Summary		Create or replace a template schema
+// @Description	Store the JSON Schema of a template's variables. Printing the template then validates the variables against it and fills in defaults; invalid variables are rejected with 400 and every problem listed.
+// @Tags			Templates
+// @Security ApiKeyAuth
+// @Accept		json
+// @Param			name	path	string	true	"Template name"
+// @Param request body object	true "JSON Schema"
+// @Success		200
+// @Success		201
+// @Router			/api/v1/templates/{name}/schema [put]
+func (tc *TemplateController) putSchemaHandler(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	created, err := tc.templates.PutSchema(c.Param("name"), data)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	if created {
+		c.Status(http.StatusCreated)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// @Summary		Delete a template schema
+// @Description	Remove the JSON Schema of a template; its variables are no longer validated.
+// @Tags			Templates
+// @Security ApiKeyAuth
+// @Param			name	path	string	true	"Template name"
+// @Success		204
+// @Router			/api/v1/templates/{name}/schema [delete]
+func (tc *TemplateController) deleteSchemaHandler(c *gin.Context) {
+	if err := tc.templates.DeleteSchema(c.Param("name")); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func toTemplateInfoDto(info service.TemplateInfo) dto.TemplateInfoDto {
+	return dto.TemplateInfoDto{
+		Name:       info.Name,
+		Size:       info.Size,
+		ModifiedAt: info.ModifiedAt,
+		HasSchema:  info.HasSchema,
+	}
+}
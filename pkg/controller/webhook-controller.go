@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jonasclaes/go-thermal-printer/pkg/common"
+	"github.com/jonasclaes/go-thermal-printer/pkg/dto"
+	"github.com/jonasclaes/go-thermal-printer/pkg/model"
+	"github.com/jonasclaes/go-thermal-printer/pkg/service"
+)
+
+const defaultDeliveryLimit = 100
+
+type WebhookController struct {
+	webhooks *service.WebhookDispatcher
+}
+
+func NewWebhookController(group *gin.RouterGroup, webhooks *service.WebhookDispatcher) {
+	controller := &WebhookController{
+		webhooks: webhooks,
+	}
+
+	{
+		webhookGroup := group.Group("/webhooks")
+		webhookGroup.GET("", controller.getWebhooksHandler)
+		webhookGroup.GET("/deliveries", controller.getDeliveriesHandler)
+		webhookGroup.GET("/deliveries/:id", controller.getDeliveryHandler)
+	}
+}
+
+// EventPayload returns how events are encoded on the event stream, so
+// webhooks send the same JSON.
+func EventPayload(printerManager *service.PrinterManager) func(service.Event) any {
+	controller := &EventController{printerManager: printerManager}
+	return func(event service.Event) any {
+		return controller.toEventDto(event)
+	}
+}
+
+// @Summary		List webhooks
+// @Description	List the webhooks configured in [[webhooks]]. Only the host of each URL is shown, as the rest may hold a token.
+// @Tags			Webhooks
+// @Security ApiKeyAuth
+// @Success		200	{array}	dto.WebhookDto
+// @Router			/api/v1/webhooks [get]
+func (wc *WebhookController) getWebhooksHandler(c *gin.Context) {
+	webhooks := wc.webhooks.Webhooks()
+
+	result := make([]dto.WebhookDto, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		result = append(result, toWebhookDto(webhook))
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// @Summary		List webhook deliveries
+// @Description	List the most recent webhook deliveries, newest first, with their attempts and the outcome of the last one. The last 1000 deliveries are kept in memory.
+// @Tags			Webhooks
+// @Security ApiKeyAuth
+// @Param			webhook	query	string	false	"Only deliveries to this webhook"
+// @Param			status	query	string	false	"Only deliveries in this state"	Enums(pending, delivered, failed, dropped)
+// @Param			limit	query	int	false	"Most recent deliveries to return (default 100)"
+// @Success		200	{array}	dto.WebhookDeliveryDto
+// @Router			/api/v1/webhooks/deliveries [get]
+func (wc *WebhookController) getDeliveriesHandler(c *gin.Context) {
+	var query dto.WebhookDeliveriesQueryDto
+	if err := c.ShouldBindQuery(&query); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	if query.Webhook != "" {
+		known := slices.ContainsFunc(wc.webhooks.Webhooks(), func(webhook model.WebhookConfig) bool {
+			return webhook.Name == query.Webhook
+		})
+		if !known {
+			_ = c.Error(&common.WebhookNotFoundError{Name: query.Webhook})
+			return
+		}
+	}
+	limit := query.Limit
+	if limit == 0 {
+		limit = defaultDeliveryLimit
+	}
+
+	deliveries := wc.webhooks.Deliveries().List(query.Webhook, service.WebhookDeliveryState(query.Status), limit)
+
+	result := make([]dto.WebhookDeliveryDto, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		result = append(result, toWebhookDeliveryDto(delivery))
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// @Summary		Get a webhook delivery
+// @Description	Get one webhook delivery. Its ID is sent to the webhook in the X-Webhook-Delivery header.
+// @Tags			Webhooks
+// @Security ApiKeyAuth
+// @Param			id	path	string	true	"Delivery ID"
+// @Success		200	{object}	dto.WebhookDeliveryDto
+// @Router			/api/v1/webhooks/deliveries/{id} [get]
+func (wc *WebhookController) getDeliveryHandler(c *gin.Context) {
+	delivery, err := wc.webhooks.Deliveries().Get(c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toWebhookDeliveryDto(delivery))
+}
+
+func toWebhookDto(webhook model.WebhookConfig) dto.WebhookDto {
+	var host string
+	if target, err := url.Parse(webhook.URL); err == nil {
+		host = target.Host
+	}
+	events := webhook.Events
+	if events == nil {
+		events = []string{}
+	}
+	printers := webhook.Printers
+	if printers == nil {
+		printers = []string{}
+	}
+
+	return dto.WebhookDto{
+		Name:        webhook.Name,
+		Host:        host,
+		Events:      events,
+		Printers:    printers,
+		Format:      webhook.Format,
+		Signed:      webhook.Secret != "",
+		MaxAttempts: webhook.MaxAttempts,
+	}
+}
+
+func toWebhookDeliveryDto(delivery service.WebhookDelivery) dto.WebhookDeliveryDto {
+	return dto.WebhookDeliveryDto{
+		ID:            delivery.ID,
+		Webhook:       delivery.Webhook,
+		EventID:       delivery.EventID,
+		EventType:     delivery.EventType,
+		Printer:       delivery.Printer,
+		Status:        string(delivery.State),
+		Attempts:      delivery.Attempts,
+		StatusCode:    delivery.StatusCode,
+		Error:         delivery.Error,
+		CreatedAt:     delivery.CreatedAt,
+		NextAttemptAt: delivery.NextAttemptAt,
+		FinishedAt:    delivery.FinishedAt,
+	}
+}
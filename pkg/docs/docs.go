@@ -15,6 +15,151 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/api/v1/events": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Stream events as Server-Sent Events until the client disconnects. Each event is named after its type, carries its ID and has an EventDto as JSON data. Types are printer.status, printer.alert, job.queued, job.started, job.completed, job.failed, job.cancelled and queue.depth. Both filters take comma-separated lists and may be repeated; a type filter may also name a category (printer, job or queue). A comment is sent every 15 seconds while nothing happens.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Events"
+                ],
+                "summary": "Stream printer and job events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only events of these printers, comma-separated",
+                        "name": "printer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only events of these types or categories, comma-separated",
+                        "name": "type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/EventDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/jobs/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the state of a print job submitted with ?async=true (or any recent job).",
+                "tags": [
+                    "Jobs"
+                ],
+                "summary": "Get a print job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/JobDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/printer/drawer": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Pulse the drawer kick-out connector. With waitForClose the drawer status is polled until the drawer has been closed again or timeoutMs expires; this needs a transport that can read status (not USB mode). The body is optional.",
+                "tags": [
+                    "Printer"
+                ],
+                "summary": "Open the cash drawer",
+                "parameters": [
+                    {
+                        "description": "Drawer pulse",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/PrinterDrawerDto"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/PrinterDrawerResultDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/printer/preview-template": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Render a template the way the printer would print it, without sending anything to the printer. Returns a PNG (receipts stacked, cuts marked by a dashed line) or a PDF with one page per cut.",
+                "produces": [
+                    "image/png",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "Printer"
+                ],
+                "summary": "Preview a template",
+                "parameters": [
+                    {
+                        "description": "Printer data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/PrinterPrintTemplateDto"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Output format: png (default) or pdf",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/printer/print": {
             "post": {
                 "security": [
@@ -22,76 +167,672 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Print an array of bytes to the printer, with ESC/POS commands.",
+                "description": "Print an array of bytes to the printer, with ESC/POS commands.",
+                "tags": [
+                    "Printer"
+                ],
+                "summary": "Print an array of bytes",
+                "parameters": [
+                    {
+                        "description": "Printer data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/PrinterPrintDto"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Queue the job and return 202 with a job ID instead of waiting",
+                        "name": "async",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created"
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/JobDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/printer/print-template": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Print a template with arbitrary data.",
+                "tags": [
+                    "Printer"
+                ],
+                "summary": "Print a template",
+                "parameters": [
+                    {
+                        "description": "Printer data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/PrinterPrintTemplateDto"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Queue the job and return 202 with a job ID instead of waiting",
+                        "name": "async",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created"
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/JobDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/printer/status": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Query the printer status through the configured port. The raw DLE EOT bytes are returned with their decoded flags, an overall state (ready, warning or error) and messages describing what needs attention.",
+                "tags": [
+                    "Printer"
+                ],
+                "summary": "Query printer status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/PrinterStatusDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/printers": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the configured printers with their capability profiles and the default printer used by /api/v1/printer/*.",
+                "tags": [
+                    "Printer"
+                ],
+                "summary": "List printers",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/PrinterInfoDto"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/printers/{name}/drawer": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Pulse the drawer kick-out connector. With waitForClose the drawer status is polled until the drawer has been closed again or timeoutMs expires; this needs a transport that can read status (not USB mode). The body is optional.",
+                "tags": [
+                    "Printer"
+                ],
+                "summary": "Open the cash drawer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Printer name (named routes only)",
+                        "name": "name",
+                        "in": "path"
+                    },
+                    {
+                        "description": "Drawer pulse",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/PrinterDrawerDto"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/PrinterDrawerResultDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/printers/{name}/preview-template": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Render a template the way the printer would print it, without sending anything to the printer. Returns a PNG (receipts stacked, cuts marked by a dashed line) or a PDF with one page per cut.",
+                "produces": [
+                    "image/png",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "Printer"
+                ],
+                "summary": "Preview a template",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Printer name (named routes only)",
+                        "name": "name",
+                        "in": "path"
+                    },
+                    {
+                        "description": "Printer data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/PrinterPrintTemplateDto"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Output format: png (default) or pdf",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/printers/{name}/print": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Print an array of bytes to the printer, with ESC/POS commands.",
+                "tags": [
+                    "Printer"
+                ],
+                "summary": "Print an array of bytes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Printer name (named routes only)",
+                        "name": "name",
+                        "in": "path"
+                    },
+                    {
+                        "description": "Printer data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/PrinterPrintDto"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Queue the job and return 202 with a job ID instead of waiting",
+                        "name": "async",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created"
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/JobDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/printers/{name}/print-template": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Print a template with arbitrary data.",
+                "tags": [
+                    "Printer"
+                ],
+                "summary": "Print a template",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Printer name (named routes only)",
+                        "name": "name",
+                        "in": "path"
+                    },
+                    {
+                        "description": "Printer data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/PrinterPrintTemplateDto"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Queue the job and return 202 with a job ID instead of waiting",
+                        "name": "async",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created"
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/JobDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/printers/{name}/status": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Query the printer status through the configured port. The raw DLE EOT bytes are returned with their decoded flags, an overall state (ready, warning or error) and messages describing what needs attention.",
+                "tags": [
+                    "Printer"
+                ],
+                "summary": "Query printer status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Printer name (named routes only)",
+                        "name": "name",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/PrinterStatusDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/templates": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the templates in the template store with their size and modification time.",
+                "tags": [
+                    "Templates"
+                ],
+                "summary": "List templates",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/TemplateInfoDto"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/templates/{name}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the source of a stored template.",
+                "tags": [
+                    "Templates"
+                ],
+                "summary": "Get a template",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Template name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/TemplateDto"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Store a template under the given name. Names use letters, digits, '-' and '_'. The template must parse; it is printed with {\"template\": \"\u003cname\u003e\"}.",
+                "tags": [
+                    "Templates"
+                ],
+                "summary": "Create or replace a template",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Template name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Template source",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/TemplatePutDto"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/TemplateInfoDto"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/TemplateInfoDto"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Remove a template from the template store.",
+                "tags": [
+                    "Templates"
+                ],
+                "summary": "Delete a template",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Template name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/templates/{name}/schema": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the JSON Schema that describes the variables of a template: their types, which are required, defaults and examples.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Templates"
+                ],
+                "summary": "Get a template schema",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Template name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Store the JSON Schema of a template's variables. Printing the template then validates the variables against it and fills in defaults; invalid variables are rejected with 400 and every problem listed.",
+                "consumes": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Printer"
+                    "Templates"
                 ],
-                "summary": "Print an array of bytes",
+                "summary": "Create or replace a template schema",
                 "parameters": [
                     {
-                        "description": "Printer data",
+                        "type": "string",
+                        "description": "Template name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "JSON Schema",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/PrinterPrintDto"
+                            "type": "object"
                         }
                     }
                 ],
                 "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
                     "201": {
                         "description": "Created"
                     }
                 }
-            }
-        },
-        "/api/v1/printer/print-template": {
-            "post": {
+            },
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Print a template with arbitrary data.",
+                "description": "Remove the JSON Schema of a template; its variables are no longer validated.",
                 "tags": [
-                    "Printer"
+                    "Templates"
                 ],
-                "summary": "Print a template",
+                "summary": "Delete a template schema",
                 "parameters": [
                     {
-                        "description": "Printer data",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "Template name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the webhooks configured in [[webhooks]]. Only the host of each URL is shown, as the rest may hold a token.",
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "List webhooks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/PrinterPrintTemplateDto"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/WebhookDto"
+                            }
                         }
                     }
+                }
+            }
+        },
+        "/api/v1/webhooks/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the most recent webhook deliveries, newest first, with their attempts and the outcome of the last one. The last 1000 deliveries are kept in memory.",
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "List webhook deliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only deliveries to this webhook",
+                        "name": "webhook",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "delivered",
+                            "failed",
+                            "dropped"
+                        ],
+                        "type": "string",
+                        "description": "Only deliveries in this state",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Most recent deliveries to return (default 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/WebhookDeliveryDto"
+                            }
+                        }
                     }
                 }
             }
         },
-        "/api/v1/printer/status": {
+        "/api/v1/webhooks/deliveries/{id}": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Query the printer status through the configured port.",
+                "description": "Get one webhook delivery. Its ID is sent to the webhook in the X-Webhook-Delivery header.",
                 "tags": [
-                    "Printer"
+                    "Webhooks"
+                ],
+                "summary": "Get a webhook delivery",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Delivery ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Query printer status",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/PrinterStatusDto"
+                            "$ref": "#/definitions/WebhookDeliveryDto"
                         }
                     }
                 }
@@ -99,6 +840,141 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "EventDto": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "JobDto for job.* events, PrinterStateDto for printer.status,\nPrinterAlertDto for printer.alert and QueueDepthDto for queue.depth"
+                },
+                "id": {
+                    "description": "Increases by one for every event of the server, so a gap means the\nclient fell behind and missed events",
+                    "type": "integer"
+                },
+                "printer": {
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "printer.status",
+                        "printer.alert",
+                        "job.queued",
+                        "job.started",
+                        "job.completed",
+                        "job.failed",
+                        "job.cancelled",
+                        "queue.depth"
+                    ]
+                }
+            }
+        },
+        "JobDto": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finishedAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "printer": {
+                    "type": "string"
+                },
+                "startedAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "queued",
+                        "printing",
+                        "done",
+                        "failed",
+                        "cancelled"
+                    ]
+                }
+            }
+        },
+        "PrinterDrawerDto": {
+            "type": "object",
+            "properties": {
+                "offMs": {
+                    "type": "integer",
+                    "maximum": 510,
+                    "minimum": 2,
+                    "example": 500
+                },
+                "onMs": {
+                    "type": "integer",
+                    "maximum": 510,
+                    "minimum": 2,
+                    "example": 50
+                },
+                "pin": {
+                    "type": "integer",
+                    "enum": [
+                        2,
+                        5
+                    ],
+                    "example": 2
+                },
+                "timeoutMs": {
+                    "description": "How long to wait for the drawer to close, default 30000 (30 s)",
+                    "type": "integer",
+                    "maximum": 300000,
+                    "minimum": 1,
+                    "example": 30000
+                },
+                "waitForClose": {
+                    "description": "Poll the drawer status after the kick until the drawer is closed again",
+                    "type": "boolean"
+                }
+            }
+        },
+        "PrinterDrawerResultDto": {
+            "type": "object",
+            "properties": {
+                "closed": {
+                    "type": "boolean"
+                },
+                "opened": {
+                    "description": "Only set with waitForClose: whether the drawer was seen open after the\nkick, whether it was closed again before the timeout and how long it took",
+                    "type": "boolean"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "waitedMs": {
+                    "type": "integer"
+                }
+            }
+        },
+        "PrinterInfoDto": {
+            "type": "object",
+            "properties": {
+                "default": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "profile": {
+                    "$ref": "#/definitions/PrinterProfileDto"
+                }
+            }
+        },
         "PrinterPrintDto": {
             "type": "object",
             "required": [
@@ -113,11 +989,13 @@ const docTemplate = `{
         "PrinterPrintTemplateDto": {
             "type": "object",
             "required": [
-                "templateFile"
+                "template"
             ],
             "properties": {
-                "templateFile": {
-                    "type": "string"
+                "template": {
+                    "description": "Name of a template in the template store, see /api/v1/templates",
+                    "type": "string",
+                    "example": "receipt"
                 },
                 "variables": {
                     "type": "object",
@@ -125,6 +1003,50 @@ const docTemplate = `{
                 }
             }
         },
+        "PrinterProfileDto": {
+            "type": "object",
+            "properties": {
+                "barcodes": {
+                    "type": "boolean"
+                },
+                "buzzer": {
+                    "type": "boolean"
+                },
+                "codePages": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "columnsFontA": {
+                    "type": "integer"
+                },
+                "columnsFontB": {
+                    "type": "integer"
+                },
+                "cutter": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "dotsPerLine": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "pdf417": {
+                    "type": "boolean"
+                },
+                "qrCode": {
+                    "type": "boolean"
+                },
+                "rasterBandHeight": {
+                    "type": "integer"
+                }
+            }
+        },
         "PrinterStatusDto": {
             "type": "object",
             "properties": {
@@ -134,11 +1056,206 @@ const docTemplate = `{
                 "errorStatus": {
                     "type": "integer"
                 },
+                "flags": {
+                    "$ref": "#/definitions/PrinterStatusFlagsDto"
+                },
+                "messages": {
+                    "description": "What needs attention, most severe first; empty when ready",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "offlineStatus": {
                     "type": "integer"
                 },
                 "printerStatus": {
+                    "description": "Raw DLE EOT 1-4 replies",
+                    "type": "integer"
+                },
+                "state": {
+                    "description": "ready, warning (the printer can print but needs attention soon) or\nerror (it cannot print until the problem is fixed)",
+                    "type": "string",
+                    "enum": [
+                        "ready",
+                        "warning",
+                        "error"
+                    ],
+                    "example": "ready"
+                }
+            }
+        },
+        "PrinterStatusFlagsDto": {
+            "type": "object",
+            "properties": {
+                "autoRecoverableError": {
+                    "type": "boolean"
+                },
+                "autocutterError": {
+                    "type": "boolean"
+                },
+                "coverOpen": {
+                    "type": "boolean"
+                },
+                "drawerOpen": {
+                    "description": "Interpreted with the printer's drawer_open_signal",
+                    "type": "boolean"
+                },
+                "feedButton": {
+                    "type": "boolean"
+                },
+                "offline": {
+                    "type": "boolean"
+                },
+                "paperEnd": {
+                    "type": "boolean"
+                },
+                "paperNearEnd": {
+                    "type": "boolean"
+                },
+                "printingStopped": {
+                    "type": "boolean"
+                },
+                "unrecoverableError": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "TemplateDto": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "hasSchema": {
+                    "description": "HasSchema: the template declares its variables in a JSON Schema",
+                    "type": "boolean"
+                },
+                "modifiedAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "receipt"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "TemplateInfoDto": {
+            "type": "object",
+            "properties": {
+                "hasSchema": {
+                    "description": "HasSchema: the template declares its variables in a JSON Schema",
+                    "type": "boolean"
+                },
+                "modifiedAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "receipt"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "TemplatePutDto": {
+            "type": "object",
+            "required": [
+                "content"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string"
+                }
+            }
+        },
+        "WebhookDeliveryDto": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "eventType": {
+                    "type": "string"
+                },
+                "finishedAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "nextAttemptAt": {
+                    "type": "string"
+                },
+                "printer": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "delivered",
+                        "failed",
+                        "dropped"
+                    ]
+                },
+                "statusCode": {
+                    "description": "HTTP status of the last attempt; absent when it got no response",
+                    "type": "integer"
+                },
+                "webhook": {
+                    "type": "string"
+                }
+            }
+        },
+        "WebhookDto": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "format": {
+                    "type": "string",
+                    "enum": [
+                        "json",
+                        "slack"
+                    ]
+                },
+                "host": {
+                    "description": "Host of the webhook URL; the rest of it may hold a token",
+                    "type": "string"
+                },
+                "maxAttempts": {
                     "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "printers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "signed": {
+                    "description": "Whether payloads are signed with a secret",
+                    "type": "boolean"
                 }
             }
         }
@@ -0,0 +1,37 @@
+package dto
+
+import "time"
+
+// EventDto is one event of the /api/v1/events stream.
+type EventDto struct {
+	// Increases by one for every event of the server, so a gap means the
+	// client fell behind and missed events
+	ID      uint64    `json:"id"`
+	Type    string    `json:"type" enums:"printer.status,printer.alert,job.queued,job.started,job.completed,job.failed,job.cancelled,queue.depth"`
+	Printer string    `json:"printer"`
+	Time    time.Time `json:"time"`
+	// JobDto for job.* events, PrinterStateDto for printer.status,
+	// PrinterAlertDto for printer.alert and QueueDepthDto for queue.depth
+	Data any `json:"data"`
+}
+
+// PrinterStateDto is the last known status of a printer.
+type PrinterStateDto struct {
+	Status PrinterStatusDto `json:"status"`
+	// The active alert conditions
+	Alerts []string `json:"alerts" enums:"unreachable,offline,cover_open,paper_near_end,paper_out,autocutter_error,unrecoverable_error"`
+	// Why the printer did not answer; status is then the last one it gave
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// PrinterAlertDto reports an alert condition that was raised or cleared.
+type PrinterAlertDto struct {
+	Alert  string `json:"alert" enums:"unreachable,offline,cover_open,paper_near_end,paper_out,autocutter_error,unrecoverable_error"`
+	Active bool   `json:"active"`
+}
+
+type QueueDepthDto struct {
+	// Jobs waiting to be printed, not counting the one that is printing
+	Queued int `json:"queued"`
+}
@@ -0,0 +1,14 @@
+package dto
+
+import "time"
+
+type JobDto struct {
+	ID         string     `json:"id"`
+	Printer    string     `json:"printer"`
+	Status     string     `json:"status" enums:"queued,printing,done,failed,cancelled"`
+	Bytes      int        `json:"bytes"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
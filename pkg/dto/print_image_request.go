@@ -1,7 +1,16 @@
 package dto
 
-// PrintImageRequest is the JSON payload for POST /api/v1/printer/print-image
+// PrintImageRequest is the JSON payload for POST /api/v1/printer/print-image.
+// MaxWidthDots defaults to the paper width of the printer's profile. Dither
+// selects threshold (the default), floyd-steinberg, atkinson or ordered;
+// brightness and contrast range from -1 to 1, gamma defaults to 1 and align
+// (left, center or right) defaults to center.
 type PrintImageRequest struct {
-	ImageBase64  string `json:"imageBase64" form:"imageBase64" binding:"required"`
-	MaxWidthDots int    `json:"maxWidthDots,omitempty" form:"maxWidthDots"`
+	ImageBase64  string  `json:"imageBase64" form:"imageBase64" binding:"required"`
+	MaxWidthDots int     `json:"maxWidthDots,omitempty" form:"maxWidthDots"`
+	Dither       string  `json:"dither,omitempty" form:"dither" binding:"omitempty,oneof=threshold floyd-steinberg atkinson ordered"`
+	Brightness   float64 `json:"brightness,omitempty" form:"brightness" binding:"min=-1,max=1"`
+	Contrast     float64 `json:"contrast,omitempty" form:"contrast" binding:"min=-1,max=1"`
+	Gamma        float64 `json:"gamma,omitempty" form:"gamma" binding:"omitempty,gt=0,max=10"`
+	Align        string  `json:"align,omitempty" form:"align" binding:"omitempty,oneof=left center right"`
 }
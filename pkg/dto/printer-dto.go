@@ -1,10 +1,36 @@
 package dto
 
 type PrinterStatusDto struct {
+	// Raw DLE EOT 1-4 replies
 	PrinterStatus         byte `json:"printerStatus"`
 	OfflineStatus         byte `json:"offlineStatus"`
 	ErrorStatus           byte `json:"errorStatus"`
 	ContinuousPaperStatus byte `json:"continuousPaperStatus"`
+
+	// ready, warning (the printer can print but needs attention soon) or
+	// error (it cannot print until the problem is fixed)
+	State string `json:"state" enums:"ready,warning,error" example:"ready"`
+	// What needs attention, most severe first; empty when ready
+	Messages []string              `json:"messages"`
+	Flags    PrinterStatusFlagsDto `json:"flags"`
+}
+
+// PrinterStatusFlagsDto is the meaning of the status bytes, one flag per bit.
+type PrinterStatusFlagsDto struct {
+	Offline bool `json:"offline"`
+	// Interpreted with the printer's drawer_open_signal
+	DrawerOpen bool `json:"drawerOpen"`
+
+	CoverOpen       bool `json:"coverOpen"`
+	FeedButton      bool `json:"feedButton"`
+	PrintingStopped bool `json:"printingStopped"`
+
+	AutocutterError      bool `json:"autocutterError"`
+	UnrecoverableError   bool `json:"unrecoverableError"`
+	AutoRecoverableError bool `json:"autoRecoverableError"`
+
+	PaperNearEnd bool `json:"paperNearEnd"`
+	PaperEnd     bool `json:"paperEnd"`
 }
 
 type PrinterPrintDto struct {
@@ -12,6 +38,53 @@ type PrinterPrintDto struct {
 }
 
 type PrinterPrintTemplateDto struct {
-	TemplateFile string         `json:"templateFile" binding:"required"`
-	Variables    map[string]any `json:"variables"`
+	// Name of a template in the template store, see /api/v1/templates
+	Template  string         `json:"template" binding:"required" example:"receipt"`
+	Variables map[string]any `json:"variables"`
+}
+
+type PrinterInfoDto struct {
+	Name    string            `json:"name"`
+	Default bool              `json:"default"`
+	Profile PrinterProfileDto `json:"profile"`
+}
+
+// PrinterProfileDto describes what the printer can do, so clients can lay out
+// content for it.
+type PrinterProfileDto struct {
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	DotsPerLine  int    `json:"dotsPerLine"`
+	ColumnsFontA int    `json:"columnsFontA"`
+	ColumnsFontB int    `json:"columnsFontB"`
+	CodePages    []int  `json:"codePages"`
+	Cutter       string `json:"cutter"`
+	Barcodes     bool   `json:"barcodes"`
+	QRCode       bool   `json:"qrCode"`
+	PDF417       bool   `json:"pdf417"`
+	Buzzer       bool   `json:"buzzer"`
+
+	RasterBandHeight int `json:"rasterBandHeight"`
+}
+
+// PrinterDrawerDto pulses the cash drawer. Zero values select pin 2 and a
+// 50 ms pulse followed by 500 ms off.
+type PrinterDrawerDto struct {
+	Pin   int `json:"pin" binding:"omitempty,oneof=2 5" example:"2"`
+	OnMs  int `json:"onMs" binding:"omitempty,min=2,max=510" example:"50"`
+	OffMs int `json:"offMs" binding:"omitempty,min=2,max=510" example:"500"`
+
+	// Poll the drawer status after the kick until the drawer is closed again
+	WaitForClose bool `json:"waitForClose"`
+	// How long to wait for the drawer to close, default 30000 (30 s)
+	TimeoutMs int `json:"timeoutMs" binding:"omitempty,min=1,max=300000" example:"30000"`
+}
+
+type PrinterDrawerResultDto struct {
+	Status string `json:"status"`
+	// Only set with waitForClose: whether the drawer was seen open after the
+	// kick, whether it was closed again before the timeout and how long it took
+	Opened   *bool `json:"opened,omitempty"`
+	Closed   *bool `json:"closed,omitempty"`
+	WaitedMs int64 `json:"waitedMs,omitempty"`
 }
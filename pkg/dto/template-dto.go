@@ -0,0 +1,20 @@
+package dto
+
+import "time"
+
+type TemplateInfoDto struct {
+	Name       string    `json:"name" example:"receipt"`
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modifiedAt"`
+	// HasSchema: the template declares its variables in a JSON Schema
+	HasSchema bool `json:"hasSchema"`
+}
+
+type TemplateDto struct {
+	TemplateInfoDto
+	Content string `json:"content"`
+}
+
+type TemplatePutDto struct {
+	Content string `json:"content" binding:"required"`
+}
@@ -0,0 +1,39 @@
+package dto
+
+import "time"
+
+type WebhookDto struct {
+	Name string `json:"name"`
+	// Host of the webhook URL; the rest of it may hold a token
+	Host     string   `json:"host"`
+	Events   []string `json:"events"`
+	Printers []string `json:"printers"`
+	Format   string   `json:"format" enums:"json,slack"`
+	// Whether payloads are signed with a secret
+	Signed      bool `json:"signed"`
+	MaxAttempts int  `json:"maxAttempts"`
+}
+
+type WebhookDeliveryDto struct {
+	ID        string `json:"id"`
+	Webhook   string `json:"webhook"`
+	EventID   uint64 `json:"eventId"`
+	EventType string `json:"eventType"`
+	Printer   string `json:"printer"`
+	Status    string `json:"status" enums:"pending,delivered,failed,dropped"`
+	Attempts  int    `json:"attempts"`
+	// HTTP status of the last attempt; absent when it got no response
+	StatusCode    int        `json:"statusCode,omitempty"`
+	Error         string     `json:"error,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty"`
+	FinishedAt    *time.Time `json:"finishedAt,omitempty"`
+}
+
+// WebhookDeliveriesQueryDto filters the delivery log.
+type WebhookDeliveriesQueryDto struct {
+	Webhook string `form:"webhook"`
+	Status  string `form:"status" binding:"omitempty,oneof=pending delivered failed dropped"`
+	// Most recent deliveries to return, default 100
+	Limit int `form:"limit" binding:"omitempty,min=1,max=1000"`
+}
@@ -0,0 +1,116 @@
+package emulator
+
+import (
+	"github.com/jonasclaes/go-thermal-printer/pkg/escpos"
+)
+
+// barcode draws a GS k command and returns its length. Function A (m 0-6)
+// data is NUL terminated, function B (m 65-73) data is length prefixed.
+func (e *Emulator) barcode(b []byte) int {
+	if len(b) < 3 {
+		return 0
+	}
+
+	var (
+		size int
+		data []byte
+	)
+	if b[2] <= 6 {
+		for i := 3; i < len(b); i++ {
+			if b[i] == 0 {
+				size, data = i+1, b[3:i]
+				break
+			}
+		}
+	} else if len(b) >= 4 {
+		size = needed(b, 4+int(b[3]))
+		if size > 0 {
+			data = b[4:size]
+		}
+	}
+	if size == 0 {
+		return 0
+	}
+
+	// Like the printer, only print barcodes at the beginning of a line
+	if len(e.line) > 0 || len(data) == 0 {
+		return size
+	}
+
+	text := string(data)
+	if escpos.BarcodeSystem(b[2]) == escpos.BarcodeCODE128 {
+		text = code128Text(data)
+	}
+
+	e.drawBarcode(data, text)
+	return size
+}
+
+// drawBarcode draws a bar pattern derived from data, at the configured
+// height and module width, with the HRI text above and/or below it.
+func (e *Emulator) drawBarcode(data []byte, text string) {
+	modules := []bool{true, false, true, true}
+	for _, c := range data {
+		for bit := 7; bit >= 0; bit-- {
+			modules = append(modules, c&(1<<bit) != 0)
+		}
+		modules = append(modules, false)
+	}
+	modules = append(modules, true, true, false, true)
+
+	width := min(len(modules)*e.barcodeWidth, e.width)
+	left := e.alignOffset(width)
+
+	hri := make([]glyph, 0, len(text))
+	hriWidth := 0
+	for _, r := range text {
+		g := glyph{r: r, style: style{font: e.hriFont, scaleW: 1, scaleH: 1}}
+		hri = append(hri, g)
+		hriWidth += g.advance()
+	}
+	hriLeft := left + max(0, (width-hriWidth)/2)
+	hriHeight := fontCells[e.hriFont].height
+
+	drawHRI := func() {
+		e.grow(e.y + hriHeight)
+		x := hriLeft
+		for _, g := range hri {
+			e.drawGlyph(x, e.y, g)
+			x += g.advance()
+		}
+		e.y += hriHeight
+	}
+
+	if e.hriPosition&int(escpos.HRIAbove) != 0 {
+		drawHRI()
+	}
+
+	e.grow(e.y + e.barcodeHeight)
+	for i, bar := range modules {
+		if bar {
+			e.fill(left+i*e.barcodeWidth, e.y, e.barcodeWidth, e.barcodeHeight, 0x00)
+		}
+	}
+	e.y += e.barcodeHeight
+
+	if e.hriPosition&int(escpos.HRIBelow) != 0 {
+		drawHRI()
+	}
+}
+
+// code128Text strips the code set selections ({A, {B, {C, ...) from CODE128
+// data; "{{" stands for a literal brace.
+func code128Text(data []byte) string {
+	text := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		if data[i] == '{' && i+1 < len(data) {
+			i++
+			if data[i] == '{' {
+				text = append(text, '{')
+			}
+			continue
+		}
+		text = append(text, data[i])
+	}
+	return string(text)
+}
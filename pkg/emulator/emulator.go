@@ -0,0 +1,613 @@
+// Package emulator interprets the ESC/POS byte stream this project sends to
+// printers and renders it onto a receipt-width bitmap, so test_mode output
+// and template previews can be inspected without wasting paper.
+//
+// The emulator understands text (decoded through the selected ESC t code
+// page), ESC ! / E / G / - / 4 / M / a / t / 3 / 2 / SP / d / J / @, GS ! and
+// GS B, GS v 0 raster images and the ESC i / ESC m / GS V cuts. Every cut
+// finishes the current receipt and starts a new one. GS ( k QR codes are
+// rendered for real; GS k barcodes and PDF417 symbols are drawn as
+// placeholders of about the right size (with HRI text) that do not scan. Other commands are skipped along with their parameters so they
+// cannot corrupt the output. DLE EOT status requests and GS a Automatic
+// Status Back are answered as a healthy printer would.
+package emulator
+
+import (
+	"image"
+	"io"
+	"sync"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/escpos"
+)
+
+const (
+	// DefaultWidthDots is the printable width of 58mm paper at 203 dpi.
+	DefaultWidthDots = 384
+
+	defaultLineSpacing = 30
+	tabWidth           = 8
+
+	// statusReady is the reply to every DLE EOT n query: only the fixed bits
+	// are set, i.e. online, cover closed, no errors and paper present.
+	statusReady = 0x12
+)
+
+// asbReady is the Automatic Status Back block of the same healthy printer.
+var asbReady = [4]byte{0x10, 0x00, 0x00, 0x00}
+
+const (
+	lf  = 0x0A
+	ht  = 0x09
+	ff  = 0x0C
+	dle = 0x10
+	esc = 0x1B
+	fs  = 0x1C
+	gs  = 0x1D
+)
+
+const (
+	alignLeft = iota
+	alignCenter
+	alignRight
+)
+
+type Options struct {
+	// WidthDots is the printable width in dots; DefaultWidthDots when zero.
+	WidthDots int
+	// OnPage receives every finished receipt. When set, receipts are handed
+	// off instead of being retained for Pages.
+	OnPage func(page *image.Gray)
+}
+
+type style struct {
+	font        int
+	bold        bool
+	underline   int
+	italic      bool
+	invert      bool
+	scaleW      int
+	scaleH      int
+	charSpacing int
+}
+
+type glyph struct {
+	r     rune
+	style style
+}
+
+// Emulator is an io.ReadWriter that behaves like an idle, healthy printer:
+// everything written is rendered and DLE EOT status requests are answered.
+// It is safe for concurrent use.
+type Emulator struct {
+	mu     sync.Mutex
+	width  int
+	onPage func(page *image.Gray)
+
+	// pending holds an incomplete command carried over to the next Write
+	pending []byte
+	replies []byte
+
+	style       style
+	align       int
+	lineSpacing int
+	codePage    escpos.CharacterCodePage
+
+	line      []glyph
+	lineWidth int
+
+	barcodeHeight int
+	barcodeWidth  int
+	hriPosition   int
+	hriFont       int
+	symbols       symbolState
+
+	canvas *image.Gray
+	y      int
+	pages  []*image.Gray
+}
+
+func New(options Options) *Emulator {
+	width := options.WidthDots
+	if width <= 0 {
+		width = DefaultWidthDots
+	}
+
+	e := &Emulator{
+		width:  width,
+		onPage: options.OnPage,
+	}
+	e.reset()
+	return e
+}
+
+// Render interprets data on a fresh emulator and returns one image per
+// receipt. Trailing output that was not followed by a cut forms the last
+// receipt.
+func Render(data []byte, widthDots int) []*image.Gray {
+	e := New(Options{WidthDots: widthDots})
+	_, _ = e.Write(data)
+	e.Flush()
+	return e.Pages()
+}
+
+// Width returns the printable width in dots.
+func (e *Emulator) Width() int {
+	return e.width
+}
+
+func (e *Emulator) Write(p []byte) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	data := append(e.pending, p...)
+	consumed := e.interpret(data)
+	e.pending = append([]byte(nil), data[consumed:]...)
+
+	return len(p), nil
+}
+
+// Read returns replies to realtime status requests. It fails with io.EOF
+// when no reply is waiting.
+func (e *Emulator) Read(p []byte) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.replies) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, e.replies)
+	e.replies = e.replies[n:]
+	return n, nil
+}
+
+// ResetInputBuffer discards unread status replies.
+func (e *Emulator) ResetInputBuffer() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.replies = nil
+	return nil
+}
+
+// Flush prints any buffered line and finishes the current receipt as if it
+// had been cut.
+func (e *Emulator) Flush() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.cut()
+}
+
+// Pages returns the finished receipts retained so far.
+func (e *Emulator) Pages() []*image.Gray {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]*image.Gray(nil), e.pages...)
+}
+
+// Close flushes the current receipt.
+func (e *Emulator) Close() error {
+	e.Flush()
+	return nil
+}
+
+func (e *Emulator) reset() {
+	e.style = style{scaleW: 1, scaleH: 1}
+	e.align = alignLeft
+	e.lineSpacing = defaultLineSpacing
+	e.codePage = escpos.CharacterCodePageDefault
+	e.barcodeHeight = escpos.DefaultBarcodeHeight
+	e.barcodeWidth = escpos.DefaultBarcodeWidth
+	e.hriPosition = int(escpos.HRINone)
+	e.hriFont = int(escpos.CharacterFontA)
+	e.symbols = defaultSymbolState()
+}
+
+// interpret executes every complete command in data and returns the number
+// of bytes consumed.
+func (e *Emulator) interpret(data []byte) int {
+	i := 0
+	for i < len(data) {
+		n := e.command(data[i:])
+		if n == 0 {
+			break
+		}
+		i += n
+	}
+	return i
+}
+
+// command executes the command at the start of b and returns its length, or
+// 0 when b ends before the command is complete.
+func (e *Emulator) command(b []byte) int {
+	switch b[0] {
+	case lf, ff:
+		e.printLine(e.lineSpacing)
+		return 1
+	case ht:
+		e.tab()
+		return 1
+	case esc:
+		return e.escCommand(b)
+	case gs:
+		return e.gsCommand(b)
+	case dle:
+		return e.dleCommand(b)
+	case fs:
+		return e.fsCommand(b)
+	}
+
+	if b[0] < 0x20 {
+		// CR and other control codes have no effect in standard mode
+		return 1
+	}
+	e.char(b[0])
+	return 1
+}
+
+// escIgnored lists the parameter count of ESC commands that do not affect
+// the rendered output.
+var escIgnored = map[byte]int{
+	'%': 1, '=': 1, '?': 1, 'B': 2, 'L': 0, 'R': 1, 'S': 0, 'T': 1, 'U': 1,
+	'V': 1, 'W': 8, '$': 2, '\\': 2, 'c': 2, 'e': 1, 'p': 3, 'r': 1, '{': 1,
+}
+
+func (e *Emulator) escCommand(b []byte) int {
+	if len(b) < 2 {
+		return 0
+	}
+
+	switch b[1] {
+	case '@':
+		e.reset()
+		return 2
+	case '2':
+		e.lineSpacing = defaultLineSpacing
+		return 2
+	case 'i', 'm':
+		e.cut()
+		return 2
+	case 'D':
+		// Tab positions, terminated by NUL
+		for i := 2; i < len(b); i++ {
+			if b[i] == 0 {
+				return i + 1
+			}
+		}
+		return 0
+	case '*':
+		if len(b) < 5 {
+			return 0
+		}
+		columns := int(b[3]) | int(b[4])<<8
+		if b[2] >= 32 {
+			columns *= 3
+		}
+		return needed(b, 5+columns)
+	}
+
+	if params, ok := escIgnored[b[1]]; ok {
+		return needed(b, 2+params)
+	}
+
+	if len(b) < 3 {
+		return 0
+	}
+	n := b[2]
+
+	switch b[1] {
+	case '!':
+		e.style.font = int(n & 0x01)
+		e.style.bold = n&0x08 != 0
+		e.style.scaleH = 1 + int(n>>4&0x01)
+		e.style.scaleW = 1 + int(n>>5&0x01)
+		e.style.underline = int(n >> 7)
+	case 'E', 'G':
+		e.style.bold = n&0x01 != 0
+	case '-':
+		e.style.underline = int(n & 0x03)
+	case '4':
+		e.style.italic = n&0x01 != 0
+	case 'M':
+		// Font C is drawn as font B
+		e.style.font = min(int(n&0x03), 1)
+	case 'a':
+		// Like ESC/POS printers, justification only changes at the
+		// beginning of a line
+		if len(e.line) == 0 {
+			e.align = int(n & 0x03)
+			if e.align > alignRight {
+				e.align = alignLeft
+			}
+		}
+	case 't':
+		e.codePage = escpos.CharacterCodePage(n)
+	case '3':
+		e.lineSpacing = int(n)
+	case ' ':
+		e.style.charSpacing = int(n)
+	case 'd':
+		e.printLine(int(n) * e.lineSpacing)
+	case 'J':
+		e.printLine(int(n))
+	default:
+		// Unknown command: skip ESC and the command byte only
+		return 2
+	}
+
+	return 3
+}
+
+// gsIgnored lists the parameter count of GS commands that do not affect the
+// rendered output.
+var gsIgnored = map[byte]int{
+	'/': 1, 'I': 1, 'P': 2, 'L': 2, 'W': 2, '$': 2, '\\': 2,
+	'b': 1, 'r': 1,
+}
+
+func (e *Emulator) gsCommand(b []byte) int {
+	if len(b) < 2 {
+		return 0
+	}
+
+	switch b[1] {
+	case 'v':
+		return e.raster(b)
+	case '(':
+		// GS ( fn pL pH d1...dk
+		if len(b) < 5 {
+			return 0
+		}
+		size := needed(b, 5+(int(b[3])|int(b[4])<<8))
+		if size >= 7 && b[2] == 'k' {
+			e.symbol(b[5], b[6], b[7:size])
+		}
+		return size
+	case '8':
+		// GS 8 L p1 p2 p3 p4 d1...dk
+		if len(b) < 7 {
+			return 0
+		}
+		return needed(b, 7+(int(b[3])|int(b[4])<<8|int(b[5])<<16|int(b[6])<<24))
+	case 'k':
+		return e.barcode(b)
+	case '*':
+		if len(b) < 4 {
+			return 0
+		}
+		return needed(b, 4+int(b[2])*int(b[3])*8)
+	}
+
+	if params, ok := gsIgnored[b[1]]; ok {
+		return needed(b, 2+params)
+	}
+
+	if len(b) < 3 {
+		return 0
+	}
+	n := b[2]
+
+	switch b[1] {
+	case '!':
+		e.style.scaleW = 1 + int(n>>4&0x07)
+		e.style.scaleH = 1 + int(n&0x07)
+	case 'B':
+		e.style.invert = n&0x01 != 0
+	case 'H':
+		e.hriPosition = int(n & 0x03)
+	case 'f':
+		e.hriFont = min(int(n&0x03), 1)
+	case 'a':
+		// GS a n: Automatic Status Back sends the status once when enabled
+		if n&0x0F != 0 {
+			e.replies = append(e.replies, asbReady[:]...)
+		}
+	case 'h':
+		if n > 0 {
+			e.barcodeHeight = int(n)
+		}
+	case 'w':
+		if n >= 2 && n <= 6 {
+			e.barcodeWidth = int(n)
+		}
+	case 'V':
+		switch n {
+		case 65, 66, 97, 98, 103, 104:
+			// Feed n dots, then cut
+			if len(b) < 4 {
+				return 0
+			}
+			e.printLine(int(b[3]))
+			e.cut()
+			return 4
+		}
+		e.cut()
+	default:
+		return 2
+	}
+
+	return 3
+}
+
+func (e *Emulator) dleCommand(b []byte) int {
+	if len(b) < 2 {
+		return 0
+	}
+
+	switch b[1] {
+	case 0x04:
+		// DLE EOT n: transmit realtime status
+		if len(b) < 3 {
+			return 0
+		}
+		e.replies = append(e.replies, statusReady)
+		return 3
+	case 0x05:
+		return needed(b, 3)
+	case 0x14:
+		return needed(b, 5)
+	}
+
+	return 1
+}
+
+func (e *Emulator) fsCommand(b []byte) int {
+	if len(b) < 2 {
+		return 0
+	}
+
+	switch b[1] {
+	case 'p':
+		return needed(b, 4)
+	case 'C', '!', '-', 'W':
+		return needed(b, 3)
+	}
+
+	return 2
+}
+
+// needed returns n when b holds at least n bytes, otherwise 0.
+func needed(b []byte, n int) int {
+	if len(b) < n {
+		return 0
+	}
+	return n
+}
+
+func (e *Emulator) char(c byte) {
+	r := rune(c)
+	if c >= 0x80 {
+		r = '?'
+		if cm := e.codePage.Charmap(); cm != nil {
+			r = cm.DecodeByte(c)
+		}
+	}
+
+	g := glyph{r: r, style: e.style}
+	advance := g.advance()
+	if len(e.line) > 0 && e.lineWidth+advance > e.width {
+		// Line buffer full: the printer prints it and continues on a new line
+		e.printLine(e.lineSpacing)
+	}
+
+	e.line = append(e.line, g)
+	e.lineWidth += advance
+}
+
+func (e *Emulator) tab() {
+	spaces := tabWidth - len(e.line)%tabWidth
+	for range spaces {
+		e.char(' ')
+	}
+}
+
+// printLine prints the line buffer at the current position and feeds the
+// paper by feed dots, or by the height of the line if that is larger.
+func (e *Emulator) printLine(feed int) {
+	if len(e.line) == 0 {
+		e.y += feed
+		return
+	}
+
+	height := 0
+	for _, g := range e.line {
+		height = max(height, g.height())
+	}
+
+	e.grow(e.y + height)
+	x := e.alignOffset(e.lineWidth)
+	for _, g := range e.line {
+		// Characters of different sizes share the baseline at the bottom
+		e.drawGlyph(x, e.y+height-g.height(), g)
+		x += g.advance()
+	}
+
+	e.line = e.line[:0]
+	e.lineWidth = 0
+	e.y += max(feed, height)
+}
+
+func (e *Emulator) alignOffset(width int) int {
+	switch e.align {
+	case alignCenter:
+		return max(0, (e.width-width)/2)
+	case alignRight:
+		return max(0, e.width-width)
+	default:
+		return 0
+	}
+}
+
+// raster draws a GS v 0 m xL xH yL yH d1...dk image.
+func (e *Emulator) raster(b []byte) int {
+	if len(b) < 8 {
+		return 0
+	}
+	if b[2] != '0' && b[2] != 0 {
+		return 3
+	}
+
+	mode := b[3] & 0x03
+	widthBytes := int(b[4]) | int(b[5])<<8
+	height := int(b[6]) | int(b[7])<<8
+	size := 8 + widthBytes*height
+	if len(b) < size {
+		return 0
+	}
+
+	if len(e.line) > 0 {
+		e.printLine(e.lineSpacing)
+	}
+
+	scaleW, scaleH := 1, 1
+	if mode&0x01 != 0 {
+		scaleW = 2
+	}
+	if mode&0x02 != 0 {
+		scaleH = 2
+	}
+
+	data := b[8:size]
+	left := e.alignOffset(widthBytes * 8 * scaleW)
+	e.grow(e.y + height*scaleH)
+	for row := 0; row < height; row++ {
+		for col := 0; col < widthBytes*8; col++ {
+			if data[row*widthBytes+col/8]&(0x80>>(col%8)) == 0 {
+				continue
+			}
+			e.fill(left+col*scaleW, e.y+row*scaleH, scaleW, scaleH, 0x00)
+		}
+	}
+	e.y += height * scaleH
+
+	return size
+}
+
+// cut prints the line buffer and finishes the current receipt. A cut on
+// blank paper does not produce an empty receipt.
+func (e *Emulator) cut() {
+	if len(e.line) > 0 {
+		e.printLine(e.lineSpacing)
+	}
+	if e.y == 0 {
+		return
+	}
+
+	page := image.NewGray(image.Rect(0, 0, e.width, e.y))
+	for i := range page.Pix {
+		page.Pix[i] = 0xFF
+	}
+	if e.canvas != nil {
+		copy(page.Pix, e.canvas.Pix[:min(len(page.Pix), len(e.canvas.Pix))])
+	}
+
+	e.canvas = nil
+	e.y = 0
+
+	if e.onPage != nil {
+		e.onPage(page)
+		return
+	}
+	e.pages = append(e.pages, page)
+}
@@ -0,0 +1,366 @@
+package emulator
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/escpos"
+)
+
+var update = flag.Bool("update", false, "rewrite golden images in testdata")
+
+// inkBounds returns the bounding box of all black dots on the page.
+func inkBounds(page *image.Gray) image.Rectangle {
+	var bounds image.Rectangle
+	for y := page.Rect.Min.Y; y < page.Rect.Max.Y; y++ {
+		for x := page.Rect.Min.X; x < page.Rect.Max.X; x++ {
+			if page.GrayAt(x, y).Y < 0x80 {
+				bounds = bounds.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return bounds
+}
+
+func inkCount(page *image.Gray) int {
+	count := 0
+	for _, v := range page.Pix {
+		if v < 0x80 {
+			count++
+		}
+	}
+	return count
+}
+
+func renderOne(t *testing.T, data []byte) *image.Gray {
+	t.Helper()
+
+	pages := Render(data, DefaultWidthDots)
+	if len(pages) != 1 {
+		t.Fatalf("expected 1 page, got %d", len(pages))
+	}
+	return pages[0]
+}
+
+func TestRenderTextUsesReceiptWidthAndLineSpacing(t *testing.T) {
+	page := renderOne(t, []byte("Hello\nWorld\n"))
+
+	if page.Rect.Dx() != DefaultWidthDots {
+		t.Fatalf("expected width %d, got %d", DefaultWidthDots, page.Rect.Dx())
+	}
+	if page.Rect.Dy() != 2*defaultLineSpacing {
+		t.Fatalf("expected two lines of %d dots, got height %d", defaultLineSpacing, page.Rect.Dy())
+	}
+
+	ink := inkBounds(page)
+	if ink.Empty() || ink.Min.X > 2 || ink.Max.X > 5*12 {
+		t.Fatalf("expected left aligned 12 dot wide characters, ink at %v", ink)
+	}
+}
+
+func TestRenderCutsSplitPages(t *testing.T) {
+	data := []byte("first\n")
+	data = append(data, 0x1D, 0x56, 0x00)
+	data = append(data, "second\n"...)
+	data = append(data, 0x1B, 0x6D)
+	// A second cut on blank paper must not produce an empty receipt
+	data = append(data, 0x1D, 0x56, 0x01)
+
+	pages := Render(data, DefaultWidthDots)
+	if len(pages) != 2 {
+		t.Fatalf("expected 2 pages, got %d", len(pages))
+	}
+	for i, page := range pages {
+		if inkCount(page) == 0 {
+			t.Fatalf("page %d is blank", i)
+		}
+	}
+}
+
+func TestRenderAlignment(t *testing.T) {
+	center := inkBounds(renderOne(t, []byte("\x1B\x61\x01WIDE\n")))
+	right := inkBounds(renderOne(t, []byte("\x1B\x61\x02WIDE\n")))
+
+	if mid := (center.Min.X + center.Max.X) / 2; mid < DefaultWidthDots/2-12 || mid > DefaultWidthDots/2+12 {
+		t.Fatalf("expected centered text, ink at %v", center)
+	}
+	if right.Max.X < DefaultWidthDots-12 {
+		t.Fatalf("expected right aligned text, ink at %v", right)
+	}
+
+	// ESC a in the middle of a line only affects the next line, so the
+	// center helper's trailing reset does not undo the centering
+	centered := inkBounds(renderOne(t, []byte("\x1B\x61\x01WIDE\x1B\x61\x00\n")))
+	if centered != center {
+		t.Fatalf("expected justification to be taken at the start of the line, ink at %v", centered)
+	}
+}
+
+func TestRenderTextStyles(t *testing.T) {
+	plain := renderOne(t, []byte("Text\n"))
+	bold := renderOne(t, []byte("\x1B\x45\x01Text\n"))
+	if inkCount(bold) <= inkCount(plain) {
+		t.Fatalf("expected emphasized text to be darker: %d <= %d", inkCount(bold), inkCount(plain))
+	}
+
+	doubled := renderOne(t, []byte("\x1D\x21\x11Text\n"))
+	plainInk, doubledInk := inkBounds(plain), inkBounds(doubled)
+	if doubledInk.Dx() < 2*plainInk.Dx()-2 || doubledInk.Dy() < 2*plainInk.Dy()-2 {
+		t.Fatalf("expected double size text, got %v vs %v", doubledInk, plainInk)
+	}
+
+	fontB := inkBounds(renderOne(t, []byte("\x1B\x4D\x01Text\n")))
+	if fontB.Dx() >= plainInk.Dx() {
+		t.Fatalf("expected font B to be narrower than font A: %v vs %v", fontB, plainInk)
+	}
+
+	underlined := renderOne(t, []byte("\x1B\x2D\x02    \n"))
+	if ink := inkBounds(underlined); ink.Dx() != 4*12 || ink.Dy() != 2 {
+		t.Fatalf("expected 2 dot underline under 4 spaces, got %v", ink)
+	}
+
+	inverted := renderOne(t, []byte("\x1D\x42\x01    \n"))
+	if inkCount(inverted) != 4*12*24 {
+		t.Fatalf("expected inverted spaces to be solid black, got %d dots", inkCount(inverted))
+	}
+}
+
+func TestRenderRaster(t *testing.T) {
+	// 16x2 image: first row all black, second row only the leftmost dot
+	data := []byte{0x1D, 0x76, 0x30, 0x00, 0x02, 0x00, 0x02, 0x00, 0xFF, 0xFF, 0x80, 0x00}
+
+	page := renderOne(t, data)
+	if page.Rect.Dy() != 2 {
+		t.Fatalf("expected a 2 dot tall page, got %d", page.Rect.Dy())
+	}
+	if inkCount(page) != 17 {
+		t.Fatalf("expected 17 black dots, got %d", inkCount(page))
+	}
+	if page.GrayAt(0, 1).Y != 0x00 || page.GrayAt(1, 1).Y != 0xFF {
+		t.Fatal("second row rendered incorrectly")
+	}
+
+	// Double width and height, centered
+	data[3] = 0x03
+	page = renderOne(t, append([]byte{0x1B, 0x61, 0x01}, data...))
+	if ink := inkBounds(page); ink != image.Rect(DefaultWidthDots/2-16, 0, DefaultWidthDots/2+16, 4) {
+		t.Fatalf("unexpected scaled raster bounds %v", ink)
+	}
+}
+
+func TestEmulatorReassemblesCommandsAcrossWrites(t *testing.T) {
+	e := New(Options{})
+	raster := []byte{0x1D, 0x76, 0x30, 0x00, 0x01, 0x00, 0x01, 0x00, 0xFF}
+	for _, b := range raster {
+		if _, err := e.Write([]byte{b}); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	e.Flush()
+
+	pages := e.Pages()
+	if len(pages) != 1 || inkCount(pages[0]) != 8 {
+		t.Fatalf("expected one page with 8 dots, got %d pages", len(pages))
+	}
+}
+
+func TestEmulatorSkipsUnrenderedCommands(t *testing.T) {
+	var data []byte
+	data = append(data, 0x1B, 0x70, 0x00, 0x19, 0xFA)                   // ESC p drawer kick
+	data = append(data, 0x1D, 0x28, 0x6B, 0x03, 0x00, 0x31, 0x43, 0x41) // GS ( k
+	data = append(data, 0x1D, 0x48, 0x02, 0x1D, 0x68, 0x50)             // GS H, GS h
+	data = append(data, 0x1B, 0x56, 0x01)                               // ESC V
+
+	if pages := Render(data, DefaultWidthDots); len(pages) != 0 {
+		t.Fatalf("expected no output, got %d pages", len(pages))
+	}
+}
+
+func TestRenderBarcodePlaceholder(t *testing.T) {
+	var data []byte
+	data = append(data, 0x1D, 0x68, 0x50)                                // GS h 80
+	data = append(data, 0x1D, 0x77, 0x02)                                // GS w 2
+	data = append(data, 0x1D, 0x48, 0x02)                                // GS H below
+	data = append(data, 0x1D, 0x6B, 0x49, 0x05, '{', 'B', 'A', 'B', 'C') // GS k CODE128
+
+	page := renderOne(t, data)
+	if want := 80 + 24; page.Rect.Dy() != want {
+		t.Fatalf("expected barcode plus HRI line of %d dots, got %d", want, page.Rect.Dy())
+	}
+	if page.GrayAt(0, 40).Y != 0x00 {
+		t.Fatal("expected bars at the left edge")
+	}
+
+	// Function A data is NUL terminated; without HRI only the bars remain
+	page = renderOne(t, []byte{0x1D, 0x6B, 0x04, 'A', 'B', 'C', 0x00})
+	if page.Rect.Dy() != escpos.DefaultBarcodeHeight {
+		t.Fatalf("expected default barcode height, got %d", page.Rect.Dy())
+	}
+}
+
+func TestEmulatorAnswersStatusRequests(t *testing.T) {
+	printer := escpos.NewESCPOS(New(Options{}))
+
+	status, err := printer.PrinterStatus()
+	if err != nil {
+		t.Fatalf("status failed: %v", err)
+	}
+	if status != statusReady {
+		t.Fatalf("expected status %#x, got %#x", statusReady, status)
+	}
+
+	offline, err := printer.IsOffline()
+	if err != nil || offline {
+		t.Fatalf("expected printer to be online, got %v, %v", offline, err)
+	}
+}
+
+func TestEmulatorSendsAutomaticStatusBack(t *testing.T) {
+	demux := escpos.NewStatusDemux(New(Options{}), time.Second)
+	defer demux.Close()
+	printer := escpos.NewESCPOS(demux)
+
+	if _, err := printer.AutomaticStatusBack(escpos.ASBAll); err != nil {
+		t.Fatalf("enable failed: %v", err)
+	}
+	select {
+	case block := <-demux.Blocks():
+		if block.Decode() != (escpos.Status{}) {
+			t.Fatalf("expected a ready block, got %x", block)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the status block")
+	}
+
+	status, err := printer.PrinterStatus()
+	if err != nil || status != statusReady {
+		t.Fatalf("expected status %#x, got %#x, %v", statusReady, status, err)
+	}
+}
+
+func TestEmulatorHandsPagesToOnPage(t *testing.T) {
+	dir := t.TempDir()
+	e := New(Options{OnPage: PNGWriter(dir, "test")})
+
+	if _, err := e.Write([]byte("receipt\n\x1B\x6D")); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if len(e.Pages()) != 0 {
+		t.Fatal("expected pages to be handed off instead of retained")
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "test-*.png"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one saved receipt, got %v (%v)", files, err)
+	}
+}
+
+func TestRenderGolden(t *testing.T) {
+	var data []byte
+	data = append(data, 0x1B, 0x40)
+	data = append(data, "\x1B\x61\x01\x1D\x21\x11RECEIPT\x1D\x21\x00\n"...)
+	data = append(data, "\x1B\x61\x00Coffee            3.50\n"...)
+	data = append(data, "\x1B\x45\x01Total             3.50\x1B\x45\x00\n"...)
+	data = append(data, "\x1B\x4D\x01Font B \x1B\x2D\x01underlined\x1B\x2D\x00 text\x1B\x4D\x00\n"...)
+	data = append(data, "\x1D\x42\x01 inverted \x1D\x42\x00 \x1B\x34\x01italic\x1B\x34\x00\n"...)
+	data = append(data, 0x1B, 0x64, 0x02, 0x1D, 0x56, 0x00)
+
+	page := renderOne(t, data)
+
+	var got bytes.Buffer
+	if err := png.Encode(&got, page); err != nil {
+		t.Fatalf("failed to encode page: %v", err)
+	}
+
+	golden := filepath.Join("testdata", "receipt.golden.png")
+	if *update {
+		if err := os.WriteFile(golden, got.Bytes(), 0o644); err != nil {
+			t.Fatalf("failed to update golden image: %v", err)
+		}
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("failed to read golden image (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Fatalf("rendered receipt differs from %s; inspect it and run with -update if the change is intended", golden)
+	}
+}
+
+func TestWritePNGStacksReceipts(t *testing.T) {
+	pages := Render([]byte("one\n\x1B\x6Dtwo\n\x1B\x6D"), DefaultWidthDots)
+
+	var out bytes.Buffer
+	if err := WritePNG(&out, pages); err != nil {
+		t.Fatalf("WritePNG failed: %v", err)
+	}
+	img, err := png.Decode(&out)
+	if err != nil {
+		t.Fatalf("invalid png: %v", err)
+	}
+	if want := pages[0].Rect.Dy() + cutGap + pages[1].Rect.Dy(); img.Bounds().Dy() != want {
+		t.Fatalf("expected height %d, got %d", want, img.Bounds().Dy())
+	}
+}
+
+func TestWritePDFOnePagePerReceipt(t *testing.T) {
+	pages := Render([]byte("one\n\x1D\x56\x00two\n\x1D\x56\x00three\n"), 576)
+
+	var out bytes.Buffer
+	if err := WritePDF(&out, pages); err != nil {
+		t.Fatalf("WritePDF failed: %v", err)
+	}
+	pdf := out.Bytes()
+
+	if !bytes.HasPrefix(pdf, []byte("%PDF-")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatal("output is not a PDF document")
+	}
+	if count := bytes.Count(pdf, []byte("/Type /Page ")); count != 3 {
+		t.Fatalf("expected 3 pages, got %d", count)
+	}
+	// 576 dots at 203 dpi is 80mm paper, i.e. about 204pt wide
+	if !bytes.Contains(pdf, []byte("/MediaBox [0 0 204.30 ")) {
+		t.Fatal("expected pages sized to the paper width")
+	}
+}
+
+func TestRenderNativeQRCode(t *testing.T) {
+	command, err := escpos.QRCodeCommand("hello", 4, escpos.QRErrorCorrectionL)
+	if err != nil {
+		t.Fatalf("qr command failed: %v", err)
+	}
+
+	page := renderOne(t, append([]byte{0x1B, 0x61, 0x01}, command...))
+
+	// "hello" at level L is a version 1 symbol of 21x21 modules
+	bounds := inkBounds(page)
+	if bounds.Dx() != 84 || bounds.Dy() != 84 {
+		t.Fatalf("expected an 84x84 dot symbol, got %v", bounds)
+	}
+	if want := (DefaultWidthDots - 84) / 2; bounds.Min.X != want {
+		t.Fatalf("expected centered symbol at x=%d, got %d", want, bounds.Min.X)
+	}
+	// Finder pattern: a 7 module dark ring around a light ring
+	if page.GrayAt(bounds.Min.X+2, bounds.Min.Y+2).Y != 0x00 || page.GrayAt(bounds.Min.X+6, bounds.Min.Y+6).Y != 0xFF {
+		t.Fatal("expected a finder pattern in the top left corner")
+	}
+}
+
+func TestRenderPDF417Placeholder(t *testing.T) {
+	command, err := escpos.PDF417Command("hello", escpos.PDF417Options{})
+	if err != nil {
+		t.Fatalf("pdf417 command failed: %v", err)
+	}
+
+	page := renderOne(t, command)
+	if page.GrayAt(0, 0).Y != 0x00 || inkCount(page) == 0 {
+		t.Fatal("expected a placeholder starting at the left edge")
+	}
+}
@@ -0,0 +1,97 @@
+package emulator
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"io"
+)
+
+// dotsPerInch is the resolution of common thermal print heads (8 dots/mm).
+const dotsPerInch = 203
+
+// WritePDF encodes the receipts as a PDF with one page per receipt, sized so
+// the PDF prints at the physical size of the thermal paper.
+func WritePDF(w io.Writer, pages []*image.Gray) error {
+	if len(pages) == 0 {
+		return fmt.Errorf("nothing to render")
+	}
+
+	// Object numbers: 1 catalog, 2 page tree, then page, content stream and
+	// image for every receipt.
+	pageID := func(i int) int { return 3 + 3*i }
+
+	var out bytes.Buffer
+	offsets := make([]int, 3+3*len(pages))
+	object := func(id int, dict string, stream []byte) {
+		offsets[id] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\n", id, dict)
+		if stream != nil {
+			out.WriteString("stream\n")
+			out.Write(stream)
+			out.WriteString("\nendstream\n")
+		}
+		out.WriteString("endobj\n")
+	}
+
+	out.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	object(1, "<< /Type /Catalog /Pages 2 0 R >>", nil)
+
+	var kids bytes.Buffer
+	for i := range pages {
+		fmt.Fprintf(&kids, "%d 0 R ", pageID(i))
+	}
+	object(2, fmt.Sprintf("<< /Type /Pages /Kids [ %s] /Count %d >>", kids.String(), len(pages)), nil)
+
+	for i, page := range pages {
+		width, height := page.Rect.Dx(), page.Rect.Dy()
+		widthPt := float64(width) * 72 / dotsPerInch
+		heightPt := float64(height) * 72 / dotsPerInch
+
+		pixels, err := deflate(page)
+		if err != nil {
+			return err
+		}
+
+		id := pageID(i)
+		object(id, fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Contents %d 0 R /Resources << /XObject << /Im0 %d 0 R >> >> >>",
+			widthPt, heightPt, id+1, id+2,
+		), nil)
+
+		content := fmt.Appendf(nil, "q %.2f 0 0 %.2f 0 0 cm /Im0 Do Q", widthPt, heightPt)
+		object(id+1, fmt.Sprintf("<< /Length %d >>", len(content)), content)
+
+		object(id+2, fmt.Sprintf(
+			"<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>",
+			width, height, len(pixels),
+		), pixels)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets))
+	for _, offset := range offsets[1:] {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets), xref)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+func deflate(page *image.Gray) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	for row := 0; row < page.Rect.Dy(); row++ {
+		offset := row * page.Stride
+		if _, err := zw.Write(page.Pix[offset : offset+page.Rect.Dx()]); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,88 @@
+package emulator
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// PNGWriter returns an Options.OnPage callback that saves every receipt as
+// dir/<prefix>-<timestamp>-<n>.png. Failures are logged since the printer
+// has no caller to report them to.
+func PNGWriter(dir, prefix string) func(page *image.Gray) {
+	var sequence atomic.Int64
+
+	return func(page *image.Gray) {
+		name := fmt.Sprintf("%s-%s-%d.png", prefix, time.Now().Format("20060102-150405"), sequence.Add(1))
+		path := filepath.Join(dir, name)
+		if err := savePNG(path, page); err != nil {
+			log.Printf("emulator: failed to save receipt: %v", err)
+			return
+		}
+		log.Printf("emulator: saved receipt to %s", path)
+	}
+}
+
+func savePNG(path string, page image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(file, page); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// cutGap is the space between receipts in a combined PNG; a dashed line in
+// its middle marks the cut.
+const cutGap = 16
+
+// WritePNG encodes the receipts as a single PNG, stacked top to bottom with
+// a dashed line where the paper would have been cut.
+func WritePNG(w io.Writer, pages []*image.Gray) error {
+	if len(pages) == 0 {
+		return fmt.Errorf("nothing to render")
+	}
+
+	width, height := 0, (len(pages)-1)*cutGap
+	for _, page := range pages {
+		width = max(width, page.Rect.Dx())
+		height += page.Rect.Dy()
+	}
+
+	sheet := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range sheet.Pix {
+		sheet.Pix[i] = 0xFF
+	}
+
+	y := 0
+	for i, page := range pages {
+		if i > 0 {
+			line := y + cutGap/2
+			for x := 0; x < width; x += 8 {
+				for dx := x; dx < min(x+4, width); dx++ {
+					sheet.Pix[sheet.PixOffset(dx, line)] = 0x80
+				}
+			}
+			y += cutGap
+		}
+		for row := 0; row < page.Rect.Dy(); row++ {
+			copy(sheet.Pix[sheet.PixOffset(0, y+row):], page.Pix[row*page.Stride:row*page.Stride+page.Rect.Dx()])
+		}
+		y += page.Rect.Dy()
+	}
+
+	return png.Encode(w, sheet)
+}
@@ -0,0 +1,173 @@
+package emulator
+
+import (
+	"image"
+	"sync"
+	"unicode"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/text/unicode/norm"
+)
+
+// fontCell is the character cell of a printer font in dots. Glyphs are
+// rasterized from Go Mono at a size whose advance matches the cell width.
+type fontCell struct {
+	width  int
+	height int
+	size   float64
+}
+
+var fontCells = [...]fontCell{
+	{width: 12, height: 24, size: 20}, // Font A
+	{width: 9, height: 17, size: 15},  // Font B
+}
+
+type glyphKey struct {
+	font int
+	r    rune
+}
+
+var (
+	facesOnce  sync.Once
+	faces      [len(fontCells)]font.Face
+	glyphMu    sync.Mutex
+	glyphCache = make(map[glyphKey]*image.Alpha)
+)
+
+func loadFaces() {
+	parsed, err := opentype.Parse(gomono.TTF)
+	if err != nil {
+		panic("emulator: failed to parse Go Mono: " + err.Error())
+	}
+	for i, cell := range fontCells {
+		face, err := opentype.NewFace(parsed, &opentype.FaceOptions{
+			Size:    cell.size,
+			DPI:     72,
+			Hinting: font.HintingFull,
+		})
+		if err != nil {
+			panic("emulator: failed to create font face: " + err.Error())
+		}
+		faces[i] = face
+	}
+}
+
+// glyphMask returns the unscaled bitmap of r in the given font's cell.
+func glyphMask(fontIndex int, r rune) *image.Alpha {
+	facesOnce.Do(loadFaces)
+
+	glyphMu.Lock()
+	defer glyphMu.Unlock()
+
+	key := glyphKey{font: fontIndex, r: r}
+	if mask, ok := glyphCache[key]; ok {
+		return mask
+	}
+
+	cell := fontCells[fontIndex]
+	face := faces[fontIndex]
+	if _, ok := face.GlyphAdvance(r); !ok {
+		r = fallbackRune(r)
+	}
+
+	mask := image.NewAlpha(image.Rect(0, 0, cell.width, cell.height))
+	drawer := font.Drawer{
+		Dst:  mask,
+		Src:  image.Opaque,
+		Face: face,
+		Dot:  fixed.P(0, cell.height-face.Metrics().Descent.Ceil()),
+	}
+	drawer.DrawString(string(r))
+
+	glyphCache[key] = mask
+	return mask
+}
+
+// fallbackRune strips accents from runes the font cannot draw, or returns '?'.
+func fallbackRune(r rune) rune {
+	for _, base := range norm.NFD.String(string(r)) {
+		if base < 0x80 && !unicode.Is(unicode.Mn, base) {
+			return base
+		}
+	}
+	return '?'
+}
+
+func (g glyph) advance() int {
+	return (fontCells[g.style.font].width + g.style.charSpacing) * g.style.scaleW
+}
+
+func (g glyph) height() int {
+	return fontCells[g.style.font].height * g.style.scaleH
+}
+
+func (e *Emulator) drawGlyph(x, top int, g glyph) {
+	cell := fontCells[g.style.font]
+	scaleW, scaleH := g.style.scaleW, g.style.scaleH
+
+	ink := byte(0x00)
+	if g.style.invert {
+		e.fill(x, top, g.advance(), g.height(), 0x00)
+		ink = 0xFF
+	}
+
+	mask := glyphMask(g.style.font, g.r)
+	for py := 0; py < cell.height; py++ {
+		shift := 0
+		if g.style.italic {
+			shift = (cell.height - 1 - py) / 4
+		}
+		for px := 0; px < cell.width; px++ {
+			if mask.AlphaAt(px, py).A < 0x80 {
+				continue
+			}
+			dx := x + (px+shift)*scaleW
+			dy := top + py*scaleH
+			e.fill(dx, dy, scaleW, scaleH, ink)
+			if g.style.bold {
+				// Emphasized text is struck twice, one dot apart
+				e.fill(dx+1, dy, scaleW, scaleH, ink)
+			}
+		}
+	}
+
+	if g.style.underline > 0 {
+		thickness := g.style.underline * scaleH
+		e.fill(x, top+g.height()-thickness, g.advance(), thickness, ink)
+	}
+}
+
+// grow makes sure the canvas is at least height dots tall.
+func (e *Emulator) grow(height int) {
+	if e.canvas != nil && e.canvas.Rect.Dy() >= height {
+		return
+	}
+
+	size := max(height, 256)
+	if e.canvas != nil {
+		size = max(size, 2*e.canvas.Rect.Dy())
+	}
+
+	canvas := image.NewGray(image.Rect(0, 0, e.width, size))
+	for i := range canvas.Pix {
+		canvas.Pix[i] = 0xFF
+	}
+	if e.canvas != nil {
+		copy(canvas.Pix, e.canvas.Pix)
+	}
+	e.canvas = canvas
+}
+
+// fill paints a rectangle, clipped to the canvas.
+func (e *Emulator) fill(x, y, width, height int, value byte) {
+	rect := image.Rect(x, y, x+width, y+height).Intersect(e.canvas.Rect)
+	for row := rect.Min.Y; row < rect.Max.Y; row++ {
+		offset := e.canvas.PixOffset(rect.Min.X, row)
+		for i := range rect.Dx() {
+			e.canvas.Pix[offset+i] = value
+		}
+	}
+}
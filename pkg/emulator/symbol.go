@@ -0,0 +1,138 @@
+package emulator
+
+import (
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+const (
+	symbolPDF417 = 48
+	symbolQR     = 49
+
+	// Function numbers of GS ( k
+	symbolModuleSize = 67
+	symbolRowHeight  = 68
+	symbolErrorLevel = 69
+	symbolStore      = 80
+	symbolPrint      = 81
+)
+
+type symbolState struct {
+	qrModuleSize int
+	qrLevel      byte
+	qrData       []byte
+
+	pdfModuleWidth int
+	pdfRowHeight   int
+	pdfData        []byte
+}
+
+func defaultSymbolState() symbolState {
+	return symbolState{qrModuleSize: 3, qrLevel: 48, pdfModuleWidth: 3, pdfRowHeight: 3}
+}
+
+// symbol executes a GS ( k cn fn [params] function.
+func (e *Emulator) symbol(cn, fn byte, params []byte) {
+	var param byte
+	if len(params) > 0 {
+		param = params[0]
+	}
+
+	switch cn {
+	case symbolQR:
+		switch fn {
+		case symbolModuleSize:
+			if param >= 1 && param <= 16 {
+				e.symbols.qrModuleSize = int(param)
+			}
+		case symbolErrorLevel:
+			e.symbols.qrLevel = param
+		case symbolStore:
+			if len(params) > 0 {
+				e.symbols.qrData = append([]byte(nil), params[1:]...)
+			}
+		case symbolPrint:
+			e.printQR()
+		}
+	case symbolPDF417:
+		switch fn {
+		case symbolModuleSize:
+			if param >= 2 && param <= 8 {
+				e.symbols.pdfModuleWidth = int(param)
+			}
+		case symbolRowHeight:
+			if param >= 2 && param <= 8 {
+				e.symbols.pdfRowHeight = int(param)
+			}
+		case symbolStore:
+			if len(params) > 0 {
+				e.symbols.pdfData = append([]byte(nil), params[1:]...)
+			}
+		case symbolPrint:
+			e.printPDF417()
+		}
+	}
+}
+
+func (e *Emulator) printQR() {
+	if len(e.line) > 0 || len(e.symbols.qrData) == 0 {
+		return
+	}
+
+	level := qrcode.Medium
+	switch e.symbols.qrLevel {
+	case 48:
+		level = qrcode.Low
+	case 50:
+		level = qrcode.High
+	case 51:
+		level = qrcode.Highest
+	}
+
+	code, err := qrcode.New(string(e.symbols.qrData), level)
+	if err != nil {
+		// The printer does not print symbols it cannot encode
+		return
+	}
+	code.DisableBorder = true
+	bitmap := code.Bitmap()
+
+	size := e.symbols.qrModuleSize
+	left := e.alignOffset(len(bitmap) * size)
+	e.grow(e.y + len(bitmap)*size)
+	for row, modules := range bitmap {
+		for col, dark := range modules {
+			if dark {
+				e.fill(left+col*size, e.y+row*size, size, size, 0x00)
+			}
+		}
+	}
+	e.y += len(bitmap) * size
+}
+
+// printPDF417 draws a placeholder of roughly the right size; it is not a
+// scannable symbol.
+func (e *Emulator) printPDF417() {
+	data := e.symbols.pdfData
+	if len(e.line) > 0 || len(data) == 0 {
+		return
+	}
+
+	module := e.symbols.pdfModuleWidth
+	rowHeight := module * e.symbols.pdfRowHeight
+	rows := max(3, len(data)/4+2)
+	columns := min(e.width/module, 34+17*min(len(data), 8))
+	left := e.alignOffset(columns * module)
+
+	e.grow(e.y + rows*rowHeight)
+	for row := range rows {
+		for col := range columns {
+			c := data[(row+col/8)%len(data)]
+			// Start and stop patterns frame the data modules
+			dark := col < 2 || col >= columns-2 || c&(0x80>>(col%8)) != 0
+			if dark {
+				e.fill(left+col*module, e.y+row*rowHeight, module, rowHeight, 0x00)
+			}
+		}
+	}
+	e.y += rows * rowHeight
+}
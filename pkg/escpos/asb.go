@@ -0,0 +1,301 @@
+package escpos
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// ASBFlags select which changes make the printer send an Automatic Status
+// Back block (GS a n).
+type ASBFlags byte
+
+const (
+	ASBDrawer        ASBFlags = 0x01
+	ASBOnlineOffline ASBFlags = 0x02
+	ASBErrors        ASBFlags = 0x04
+	ASBPaperSensor   ASBFlags = 0x08
+	ASBAll                    = ASBDrawer | ASBOnlineOffline | ASBErrors | ASBPaperSensor
+)
+
+// AutomaticStatusBackCommand returns GS a n, which makes the printer send a
+// 4-byte status block whenever one of the selected conditions changes, and
+// once right away. Zero flags turn Automatic Status Back off. ESC @ also
+// turns it off on most printers, so it has to be enabled again after
+// initializing the printer.
+func AutomaticStatusBackCommand(flags ASBFlags) []byte {
+	return []byte{0x1D, 0x61, byte(flags & ASBAll)}
+}
+
+func (p *ESCPOS) AutomaticStatusBack(flags ASBFlags) (int, error) {
+	return p.Write(AutomaticStatusBackCommand(flags))
+}
+
+// Bits of an Automatic Status Back block. The error bits of the second byte
+// are those of the ErrorStatus reply.
+const (
+	asbDrawerSignal byte = 0x04
+	asbOffline      byte = 0x08
+	asbCoverOpen    byte = 0x20
+	asbFeedButton   byte = 0x40
+
+	asbErrors byte = 0x6C
+
+	asbPaperNearEnd byte = 0x03
+	asbPaperEnd     byte = 0x0C
+
+	// statusFixedBits are set in every DLE EOT reply
+	statusFixedBits byte = 0x12
+)
+
+// ASBStatus is an Automatic Status Back block.
+type ASBStatus [4]byte
+
+// StatusBytes returns the replies PrinterStatus, OfflineStatus, ErrorStatus
+// and ContinuousPaperStatus would have given in the state the block reports.
+func (s ASBStatus) StatusBytes() (printerStatus, offlineStatus, errorStatus, paperStatus byte) {
+	printerStatus = statusFixedBits
+	if s[0]&asbDrawerSignal != 0 {
+		printerStatus |= StatusDrawerSignal
+	}
+	if s[0]&asbOffline != 0 {
+		printerStatus |= StatusOffline
+	}
+
+	offlineStatus = statusFixedBits
+	if s[0]&asbCoverOpen != 0 {
+		offlineStatus |= StatusCoverOpen
+	}
+	if s[0]&asbFeedButton != 0 {
+		offlineStatus |= StatusFeedButton
+	}
+	if s[2]&asbPaperEnd != 0 {
+		offlineStatus |= StatusPrintingStopped
+	}
+
+	errorStatus = statusFixedBits | s[1]&asbErrors
+
+	paperStatus = statusFixedBits
+	if s[2]&asbPaperNearEnd != 0 {
+		paperStatus |= StatusPaperNearEnd
+	}
+	if s[2]&asbPaperEnd != 0 {
+		paperStatus |= StatusPaperEnd
+	}
+	return
+}
+
+// Decode returns the meaning of the block.
+func (s ASBStatus) Decode() Status {
+	return DecodeStatus(s.StatusBytes())
+}
+
+// isASBHeader reports whether c can start an ASB block (0xx1xx00).
+func isASBHeader(c byte) bool {
+	return c&0x93 == 0x10
+}
+
+// isASBData reports whether c can be the second to fourth byte of an ASB
+// block (0xx0xxxx).
+func isASBData(c byte) bool {
+	return c&0x90 == 0x00
+}
+
+// isStatusReply reports whether c can be a DLE EOT reply (0xx1xx10).
+func isStatusReply(c byte) bool {
+	return c&0x93 == statusFixedBits
+}
+
+const (
+	demuxReplyBuffer = 16
+	demuxBlockBuffer = 8
+
+	// demuxIdleDelay is how long the reader waits when the transport had
+	// nothing to read, for transports that do not block
+	demuxIdleDelay = 50 * time.Millisecond
+	// demuxErrorDelay is how long the reader waits after a failed read
+	demuxErrorDelay = time.Second
+)
+
+// StatusDemux reads everything a printer sends in a goroutine and splits it
+// into the replies to DLE EOT requests and Automatic Status Back blocks,
+// which share the stream. Bytes that are neither are discarded.
+//
+// It is the io.ReadWriter an ESCPOS must use once the printer has ASB
+// enabled: writes go straight to the transport, and reads return the
+// status replies only.
+type StatusDemux struct {
+	rw           io.ReadWriter
+	replyTimeout time.Duration
+
+	replies chan byte
+	blocks  chan ASBStatus
+	done    chan struct{}
+	close   sync.Once
+
+	// The block being received; only the reader goroutine uses them
+	block    ASBStatus
+	blockLen int
+}
+
+// NewStatusDemux starts reading rw. A Read waits up to replyTimeout for a
+// status reply; zero waits until the demux is closed.
+func NewStatusDemux(rw io.ReadWriter, replyTimeout time.Duration) *StatusDemux {
+	d := &StatusDemux{
+		rw:           rw,
+		replyTimeout: replyTimeout,
+		replies:      make(chan byte, demuxReplyBuffer),
+		blocks:       make(chan ASBStatus, demuxBlockBuffer),
+		done:         make(chan struct{}),
+	}
+	go d.run()
+	return d
+}
+
+func (d *StatusDemux) Write(p []byte) (int, error) {
+	return d.rw.Write(p)
+}
+
+// Read returns the status replies received so far, waiting for at least one.
+func (d *StatusDemux) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	var timeout <-chan time.Time
+	if d.replyTimeout > 0 {
+		timer := time.NewTimer(d.replyTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case c := <-d.replies:
+		p[0] = c
+	case <-timeout:
+		return 0, fmt.Errorf("no status reply within %s", d.replyTimeout)
+	case <-d.done:
+		return 0, io.ErrClosedPipe
+	}
+
+	n := 1
+	for n < len(p) {
+		select {
+		case c := <-d.replies:
+			p[n] = c
+			n++
+		default:
+			return n, nil
+		}
+	}
+	return n, nil
+}
+
+// ResetInputBuffer discards status replies nobody read.
+func (d *StatusDemux) ResetInputBuffer() error {
+	for {
+		select {
+		case <-d.replies:
+		default:
+			return nil
+		}
+	}
+}
+
+// Blocks returns the channel ASB blocks are delivered on. When the
+// consumer falls behind, the oldest blocks are dropped, as only the latest
+// state matters. It is closed once the demux stops reading.
+func (d *StatusDemux) Blocks() <-chan ASBStatus {
+	return d.blocks
+}
+
+// Close stops the reader goroutine after its current read. It does not close
+// the transport, which is what unblocks a read that waits for data.
+func (d *StatusDemux) Close() error {
+	d.close.Do(func() { close(d.done) })
+	return nil
+}
+
+func (d *StatusDemux) run() {
+	defer close(d.blocks)
+
+	buf := make([]byte, 64)
+	for {
+		n, err := d.rw.Read(buf)
+		for _, c := range buf[:n] {
+			d.feed(c)
+		}
+
+		select {
+		case <-d.done:
+			return
+		default:
+		}
+
+		var delay time.Duration
+		var netErr net.Error
+		switch {
+		case err != nil && errors.As(err, &netErr) && netErr.Timeout():
+			// The transport already waited
+		case err == nil && n > 0:
+		case err == nil, errors.Is(err, io.EOF):
+			delay = demuxIdleDelay
+		default:
+			delay = demuxErrorDelay
+		}
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-d.done:
+				return
+			}
+		}
+	}
+}
+
+// feed sorts one received byte. An ASB block is four bytes, and a reply
+// byte cannot be mistaken for any of them, so a reply in the middle of a
+// block is noticed and the incomplete block dropped.
+func (d *StatusDemux) feed(c byte) {
+	if d.blockLen > 0 {
+		if isASBData(c) {
+			d.block[d.blockLen] = c
+			d.blockLen++
+			if d.blockLen == len(d.block) {
+				d.blockLen = 0
+				d.deliver(d.block)
+			}
+			return
+		}
+		d.blockLen = 0
+	}
+
+	switch {
+	case isASBHeader(c):
+		d.block[0] = c
+		d.blockLen = 1
+	case isStatusReply(c):
+		select {
+		case d.replies <- c:
+		default:
+			// Nobody asked for it
+		}
+	}
+}
+
+func (d *StatusDemux) deliver(block ASBStatus) {
+	for {
+		select {
+		case d.blocks <- block:
+			return
+		default:
+		}
+		select {
+		case <-d.blocks:
+		default:
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package escpos
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// streamPrinter hands out what the printer sends one chunk per Read.
+type streamPrinter struct {
+	chunks chan []byte
+}
+
+func (s *streamPrinter) Read(p []byte) (int, error) {
+	chunk, ok := <-s.chunks
+	if !ok {
+		return 0, io.EOF
+	}
+	return copy(p, chunk), nil
+}
+
+func (s *streamPrinter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func TestASBStatusBytes(t *testing.T) {
+	if p, o, e, c := (ASBStatus{0x10, 0x00, 0x00, 0x00}).StatusBytes(); p != 0x12 || o != 0x12 || e != 0x12 || c != 0x12 {
+		t.Fatalf("expected ready replies, got %#x %#x %#x %#x", p, o, e, c)
+	}
+
+	// Drawer signal, offline, cover open, autocutter error and paper out
+	block := ASBStatus{0x3C, 0x08, 0x0C, 0x00}
+	p, o, e, c := block.StatusBytes()
+	if p != 0x1E || o != 0x36 || e != 0x1A || c != 0x72 {
+		t.Fatalf("unexpected replies %#x %#x %#x %#x", p, o, e, c)
+	}
+
+	want := Status{
+		DrawerSignalHigh: true,
+		Offline:          true,
+		CoverOpen:        true,
+		PrintingStopped:  true,
+		AutocutterError:  true,
+		PaperEnd:         true,
+	}
+	if got := block.Decode(); got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+
+	if got := (ASBStatus{0x10, 0x00, 0x03, 0x00}).Decode(); got != (Status{PaperNearEnd: true}) {
+		t.Fatalf("expected paper near end, got %+v", got)
+	}
+}
+
+func TestStatusDemuxSeparatesRepliesFromBlocks(t *testing.T) {
+	printer := &streamPrinter{chunks: make(chan []byte, 4)}
+	demux := NewStatusDemux(printer, time.Second)
+	defer demux.Close()
+
+	// A reply, a block split across reads, a block cut short by a reply,
+	// noise and another block
+	printer.chunks <- []byte{0x12, 0x10, 0x00}
+	printer.chunks <- []byte{0x03, 0x00, 0x10, 0x00, 0x16}
+	printer.chunks <- []byte{0xFF, 0x14, 0x00, 0x00}
+	printer.chunks <- []byte{0x00}
+
+	for _, want := range []byte{0x12, 0x16} {
+		buf := make([]byte, 1)
+		if _, err := demux.Read(buf); err != nil || buf[0] != want {
+			t.Fatalf("expected reply %#x, got %#x, %v", want, buf[0], err)
+		}
+	}
+
+	for _, want := range []ASBStatus{{0x10, 0x00, 0x03, 0x00}, {0x14, 0x00, 0x00, 0x00}} {
+		select {
+		case block := <-demux.Blocks():
+			if block != want {
+				t.Fatalf("expected block %x, got %x", want, block)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for block %x", want)
+		}
+	}
+}
+
+func TestStatusDemuxStatusRequests(t *testing.T) {
+	printer := &streamPrinter{chunks: make(chan []byte, 4)}
+	demux := NewStatusDemux(printer, 20*time.Millisecond)
+
+	// A stale reply is discarded before the request is sent
+	printer.chunks <- []byte{0x1E}
+	time.Sleep(10 * time.Millisecond)
+	if _, err := NewESCPOS(demux).PrinterStatus(); err == nil {
+		t.Fatal("expected the request to time out without a reply")
+	}
+
+	if err := demux.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	close(printer.chunks)
+	if _, err := demux.Read(make([]byte, 1)); !errors.Is(err, io.ErrClosedPipe) {
+		t.Fatalf("expected a closed demux, got %v", err)
+	}
+	select {
+	case _, ok := <-demux.Blocks():
+		if ok {
+			t.Fatal("expected no blocks")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the reader to stop")
+	}
+}
@@ -0,0 +1,179 @@
+package escpos
+
+import (
+	"fmt"
+	"strings"
+)
+
+var barcodeNames = map[BarcodeSystem]string{
+	BarcodeUPCA:    "UPC-A",
+	BarcodeUPCE:    "UPC-E",
+	BarcodeEAN13:   "EAN13",
+	BarcodeEAN8:    "EAN8",
+	BarcodeCODE39:  "CODE39",
+	BarcodeITF:     "ITF",
+	BarcodeCODABAR: "CODABAR",
+	BarcodeCODE93:  "CODE93",
+	BarcodeCODE128: "CODE128",
+}
+
+func (s BarcodeSystem) String() string {
+	if name, ok := barcodeNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("BarcodeSystem(%d)", byte(s))
+}
+
+// ParseBarcodeSystem resolves a symbology name such as "EAN13", "ean-13",
+// "UPC_A" or "code128".
+func ParseBarcodeSystem(name string) (BarcodeSystem, error) {
+	normalized := strings.NewReplacer("-", "", "_", "", " ", "").Replace(strings.ToUpper(name))
+	for system, systemName := range barcodeNames {
+		if strings.ReplaceAll(systemName, "-", "") == normalized {
+			return system, nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported barcode type %q; expected UPC-A, UPC-E, EAN13, EAN8, CODE39, ITF, CODABAR, CODE93 or CODE128", name)
+}
+
+// BarcodeCommand validates data and returns the GS k function B command that
+// prints it.
+func BarcodeCommand(system BarcodeSystem, data string) ([]byte, error) {
+	if err := ValidateBarcode(system, data); err != nil {
+		return nil, err
+	}
+
+	command := make([]byte, 0, 4+len(data))
+	command = append(command, 0x1D, 0x6B, byte(system), byte(len(data)))
+	return append(command, data...), nil
+}
+
+// ValidateBarcode checks data against the character set, length and check
+// digit rules of the symbology.
+func ValidateBarcode(system BarcodeSystem, data string) error {
+	if data == "" {
+		return fmt.Errorf("%s: data is required", system)
+	}
+	if len(data) > 255 {
+		return fmt.Errorf("%s: data is limited to 255 characters; got %d", system, len(data))
+	}
+
+	switch system {
+	case BarcodeUPCA:
+		return validateEAN(system, data, 11)
+	case BarcodeUPCE:
+		if !isDigits(data) {
+			return fmt.Errorf("%s: only digits are allowed; got %q", system, data)
+		}
+		switch len(data) {
+		case 6:
+			return nil
+		case 7, 8, 11, 12:
+			if data[0] != '0' {
+				return fmt.Errorf("%s: %d digit data must start with number system 0; got %q", system, len(data), data)
+			}
+			if len(data) == 12 {
+				return validateEAN(system, data, 11)
+			}
+			return nil
+		default:
+			return fmt.Errorf("%s: expects 6, 7, 8, 11 or 12 digits; got %d", system, len(data))
+		}
+	case BarcodeEAN13:
+		return validateEAN(system, data, 12)
+	case BarcodeEAN8:
+		return validateEAN(system, data, 7)
+	case BarcodeCODE39:
+		for _, r := range data {
+			if !(r >= '0' && r <= '9' || r >= 'A' && r <= 'Z' || strings.ContainsRune(" $%+-./", r)) {
+				return fmt.Errorf("%s: character %q is not allowed; use 0-9, A-Z, space and $%%+-./", system, r)
+			}
+		}
+	case BarcodeITF:
+		if !isDigits(data) {
+			return fmt.Errorf("%s: only digits are allowed; got %q", system, data)
+		}
+		if len(data)%2 != 0 {
+			return fmt.Errorf("%s: expects an even number of digits; got %d", system, len(data))
+		}
+	case BarcodeCODABAR:
+		if len(data) < 2 || !isCodabarStartStop(data[0]) || !isCodabarStartStop(data[len(data)-1]) {
+			return fmt.Errorf("%s: data must start and end with one of A, B, C or D; got %q", system, data)
+		}
+		for _, r := range data[1 : len(data)-1] {
+			if !(r >= '0' && r <= '9' || strings.ContainsRune("$+-./:", r)) {
+				return fmt.Errorf("%s: character %q is not allowed; use 0-9 and $+-./:", system, r)
+			}
+		}
+	case BarcodeCODE93:
+		if err := validateASCII(system, data); err != nil {
+			return err
+		}
+	case BarcodeCODE128:
+		if len(data) < 2 || data[0] != '{' || !strings.ContainsRune("ABC", rune(data[1])) {
+			return fmt.Errorf("%s: data must start with a code set selection ({A, {B or {C); got %q", system, data)
+		}
+		if err := validateASCII(system, data); err != nil {
+			return err
+		}
+		if len(data) == 2 {
+			return fmt.Errorf("%s: data is required after the code set selection", system)
+		}
+	default:
+		return fmt.Errorf("unsupported barcode system %d", byte(system))
+	}
+
+	return nil
+}
+
+// validateEAN checks UPC/EAN data of dataDigits digits, optionally followed
+// by the check digit.
+func validateEAN(system BarcodeSystem, data string, dataDigits int) error {
+	if !isDigits(data) {
+		return fmt.Errorf("%s: only digits are allowed; got %q", system, data)
+	}
+	if len(data) != dataDigits && len(data) != dataDigits+1 {
+		return fmt.Errorf("%s: expects %d digits, or %d including the check digit; got %d", system, dataDigits, dataDigits+1, len(data))
+	}
+	if len(data) == dataDigits+1 {
+		if want := eanCheckDigit(data[:dataDigits]); data[dataDigits] != want {
+			return fmt.Errorf("%s: invalid check digit %c for %s; expected %c", system, data[dataDigits], data[:dataDigits], want)
+		}
+	}
+	return nil
+}
+
+// eanCheckDigit computes the modulo 10 check digit shared by UPC and EAN.
+func eanCheckDigit(digits string) byte {
+	sum := 0
+	for i := range len(digits) {
+		digit := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+func validateASCII(system BarcodeSystem, data string) error {
+	for i := range len(data) {
+		if data[i] > 0x7F {
+			return fmt.Errorf("%s: only ASCII characters are allowed; got %q", system, data)
+		}
+	}
+	return nil
+}
+
+func isDigits(data string) bool {
+	for i := range len(data) {
+		if data[i] < '0' || data[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isCodabarStartStop(c byte) bool {
+	return c >= 'A' && c <= 'D' || c >= 'a' && c <= 'd'
+}
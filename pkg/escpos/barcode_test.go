@@ -0,0 +1,120 @@
+package escpos
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestValidateBarcode(t *testing.T) {
+	valid := []struct {
+		system BarcodeSystem
+		data   string
+	}{
+		{BarcodeUPCA, "03600029145"},
+		{BarcodeUPCA, "036000291452"},
+		{BarcodeUPCE, "123456"},
+		{BarcodeUPCE, "01234565"},
+		{BarcodeEAN13, "590123412345"},
+		{BarcodeEAN13, "5901234123457"},
+		{BarcodeEAN8, "9638507"},
+		{BarcodeEAN8, "96385074"},
+		{BarcodeCODE39, "ABC-123 $%"},
+		{BarcodeITF, "1234"},
+		{BarcodeCODABAR, "A40156B"},
+		{BarcodeCODE93, "Hello, world"},
+		{BarcodeCODE128, "{BHello"},
+		{BarcodeCODE128, "{C123456"},
+	}
+	for _, tc := range valid {
+		if err := ValidateBarcode(tc.system, tc.data); err != nil {
+			t.Errorf("%s %q: unexpected error: %v", tc.system, tc.data, err)
+		}
+	}
+
+	invalid := []struct {
+		system BarcodeSystem
+		data   string
+		want   string
+	}{
+		{BarcodeUPCA, "1234", "expects 11 digits"},
+		{BarcodeUPCA, "036000291453", "invalid check digit 3"},
+		{BarcodeUPCE, "1234567", "must start with number system 0"},
+		{BarcodeEAN13, "59012341234A", "only digits"},
+		{BarcodeEAN13, "5901234123458", "expected 7"},
+		{BarcodeEAN8, "96385075", "invalid check digit"},
+		{BarcodeCODE39, "abc", "character 'a' is not allowed"},
+		{BarcodeITF, "123", "even number of digits"},
+		{BarcodeCODABAR, "40156", "must start and end with one of A, B, C or D"},
+		{BarcodeCODE93, "café", "only ASCII"},
+		{BarcodeCODE128, "Hello", "code set selection"},
+		{BarcodeEAN13, "", "data is required"},
+		{BarcodeCODE39, strings.Repeat("A", 256), "limited to 255"},
+	}
+	for _, tc := range invalid {
+		err := ValidateBarcode(tc.system, tc.data)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s %q: expected error containing %q, got %v", tc.system, tc.data, tc.want, err)
+		}
+	}
+}
+
+func TestParseBarcodeSystem(t *testing.T) {
+	cases := map[string]BarcodeSystem{
+		"EAN13":   BarcodeEAN13,
+		"ean-13":  BarcodeEAN13,
+		"UPC_A":   BarcodeUPCA,
+		"upc-e":   BarcodeUPCE,
+		"code128": BarcodeCODE128,
+		"Codabar": BarcodeCODABAR,
+	}
+	for name, want := range cases {
+		got, err := ParseBarcodeSystem(name)
+		if err != nil || got != want {
+			t.Errorf("ParseBarcodeSystem(%q) = %v, %v; want %v", name, got, err, want)
+		}
+	}
+
+	if _, err := ParseBarcodeSystem("QR"); err == nil {
+		t.Error("expected error for unsupported barcode type")
+	}
+}
+
+func TestPrintBarcodeWritesFunctionBCommand(t *testing.T) {
+	writer := &steppingWriter{}
+	esc := NewESCPOS(writer)
+
+	if _, err := esc.SetBarcodeHeight(80); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := esc.SetBarcodeWidth(2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := esc.SelectHRIPosition(HRIBelow); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := esc.SelectHRIFont(CharacterFontB); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := esc.PrintBarcode(BarcodeEAN8, "9638507"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []byte{
+		0x1D, 0x68, 80,
+		0x1D, 0x77, 2,
+		0x1D, 0x48, 0x02,
+		0x1D, 0x66, 0x01,
+		0x1D, 0x6B, 68, 7, '9', '6', '3', '8', '5', '0', '7',
+	}
+	if got := writer.written.Bytes(); !bytes.Equal(got, want) {
+		t.Fatalf("unexpected bytes:\n got % x\nwant % x", got, want)
+	}
+
+	if _, err := esc.PrintBarcode(BarcodeEAN8, "123"); err == nil {
+		t.Fatal("expected validation error")
+	}
+	if _, err := esc.SetBarcodeWidth(7); err == nil {
+		t.Fatal("expected error for module width out of range")
+	}
+}
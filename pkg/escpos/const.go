@@ -1,5 +1,7 @@
 package escpos
 
+import "golang.org/x/text/encoding/charmap"
+
 type UnderlineMode byte
 
 const (
@@ -54,9 +56,118 @@ const (
 	CharacterCodePageWPC1250    CharacterCodePage = 45
 )
 
+// BarcodeSystem selects the symbology of GS k function B.
+type BarcodeSystem byte
+
+const (
+	BarcodeUPCA    BarcodeSystem = 65
+	BarcodeUPCE    BarcodeSystem = 66
+	BarcodeEAN13   BarcodeSystem = 67
+	BarcodeEAN8    BarcodeSystem = 68
+	BarcodeCODE39  BarcodeSystem = 69
+	BarcodeITF     BarcodeSystem = 70
+	BarcodeCODABAR BarcodeSystem = 71
+	BarcodeCODE93  BarcodeSystem = 72
+	BarcodeCODE128 BarcodeSystem = 73
+)
+
+// HRIPosition selects where the human readable interpretation of a barcode
+// is printed.
+type HRIPosition byte
+
+const (
+	HRINone  HRIPosition = 0x00
+	HRIAbove HRIPosition = 0x01
+	HRIBelow HRIPosition = 0x02
+	HRIBoth  HRIPosition = 0x03
+)
+
+// Printer defaults restored by ESC @
+const (
+	DefaultBarcodeHeight = 162
+	DefaultBarcodeWidth  = 3
+)
+
+// QRErrorCorrection selects the error correction level of native QR codes.
+type QRErrorCorrection byte
+
+const (
+	QRErrorCorrectionL QRErrorCorrection = 48
+	QRErrorCorrectionM QRErrorCorrection = 49
+	QRErrorCorrectionQ QRErrorCorrection = 50
+	QRErrorCorrectionH QRErrorCorrection = 51
+)
+
+// DrawerPin selects the drawer kick-out connector pin pulsed by ESC p.
+type DrawerPin byte
+
+const (
+	DrawerPin2 DrawerPin = 0x00
+	DrawerPin5 DrawerPin = 0x01
+)
+
+// Common drawer kick pulse (ESC p 0 25 250) and buzzer settings
+const (
+	DefaultDrawerOnMs   = 50
+	DefaultDrawerOffMs  = 500
+	DefaultBeepDuration = 100
+)
+
+// Alignment is the justification selected by ESC a, also used to place
+// raster images within the paper width.
+type Alignment byte
+
+const (
+	AlignLeft   Alignment = 0x00
+	AlignCenter Alignment = 0x01
+	AlignRight  Alignment = 0x02
+)
+
+// Rows per GS v 0 band. Images taller than the band height are sent as
+// several raster commands so the printer never buffers a whole image.
+const (
+	DefaultRasterBandHeight = 255
+	MaxRasterBandHeight     = 0xFFFF
+)
+
 type CutMode byte
 
 const (
 	CutModeFull    CutMode = 0x00
 	CutModePartial CutMode = 0x01
 )
+
+// Charmap returns the character set behind an ESC t code page, or nil when
+// there is no equivalent in golang.org/x/text (e.g. Katakana).
+func (c CharacterCodePage) Charmap() *charmap.Charmap {
+	switch c {
+	case CharacterCodePagePC437:
+		return charmap.CodePage437
+	case CharacterCodePagePC850:
+		return charmap.CodePage850
+	case CharacterCodePagePC860:
+		return charmap.CodePage860
+	case CharacterCodePagePC863:
+		return charmap.CodePage863
+	case CharacterCodePagePC865:
+		return charmap.CodePage865
+	case CharacterCodePageISO8859_7:
+		return charmap.ISO8859_7
+	case CharacterCodePageWPC1252:
+		return charmap.Windows1252
+	case CharacterCodePagePC866:
+		return charmap.CodePage866
+	case CharacterCodePagePC852:
+		return charmap.CodePage852
+	case CharacterCodePagePC858:
+		return charmap.CodePage858
+	case CharacterCodePageISO8859_2:
+		return charmap.ISO8859_2
+	case CharacterCodePageISO8859_15:
+		return charmap.ISO8859_15
+	case CharacterCodePageWPC1250:
+		return charmap.Windows1250
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,240 @@
+package escpos
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+)
+
+// DitherMode selects how grayscale pixels are reduced to black and white.
+type DitherMode byte
+
+const (
+	// DitherThreshold prints every pixel darker than 50% gray. It keeps line
+	// art, logos and codes crisp.
+	DitherThreshold DitherMode = iota
+	// DitherFloydSteinberg diffuses the full quantization error to the four
+	// neighbouring pixels. Best for photos.
+	DitherFloydSteinberg
+	// DitherAtkinson diffuses 3/4 of the error over six pixels, giving higher
+	// contrast and cleaner highlights than Floyd–Steinberg.
+	DitherAtkinson
+	// DitherOrdered compares pixels against an 8×8 Bayer matrix. It is the
+	// fastest mode and produces a regular cross-hatch pattern.
+	DitherOrdered
+)
+
+var ditherNames = map[DitherMode]string{
+	DitherThreshold:      "threshold",
+	DitherFloydSteinberg: "floyd-steinberg",
+	DitherAtkinson:       "atkinson",
+	DitherOrdered:        "ordered",
+}
+
+func (m DitherMode) String() string {
+	if name, ok := ditherNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("DitherMode(%d)", byte(m))
+}
+
+// ParseDitherMode resolves a dithering mode name. An empty name selects
+// DitherThreshold; "floyd-steinberg" may be written as "floydsteinberg" or
+// "fs" and "ordered" as "bayer".
+func ParseDitherMode(name string) (DitherMode, error) {
+	normalized := strings.NewReplacer("-", "", "_", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+	switch normalized {
+	case "", "threshold", "none":
+		return DitherThreshold, nil
+	case "floydsteinberg", "fs":
+		return DitherFloydSteinberg, nil
+	case "atkinson":
+		return DitherAtkinson, nil
+	case "ordered", "bayer":
+		return DitherOrdered, nil
+	default:
+		return 0, fmt.Errorf("unsupported dither mode %q; expected threshold, floyd-steinberg, atkinson or ordered", name)
+	}
+}
+
+// ImageOptions controls how RasterImage, WriteRasterImage and
+// ImageToRasterBytesWithOptions convert an image. The zero value scales to
+// 384 dots, applies a plain threshold and aligns the image to the left.
+type ImageOptions struct {
+	// MaxWidthDots is the widest the image may be printed; smaller images
+	// are not enlarged.
+	MaxWidthDots int
+	Dither       DitherMode
+	// Brightness shifts all pixels from -1 (black) to 1 (white).
+	Brightness float64
+	// Contrast scales pixels around 50% gray, from -1 (flat gray) to 1
+	// (double contrast).
+	Contrast float64
+	// Gamma above 1 lightens mid-tones, below 1 darkens them. Zero means 1.
+	Gamma float64
+	// BandHeight is the number of rows sent per GS v 0 command. Zero means
+	// DefaultRasterBandHeight.
+	BandHeight int
+	// Align places images narrower than MaxWidthDots on the line.
+	Align Alignment
+}
+
+// Validate reports options that are out of range.
+func (o ImageOptions) Validate() error {
+	if o.MaxWidthDots < 0 {
+		return fmt.Errorf("max width must be >= 0; got %d", o.MaxWidthDots)
+	}
+	if _, ok := ditherNames[o.Dither]; !ok {
+		return fmt.Errorf("unsupported dither mode %d", byte(o.Dither))
+	}
+	if o.Brightness < -1 || o.Brightness > 1 {
+		return fmt.Errorf("brightness must be between -1 and 1; got %v", o.Brightness)
+	}
+	if o.Contrast < -1 || o.Contrast > 1 {
+		return fmt.Errorf("contrast must be between -1 and 1; got %v", o.Contrast)
+	}
+	if o.Gamma < 0 || o.Gamma > 10 {
+		return fmt.Errorf("gamma must be between 0 and 10; got %v", o.Gamma)
+	}
+	if o.Align > AlignRight {
+		return fmt.Errorf("unsupported alignment %d", byte(o.Align))
+	}
+	if o.BandHeight < 0 || o.BandHeight > MaxRasterBandHeight {
+		return fmt.Errorf("band height must be between 1 and %d rows; got %d", MaxRasterBandHeight, o.BandHeight)
+	}
+	return nil
+}
+
+// adjustmentTable returns the brightness, contrast and gamma lookup table,
+// or nil when the options leave pixels unchanged.
+func (o ImageOptions) adjustmentTable() *[256]byte {
+	gamma := o.Gamma
+	if gamma == 0 {
+		gamma = 1
+	}
+	if o.Brightness == 0 && o.Contrast == 0 && gamma == 1 {
+		return nil
+	}
+
+	var table [256]byte
+	for i := range table {
+		v := float64(i) / 255
+		v = (v-0.5)*(1+o.Contrast) + 0.5 + o.Brightness
+		v = math.Max(0, math.Min(1, v))
+		v = math.Pow(v, 1/gamma)
+		table[i] = byte(math.Round(v * 255))
+	}
+	return &table
+}
+
+// bayer8 is the 8×8 ordered dithering matrix.
+var bayer8 = [8][8]byte{
+	{0, 32, 8, 40, 2, 34, 10, 42},
+	{48, 16, 56, 24, 50, 18, 58, 26},
+	{12, 44, 4, 36, 14, 46, 6, 38},
+	{60, 28, 52, 20, 62, 30, 54, 22},
+	{3, 35, 11, 43, 1, 33, 9, 41},
+	{51, 19, 59, 27, 49, 17, 57, 25},
+	{15, 47, 7, 39, 13, 45, 5, 37},
+	{63, 31, 55, 23, 61, 29, 53, 21},
+}
+
+// diffusion is one neighbour of an error diffusion kernel.
+type diffusion struct {
+	dx, dy int
+	weight int
+}
+
+var (
+	floydSteinbergKernel = []diffusion{{1, 0, 7}, {-1, 1, 3}, {0, 1, 5}, {1, 1, 1}}
+	atkinsonKernel       = []diffusion{{1, 0, 1}, {2, 0, 1}, {-1, 1, 1}, {0, 1, 1}, {1, 1, 1}, {0, 2, 1}}
+)
+
+// dither reduces gray to pure black (0) and white (255) in place.
+func dither(gray *image.Gray, mode DitherMode) {
+	b := gray.Bounds()
+	d := newDitherer(mode, b.Dx())
+	for y := 0; y < b.Dy(); y++ {
+		d.row(gray.Pix[y*gray.Stride:y*gray.Stride+b.Dx()], y)
+	}
+}
+
+// ditherer dithers an image one row at a time, so images can be processed
+// in bands. Error diffusion modes carry their errors in a ring of rows as
+// tall as the kernel.
+type ditherer struct {
+	mode    DitherMode
+	kernel  []diffusion
+	divisor int
+	depth   int
+	stride  int
+	errs    []int
+}
+
+// ditherPad columns of padding on each side keep the kernels in bounds.
+const ditherPad = 2
+
+func newDitherer(mode DitherMode, width int) *ditherer {
+	d := &ditherer{mode: mode}
+	switch mode {
+	case DitherFloydSteinberg:
+		d.kernel, d.divisor = floydSteinbergKernel, 16
+	case DitherAtkinson:
+		d.kernel, d.divisor = atkinsonKernel, 8
+	default:
+		return d
+	}
+
+	d.depth = 1
+	for _, k := range d.kernel {
+		d.depth = max(d.depth, k.dy+1)
+	}
+	d.stride = width + 2*ditherPad
+	d.errs = make([]int, d.depth*d.stride)
+	return d
+}
+
+// row dithers row y of the image in place. Rows must be passed in order.
+func (d *ditherer) row(row []byte, y int) {
+	switch {
+	case d.kernel != nil:
+		d.diffuse(row, y)
+	case d.mode == DitherOrdered:
+		matrix := &bayer8[y%8]
+		for x, v := range row {
+			// Thresholds are spread evenly over 2..254
+			if int(v)*64 < int(matrix[x%8])*256+128 {
+				row[x] = 0
+			} else {
+				row[x] = 0xFF
+			}
+		}
+	default:
+		for x, v := range row {
+			row[x] = quantize(int(v))
+		}
+	}
+}
+
+func (d *ditherer) diffuse(row []byte, y int) {
+	current := d.errs[(y%d.depth)*d.stride : (y%d.depth+1)*d.stride]
+	for x, v := range row {
+		value := int(v) + current[x+ditherPad]/d.divisor
+		out := quantize(value)
+		row[x] = out
+		quantErr := value - int(out)
+		for _, k := range d.kernel {
+			next := d.errs[((y+k.dy)%d.depth)*d.stride:]
+			next[x+ditherPad+k.dx] += quantErr * k.weight
+		}
+	}
+	clear(current)
+}
+
+func quantize(v int) byte {
+	if v < 128 {
+		return 0
+	}
+	return 0xFF
+}
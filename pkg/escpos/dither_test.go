@@ -0,0 +1,161 @@
+package escpos
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+)
+
+func uniformGray(w, h int, value uint8) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = value
+	}
+	return img
+}
+
+func blackRatio(gray *image.Gray) float64 {
+	black := 0
+	for _, v := range gray.Pix {
+		if v == 0 {
+			black++
+		}
+	}
+	return float64(black) / float64(len(gray.Pix))
+}
+
+func TestParseDitherMode(t *testing.T) {
+	cases := map[string]DitherMode{
+		"":                DitherThreshold,
+		"threshold":       DitherThreshold,
+		"Floyd-Steinberg": DitherFloydSteinberg,
+		"floyd_steinberg": DitherFloydSteinberg,
+		"fs":              DitherFloydSteinberg,
+		"atkinson":        DitherAtkinson,
+		"bayer":           DitherOrdered,
+		"ordered":         DitherOrdered,
+	}
+	for name, want := range cases {
+		got, err := ParseDitherMode(name)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", name, err)
+		}
+		if got != want {
+			t.Fatalf("%q: expected %s, got %s", name, want, got)
+		}
+	}
+
+	if _, err := ParseDitherMode("sierra"); err == nil || !strings.Contains(err.Error(), "unsupported dither mode") {
+		t.Fatalf("expected unsupported dither mode error, got %v", err)
+	}
+}
+
+func TestDitherPreservesMeanTone(t *testing.T) {
+	// Atkinson drops a quarter of the error on purpose, so only its mid-gray
+	// is checked
+	for _, mode := range []DitherMode{DitherFloydSteinberg, DitherOrdered} {
+		for _, value := range []uint8{64, 128, 192} {
+			gray := uniformGray(64, 64, value)
+			dither(gray, mode)
+
+			want := 1 - float64(value)/255
+			if got := blackRatio(gray); math.Abs(got-want) > 0.05 {
+				t.Fatalf("%s at %d: expected about %.2f black, got %.2f", mode, value, want, got)
+			}
+		}
+	}
+
+	gray := uniformGray(64, 64, 128)
+	dither(gray, DitherAtkinson)
+	if got := blackRatio(gray); math.Abs(got-0.5) > 0.05 {
+		t.Fatalf("atkinson at 128: expected about 0.50 black, got %.2f", got)
+	}
+}
+
+func TestDitherThresholdIsBinary(t *testing.T) {
+	gray := uniformGray(8, 2, 127)
+	gray.Pix[0] = 128
+	dither(gray, DitherThreshold)
+
+	if gray.Pix[0] != 0xFF {
+		t.Fatalf("expected 128 to become white, got %d", gray.Pix[0])
+	}
+	for _, v := range gray.Pix[1:] {
+		if v != 0 {
+			t.Fatalf("expected 127 to become black, got %d", v)
+		}
+	}
+}
+
+func TestImageOptionsAdjustments(t *testing.T) {
+	if table := (ImageOptions{Gamma: 1}).adjustmentTable(); table != nil {
+		t.Fatalf("expected no table for neutral options")
+	}
+
+	table := ImageOptions{Brightness: 0.2}.adjustmentTable()
+	if table[0] != 51 || table[255] != 255 {
+		t.Fatalf("unexpected brightness table: 0->%d 255->%d", table[0], table[255])
+	}
+
+	table = ImageOptions{Contrast: 1}.adjustmentTable()
+	if table[64] != 0 || table[192] != 255 {
+		t.Fatalf("unexpected contrast table: 64->%d 192->%d", table[64], table[192])
+	}
+
+	table = ImageOptions{Gamma: 2}.adjustmentTable()
+	if table[64] <= 64 || table[0] != 0 || table[255] != 255 {
+		t.Fatalf("expected gamma 2 to lighten mid-tones: 64->%d", table[64])
+	}
+}
+
+func TestImageToRasterBytesWithOptions(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 16, 4))
+	for i := range img.Pix {
+		img.Pix[i] = 100
+	}
+
+	plain, err := ImageToRasterBytes(img, 16)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, b := range plain[10 : len(plain)-3] {
+		if b != 0xFF {
+			t.Fatalf("expected a solid black raster, got % X", plain)
+		}
+	}
+
+	lighter, err := ImageToRasterBytesWithOptions(img, ImageOptions{MaxWidthDots: 16, Brightness: 0.5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, b := range lighter[10 : len(lighter)-3] {
+		if b != 0x00 {
+			t.Fatalf("expected a blank raster after brightening, got % X", lighter)
+		}
+	}
+
+	dithered, err := ImageToRasterBytesWithOptions(img, ImageOptions{MaxWidthDots: 16, Dither: DitherOrdered})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(dithered) != len(plain) {
+		t.Fatalf("expected the same raster size, got %d and %d", len(dithered), len(plain))
+	}
+}
+
+func TestImageOptionsValidate(t *testing.T) {
+	img := image.NewUniform(color.White)
+	cases := map[string]ImageOptions{
+		"brightness must be between -1 and 1": {Brightness: 1.5},
+		"contrast must be between -1 and 1":   {Contrast: -2},
+		"gamma must be between 0 and 10":      {Gamma: -1},
+		"unsupported dither mode":             {Dither: DitherMode(9)},
+	}
+	for want, options := range cases {
+		if _, err := ImageToRasterBytesWithOptions(img, options); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q error, got %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package escpos
+
+import "fmt"
+
+// ParseDrawerPin resolves the connector pin number (2 or 5) of a drawer.
+func ParseDrawerPin(pin int) (DrawerPin, error) {
+	switch pin {
+	case 2:
+		return DrawerPin2, nil
+	case 5:
+		return DrawerPin5, nil
+	default:
+		return 0, fmt.Errorf("drawer pin must be 2 or 5; got %d", pin)
+	}
+}
+
+// DrawerKickCommand returns ESC p, which drives the drawer kick-out connector
+// pin for onMs and then keeps it off for offMs. The printer counts in 2 ms
+// units, so both times must be between 2 and 510 ms.
+func DrawerKickCommand(pin DrawerPin, onMs, offMs int) ([]byte, error) {
+	if pin != DrawerPin2 && pin != DrawerPin5 {
+		return nil, fmt.Errorf("unsupported drawer pin %d", pin)
+	}
+	if onMs < 2 || onMs > 510 {
+		return nil, fmt.Errorf("drawer pulse on time must be between 2 and 510 ms; got %d", onMs)
+	}
+	if offMs < 2 || offMs > 510 {
+		return nil, fmt.Errorf("drawer pulse off time must be between 2 and 510 ms; got %d", offMs)
+	}
+
+	return []byte{0x1B, 0x70, byte(pin), byte(onMs / 2), byte(offMs / 2)}, nil
+}
+
+// BeepCommand returns ESC B n t, which sounds the buzzer n times for t x 50
+// ms. It is understood by most ESC/POS compatible printers with a built-in
+// buzzer but not by Epson TM printers, which drive an external buzzer from
+// the drawer connector instead (see DrawerKickCommand).
+func BeepCommand(times, durationMs int) ([]byte, error) {
+	if times < 1 || times > 9 {
+		return nil, fmt.Errorf("beep count must be between 1 and 9; got %d", times)
+	}
+	if durationMs < 50 || durationMs > 450 {
+		return nil, fmt.Errorf("beep duration must be between 50 and 450 ms; got %d", durationMs)
+	}
+
+	return []byte{0x1B, 0x42, byte(times), byte(durationMs / 50)}, nil
+}
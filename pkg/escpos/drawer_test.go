@@ -0,0 +1,47 @@
+package escpos
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestKickDrawer(t *testing.T) {
+	buf := &bytes.Buffer{}
+	p := NewESCPOS(buf)
+
+	if _, err := p.KickDrawer(DrawerPin5, DefaultDrawerOnMs, DefaultDrawerOffMs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []byte{0x1B, 0x70, 0x01, 25, 250}; !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("expected % X, got % X", want, buf.Bytes())
+	}
+}
+
+func TestBeep(t *testing.T) {
+	buf := &bytes.Buffer{}
+	p := NewESCPOS(buf)
+
+	if _, err := p.Beep(3, 200); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []byte{0x1B, 0x42, 3, 4}; !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("expected % X, got % X", want, buf.Bytes())
+	}
+}
+
+func TestDrawerAndBeepValidation(t *testing.T) {
+	cases := map[string]error{}
+	_, cases["drawer pulse on time must be between 2 and 510 ms"] = DrawerKickCommand(DrawerPin2, 600, 100)
+	_, cases["drawer pulse off time must be between 2 and 510 ms"] = DrawerKickCommand(DrawerPin2, 100, 0)
+	_, cases["unsupported drawer pin"] = DrawerKickCommand(DrawerPin(7), 100, 100)
+	_, cases["drawer pin must be 2 or 5"] = ParseDrawerPin(3)
+	_, cases["beep count must be between 1 and 9"] = BeepCommand(10, 100)
+	_, cases["beep duration must be between 50 and 450 ms"] = BeepCommand(1, 20)
+
+	for want, err := range cases {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error containing %q, got %v", want, err)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package escpos
 
 import (
 	"fmt"
+	"image"
 	"io"
 	"log"
 
@@ -65,15 +66,41 @@ func (p *ESCPOS) Text(data string) (int, error) {
 	return p.Write([]byte(data))
 }
 
+// inputBufferResetter is implemented by transports that can discard pending
+// input, such as serial ports and network printers.
+type inputBufferResetter interface {
+	ResetInputBuffer() error
+}
+
+var _ inputBufferResetter = serial.Port(nil)
+
 func (p *ESCPOS) resetInputBuffer() {
-	if serialPort, ok := p.rw.(serial.Port); ok {
-		err := serialPort.ResetInputBuffer()
+	if resetter, ok := p.rw.(inputBufferResetter); ok {
+		err := resetter.ResetInputBuffer()
 		if err != nil {
 			log.Printf("warning: failed to reset input buffer: %v", err)
 		}
 	}
 }
 
+// Bits of the DLE EOT status replies. Each group belongs to the reply of
+// PrinterStatus, OfflineStatus, ErrorStatus and ContinuousPaperStatus.
+const (
+	StatusDrawerSignal byte = 0x04
+	StatusOffline      byte = 0x08
+
+	StatusCoverOpen       byte = 0x04
+	StatusFeedButton      byte = 0x08
+	StatusPrintingStopped byte = 0x20
+
+	StatusAutocutterError      byte = 0x08
+	StatusUnrecoverableError   byte = 0x20
+	StatusAutoRecoverableError byte = 0x40
+
+	StatusPaperNearEnd byte = 0x0C
+	StatusPaperEnd     byte = 0x60
+)
+
 func (escpos *ESCPOS) status(statusByte byte) (byte, error) {
 	escpos.resetInputBuffer()
 
@@ -144,43 +171,43 @@ func (p *ESCPOS) isContinuousPaperStatus(mask byte) (bool, error) {
 }
 
 func (p *ESCPOS) IsDrawerOpenCloseSignalHigh() (bool, error) {
-	return p.isPrinterStatus(0x04)
+	return p.isPrinterStatus(StatusDrawerSignal)
 }
 
 func (p *ESCPOS) IsOffline() (bool, error) {
-	return p.isPrinterStatus(0x08)
+	return p.isPrinterStatus(StatusOffline)
 }
 
 func (p *ESCPOS) IsCoverOpen() (bool, error) {
-	return p.isOfflineStatus(0x04)
+	return p.isOfflineStatus(StatusCoverOpen)
 }
 
 func (p *ESCPOS) IsPaperBeingFedByFeedButton() (bool, error) {
-	return p.isOfflineStatus(0x08)
+	return p.isOfflineStatus(StatusFeedButton)
 }
 
 func (p *ESCPOS) IsPrintingBeingStopped() (bool, error) {
-	return p.isOfflineStatus(0x20)
+	return p.isOfflineStatus(StatusPrintingStopped)
 }
 
 func (p *ESCPOS) IsAutocutterError() (bool, error) {
-	return p.isErrorStatus(0x08)
+	return p.isErrorStatus(StatusAutocutterError)
 }
 
 func (p *ESCPOS) IsUnrecoverableError() (bool, error) {
-	return p.isErrorStatus(0x20)
+	return p.isErrorStatus(StatusUnrecoverableError)
 }
 
 func (p *ESCPOS) IsAutoRecoverableError() (bool, error) {
-	return p.isErrorStatus(0x40)
+	return p.isErrorStatus(StatusAutoRecoverableError)
 }
 
 func (p *ESCPOS) IsPaperNearEnd() (bool, error) {
-	return p.isContinuousPaperStatus(0x0C)
+	return p.isContinuousPaperStatus(StatusPaperNearEnd)
 }
 
 func (p *ESCPOS) IsPaperEnd() (bool, error) {
-	return p.isContinuousPaperStatus(0x60)
+	return p.isContinuousPaperStatus(StatusPaperEnd)
 }
 
 // Font Commands
@@ -209,6 +236,91 @@ func (p *ESCPOS) SelectCharacterCodePage(codePage CharacterCodePage) (int, error
 	return p.Write([]byte{0x1B, 0x74, byte(codePage)})
 }
 
+// Barcode Commands
+
+func (p *ESCPOS) SelectHRIPosition(position HRIPosition) (int, error) {
+	return p.Write([]byte{0x1D, 0x48, byte(position)})
+}
+
+// SelectHRIFont selects the font of the human readable interpretation; only
+// fonts A and B are supported.
+func (p *ESCPOS) SelectHRIFont(font CharacterFont) (int, error) {
+	return p.Write([]byte{0x1D, 0x66, byte(font)})
+}
+
+// SetBarcodeHeight sets the bar height in dots (1-255).
+func (p *ESCPOS) SetBarcodeHeight(dots int) (int, error) {
+	if dots < 1 || dots > 255 {
+		return 0, fmt.Errorf("barcode height must be between 1 and 255 dots; got %d", dots)
+	}
+	return p.Write([]byte{0x1D, 0x68, byte(dots)})
+}
+
+// SetBarcodeWidth sets the module (narrowest bar) width in dots (2-6).
+func (p *ESCPOS) SetBarcodeWidth(module int) (int, error) {
+	if module < 2 || module > 6 {
+		return 0, fmt.Errorf("barcode module width must be between 2 and 6 dots; got %d", module)
+	}
+	return p.Write([]byte{0x1D, 0x77, byte(module)})
+}
+
+// PrintBarcode prints data as a barcode of the given system. The data is
+// validated first, see ValidateBarcode.
+func (p *ESCPOS) PrintBarcode(system BarcodeSystem, data string) (int, error) {
+	command, err := BarcodeCommand(system, data)
+	if err != nil {
+		return 0, err
+	}
+	return p.Write(command)
+}
+
+// PrintQRCode prints a QR code (model 2) with the printer's built-in
+// symbol generator, see QRCodeCommand.
+func (p *ESCPOS) PrintQRCode(data string, moduleSize int, level QRErrorCorrection) (int, error) {
+	command, err := QRCodeCommand(data, moduleSize, level)
+	if err != nil {
+		return 0, err
+	}
+	return p.Write(command)
+}
+
+// PrintPDF417 prints a PDF417 symbol with the printer's built-in symbol
+// generator, see PDF417Command.
+func (p *ESCPOS) PrintPDF417(data string, options PDF417Options) (int, error) {
+	command, err := PDF417Command(data, options)
+	if err != nil {
+		return 0, err
+	}
+	return p.Write(command)
+}
+
+// Image Commands
+
+// PrintImage streams img as GS v 0 raster bands, see WriteRasterImage.
+func (p *ESCPOS) PrintImage(img image.Image, options ImageOptions) (int, error) {
+	return WriteRasterImage(p, img, options)
+}
+
+// Drawer and Buzzer Commands
+
+// KickDrawer pulses the drawer kick-out connector, see DrawerKickCommand.
+func (p *ESCPOS) KickDrawer(pin DrawerPin, onMs, offMs int) (int, error) {
+	command, err := DrawerKickCommand(pin, onMs, offMs)
+	if err != nil {
+		return 0, err
+	}
+	return p.Write(command)
+}
+
+// Beep sounds the buzzer, see BeepCommand.
+func (p *ESCPOS) Beep(times, durationMs int) (int, error) {
+	command, err := BeepCommand(times, durationMs)
+	if err != nil {
+		return 0, err
+	}
+	return p.Write(command)
+}
+
 // Paper Movement Commands
 
 func (p *ESCPOS) FullCut() (int, error) {
@@ -5,20 +5,38 @@ import (
 	"encoding/base64"
 	"errors"
 	"image"
-	imagedraw "image/draw"
 	_ "image/gif"
 	_ "image/jpeg"
 	_ "image/png"
-	"math"
+	"io"
 	"strings"
 	"unicode"
-
-	xdraw "golang.org/x/image/draw"
 )
 
 // EncodeImageToRasterBytes decodes a base64 image, scales to maxWidthDots (58mm ≈ 384),
-// dithers to 1-bit, and returns ESC/POS GS v 0 raster bytes.
+// dithers to 1-bit, and returns a print job of ESC/POS GS v 0 raster bytes,
+// see ImageJob.
 func EncodeImageToRasterBytes(imgB64 string, maxWidthDots int) ([]byte, error) {
+	return EncodeImageToRasterBytesWithOptions(imgB64, ImageOptions{MaxWidthDots: maxWidthDots, Align: AlignCenter})
+}
+
+// EncodeImageToRasterBytesWithOptions is EncodeImageToRasterBytes with
+// control over dithering, tone adjustments and alignment.
+func EncodeImageToRasterBytesWithOptions(imgB64 string, options ImageOptions) ([]byte, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	img, err := DecodeImage(imgB64)
+	if err != nil {
+		return nil, err
+	}
+
+	return ImageToRasterBytesWithOptions(img, options)
+}
+
+// DecodeImage decodes a base64 PNG, JPEG or GIF. Whitespace, missing padding
+// and a data URL prefix are accepted.
+func DecodeImage(imgB64 string) (image.Image, error) {
 	cleaned := normalizeBase64(imgB64)
 	if cleaned == "" {
 		return nil, errors.New("empty image data")
@@ -31,86 +49,68 @@ func EncodeImageToRasterBytes(imgB64 string, maxWidthDots int) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-
-	return ImageToRasterBytes(img, maxWidthDots)
+	return img, nil
 }
 
-// ImageToRasterBytes converts an image to ESC/POS raster bytes using GS v 0
-// commands. The image is scaled to fit maxWidthDots (58mm ≈ 384) while
-// preserving aspect ratio.
+// ImageToRasterBytes converts an image to a print job of ESC/POS GS v 0
+// raster commands, see ImageJob. The image is scaled to fit maxWidthDots
+// (58mm ≈ 384) while preserving aspect ratio and centered. Pixels darker than
+// 50% gray are printed.
 func ImageToRasterBytes(img image.Image, maxWidthDots int) ([]byte, error) {
-	if img == nil {
-		return nil, errors.New("nil image")
-	}
-	if maxWidthDots <= 0 {
-		maxWidthDots = 384
-	}
-	alignedMaxWidth := (maxWidthDots + 7) &^ 7
-	b := img.Bounds()
-	w, h := b.Dx(), b.Dy()
-	if w == 0 || h == 0 {
-		return nil, errors.New("empty image")
-	}
-	scale := float64(maxWidthDots) / float64(w)
-	if scale > 1.0 {
-		scale = 1.0
-	}
-	newW := int(math.Floor(float64(w)*scale + 0.5))
-	if newW <= 0 {
-		newW = 1
-	}
-	newW = (newW + 7) &^ 7
-	if newW > alignedMaxWidth {
-		alignedMaxWidth = newW
-	}
-	newH := int(math.Floor(float64(h)*scale + 0.5))
-	if newH <= 0 {
-		newH = 1
-	}
+	return ImageToRasterBytesWithOptions(img, ImageOptions{MaxWidthDots: maxWidthDots, Align: AlignCenter})
+}
 
-	dst := image.NewRGBA(image.Rect(0, 0, newW, newH))
-	xdraw.ApproxBiLinear.Scale(dst, dst.Bounds(), img, b, xdraw.Src, nil)
+// ImageToRasterBytesWithOptions is ImageToRasterBytes with the options of
+// RasterImage.
+func ImageToRasterBytesWithOptions(img image.Image, options ImageOptions) ([]byte, error) {
+	raster, err := RasterImage(img, options)
+	if err != nil {
+		return nil, err
+	}
+	return ImageJob(raster), nil
+}
 
-	gray := image.NewGray(dst.Bounds())
-	imagedraw.Draw(gray, gray.Bounds(), dst, dst.Bounds().Min, imagedraw.Src)
+var (
+	imageJobStart = []byte{0x1B, 0x40} // ESC @
+	// Feed extra space so the receipt can be torn cleanly
+	imageJobEnd = []byte{0x1B, 0x64, 0x05} // ESC d n -> feed n lines
+)
 
-	// Pack bits row by row, centering image horizontally within alignedMaxWidth
-	imageBytes := newW / 8
-	lineBytes := alignedMaxWidth / 8
-	leftPadBytes := (lineBytes - imageBytes) / 2
-	rightPadBytes := lineBytes - imageBytes - leftPadBytes
+// ImageJob wraps raster commands into a stand-alone print job: ESC @ resets
+// the printer first and ESC d 5 feeds the image past the tear bar afterwards.
+// Use RasterImage instead when the image is part of a larger document.
+func ImageJob(raster []byte) []byte {
+	job := make([]byte, 0, len(imageJobStart)+len(raster)+len(imageJobEnd))
+	job = append(job, imageJobStart...)
+	job = append(job, raster...)
+	return append(job, imageJobEnd...)
+}
 
-	data := make([]byte, 0, lineBytes*newH)
-	leftPad := make([]byte, leftPadBytes)
-	rightPad := make([]byte, rightPadBytes)
-	for y := 0; y < newH; y++ {
-		data = append(data, leftPad...)
-		for bx := 0; bx < imageBytes; bx++ {
-			var b8 byte
-			for bit := 0; bit < 8; bit++ {
-				x := bx*8 + bit
-				if gray.GrayAt(x, y).Y < 128 {
-					b8 |= 1 << (7 - bit)
-				}
-			}
-			data = append(data, b8)
-		}
-		data = append(data, rightPad...)
+// WriteImageJob writes the print job ImageToRasterBytesWithOptions returns
+// to w, converting the image band by band while it is written, see
+// WriteRasterImage. It returns the number of bytes written.
+func WriteImageJob(w io.Writer, img image.Image, options ImageOptions) (int, error) {
+	written, err := w.Write(imageJobStart)
+	if err != nil {
+		return written, err
 	}
+	n, err := WriteRasterImage(w, img, options)
+	written += n
+	if err != nil {
+		return written, err
+	}
+	n, err = w.Write(imageJobEnd)
+	return written + n, err
+}
 
-	xL := byte(lineBytes & 0xFF)
-	xH := byte((lineBytes >> 8) & 0xFF)
-	yL := byte(newH & 0xFF)
-	yH := byte((newH >> 8) & 0xFF)
-
-	var out bytes.Buffer
-	out.Write([]byte{0x1B, 0x40}) // ESC @
-	out.Write([]byte{0x1D, 0x76, 0x30, 0x00, xL, xH, yL, yH})
-	out.Write(data)
-	// Feed extra space so the receipt can be torn cleanly
-	out.Write([]byte{0x1B, 0x64, 0x05}) // ESC d n -> feed n lines
-
-	return out.Bytes(), nil
+// ImageJobSize returns how many bytes WriteImageJob writes for an image with
+// the given bounds.
+func ImageJobSize(bounds image.Rectangle, options ImageOptions) (int, error) {
+	size, err := RasterImageSize(bounds, options)
+	if err != nil {
+		return 0, err
+	}
+	return len(imageJobStart) + size + len(imageJobEnd), nil
 }
 
 func normalizeBase64(input string) string {
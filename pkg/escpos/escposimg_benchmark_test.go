@@ -3,6 +3,7 @@ package escpos
 import (
 	"image"
 	"image/color"
+	"io"
 	"testing"
 )
 
@@ -41,3 +42,67 @@ func BenchmarkImageToRasterBytesMedium(b *testing.B) {
 func BenchmarkImageToRasterBytesLarge(b *testing.B) {
 	benchmarkImageToRasterBytes(b, 2048, 1536)
 }
+
+func benchmarkDither(b *testing.B, mode DitherMode) {
+	img := generateTestImage(1024, 768)
+	options := ImageOptions{MaxWidthDots: 576, Dither: mode}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ImageToRasterBytesWithOptions(img, options); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func BenchmarkDitherThreshold(b *testing.B) {
+	benchmarkDither(b, DitherThreshold)
+}
+
+func BenchmarkDitherFloydSteinberg(b *testing.B) {
+	benchmarkDither(b, DitherFloydSteinberg)
+}
+
+func BenchmarkDitherAtkinson(b *testing.B) {
+	benchmarkDither(b, DitherAtkinson)
+}
+
+func BenchmarkDitherOrdered(b *testing.B) {
+	benchmarkDither(b, DitherOrdered)
+}
+
+func BenchmarkImageAdjustments(b *testing.B) {
+	img := generateTestImage(1024, 768)
+	options := ImageOptions{MaxWidthDots: 576, Dither: DitherFloydSteinberg, Brightness: 0.1, Contrast: 0.2, Gamma: 1.4}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ImageToRasterBytesWithOptions(img, options); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func benchmarkWriteRasterImageTall(b *testing.B, bandHeight int) {
+	img := generateTestImage(576, 4000)
+	options := ImageOptions{MaxWidthDots: 576, Dither: DitherFloydSteinberg, BandHeight: bandHeight}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := WriteRasterImage(io.Discard, img, options); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func BenchmarkWriteRasterImageTallSingleBand(b *testing.B) {
+	benchmarkWriteRasterImageTall(b, MaxRasterBandHeight)
+}
+
+func BenchmarkWriteRasterImageTallBands255(b *testing.B) {
+	benchmarkWriteRasterImageTall(b, 255)
+}
+
+func BenchmarkWriteRasterImageTallBands24(b *testing.B) {
+	benchmarkWriteRasterImageTall(b, 24)
+}
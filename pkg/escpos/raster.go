@@ -0,0 +1,183 @@
+package escpos
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	imagedraw "image/draw"
+	"io"
+	"math"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// ParseAlignment resolves "left", "center" or "right".
+func ParseAlignment(name string) (Alignment, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "left":
+		return AlignLeft, nil
+	case "center", "centre":
+		return AlignCenter, nil
+	case "right":
+		return AlignRight, nil
+	default:
+		return 0, fmt.Errorf("unsupported alignment %q; expected left, center or right", name)
+	}
+}
+
+// RasterImage converts img to GS v 0 raster commands only, without resetting
+// the printer or feeding paper afterwards, so it can be embedded anywhere in
+// a document. See WriteRasterImage.
+func RasterImage(img image.Image, options ImageOptions) ([]byte, error) {
+	var out bytes.Buffer
+	if _, err := WriteRasterImage(&out, img, options); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// WriteRasterImage writes img to w as GS v 0 raster commands of at most
+// options.BandHeight rows each. Every row spans MaxWidthDots; narrower
+// images are padded according to options.Align. The image is scaled and
+// dithered one band at a time, so only a single band of the bitmap is held
+// in memory. It returns the number of bytes written.
+func WriteRasterImage(w io.Writer, img image.Image, options ImageOptions) (int, error) {
+	if err := options.Validate(); err != nil {
+		return 0, err
+	}
+	if img == nil {
+		return 0, errors.New("nil image")
+	}
+	b := img.Bounds()
+	layout, err := newRasterLayout(b, options)
+	if err != nil {
+		return 0, err
+	}
+	newW, newH, bandHeight := layout.width, layout.height, layout.bandHeight
+	target := image.Rect(0, 0, newW, newH)
+
+	// Band buffers are reused; their bounds move down the scaled image
+	rgba := &image.RGBA{Pix: make([]byte, 4*newW*bandHeight), Stride: 4 * newW}
+	gray := &image.Gray{Pix: make([]byte, newW*bandHeight), Stride: newW}
+	table := options.adjustmentTable()
+	rowDither := newDitherer(options.Dither, newW)
+
+	// Pack bits row by row, placing the image within the line
+	imageBytes := newW / 8
+	lineBytes := layout.lineBytes
+	leftPadBytes := 0
+	switch options.Align {
+	case AlignCenter:
+		leftPadBytes = (lineBytes - imageBytes) / 2
+	case AlignRight:
+		leftPadBytes = lineBytes - imageBytes
+	}
+	command := make([]byte, 0, 8+lineBytes*bandHeight)
+
+	written := 0
+	for top := 0; top < newH; top += bandHeight {
+		rows := min(bandHeight, newH-top)
+		rgba.Rect = image.Rect(0, top, newW, top+rows)
+		gray.Rect = rgba.Rect
+		xdraw.ApproxBiLinear.Scale(rgba, target, img, b, xdraw.Src, nil)
+		imagedraw.Draw(gray, gray.Rect, rgba, rgba.Rect.Min, imagedraw.Src)
+
+		command = append(command[:0], 0x1D, 0x76, 0x30, 0x00,
+			byte(lineBytes&0xFF), byte((lineBytes>>8)&0xFF),
+			byte(rows&0xFF), byte((rows>>8)&0xFF))
+		for y := 0; y < rows; y++ {
+			row := gray.Pix[y*gray.Stride : y*gray.Stride+newW]
+			if table != nil {
+				for i, v := range row {
+					row[i] = table[v]
+				}
+			}
+			rowDither.row(row, top+y)
+
+			line := command[len(command) : len(command)+lineBytes]
+			clear(line)
+			for bx := 0; bx < imageBytes; bx++ {
+				var b8 byte
+				for bit := 0; bit < 8; bit++ {
+					if row[bx*8+bit] < 128 {
+						b8 |= 1 << (7 - bit)
+					}
+				}
+				line[leftPadBytes+bx] = b8
+			}
+			command = command[:len(command)+lineBytes]
+		}
+
+		n, err := w.Write(command)
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+
+	return written, nil
+}
+
+// RasterImageSize returns how many bytes WriteRasterImage writes for an
+// image with the given bounds, without converting it.
+func RasterImageSize(bounds image.Rectangle, options ImageOptions) (int, error) {
+	if err := options.Validate(); err != nil {
+		return 0, err
+	}
+	layout, err := newRasterLayout(bounds, options)
+	if err != nil {
+		return 0, err
+	}
+	bands := (layout.height + layout.bandHeight - 1) / layout.bandHeight
+	return bands*8 + layout.lineBytes*layout.height, nil
+}
+
+// rasterLayout is the size an image is printed at.
+type rasterLayout struct {
+	// width and height of the scaled image in dots; width is a multiple of 8
+	width, height int
+	// lineBytes is the length of a row, which spans MaxWidthDots
+	lineBytes  int
+	bandHeight int
+}
+
+func newRasterLayout(b image.Rectangle, options ImageOptions) (rasterLayout, error) {
+	maxWidthDots := options.MaxWidthDots
+	if maxWidthDots <= 0 {
+		maxWidthDots = 384
+	}
+	bandHeight := options.BandHeight
+	if bandHeight == 0 {
+		bandHeight = DefaultRasterBandHeight
+	}
+	alignedMaxWidth := (maxWidthDots + 7) &^ 7
+	srcW, srcH := b.Dx(), b.Dy()
+	if srcW == 0 || srcH == 0 {
+		return rasterLayout{}, errors.New("empty image")
+	}
+	scale := float64(maxWidthDots) / float64(srcW)
+	if scale > 1.0 {
+		scale = 1.0
+	}
+	newW := int(math.Floor(float64(srcW)*scale + 0.5))
+	if newW <= 0 {
+		newW = 1
+	}
+	newW = (newW + 7) &^ 7
+	if newW > alignedMaxWidth {
+		alignedMaxWidth = newW
+	}
+	newH := int(math.Floor(float64(srcH)*scale + 0.5))
+	if newH <= 0 {
+		newH = 1
+	}
+
+	return rasterLayout{
+		width:      newW,
+		height:     newH,
+		lineBytes:  alignedMaxWidth / 8,
+		bandHeight: min(bandHeight, newH),
+	}, nil
+}
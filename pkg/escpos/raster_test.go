@@ -0,0 +1,193 @@
+package escpos
+
+import (
+	"bytes"
+	"image"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// rasterBands splits GS v 0 commands into their heights and joined data.
+func rasterBands(t *testing.T, data []byte) (heights []int, pixels []byte) {
+	t.Helper()
+	for len(data) > 0 {
+		if len(data) < 8 || !bytes.Equal(data[:4], []byte{0x1D, 0x76, 0x30, 0x00}) {
+			t.Fatalf("expected a GS v 0 header, got % X", data[:min(len(data), 8)])
+		}
+		widthBytes := int(data[4]) | int(data[5])<<8
+		height := int(data[6]) | int(data[7])<<8
+		size := 8 + widthBytes*height
+		heights = append(heights, height)
+		pixels = append(pixels, data[8:size]...)
+		data = data[size:]
+	}
+	return heights, pixels
+}
+
+// recordingWriter remembers the largest single write.
+type recordingWriter struct {
+	bytes.Buffer
+	largest int
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.largest = max(w.largest, len(p))
+	return w.Buffer.Write(p)
+}
+
+func TestWriteRasterImageSplitsBands(t *testing.T) {
+	img := generateTestImage(64, 600)
+
+	var out bytes.Buffer
+	n, err := WriteRasterImage(&out, img, ImageOptions{MaxWidthDots: 64})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != out.Len() {
+		t.Fatalf("expected %d bytes written, got %d", out.Len(), n)
+	}
+	heights, _ := rasterBands(t, out.Bytes())
+	if want := []int{255, 255, 90}; !slices.Equal(heights, want) {
+		t.Fatalf("expected bands %v, got %v", want, heights)
+	}
+
+	out.Reset()
+	if _, err := WriteRasterImage(&out, img, ImageOptions{MaxWidthDots: 64, BandHeight: 24}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	heights, _ = rasterBands(t, out.Bytes())
+	if len(heights) != 25 || heights[24] != 24 {
+		t.Fatalf("expected 25 bands of 24 rows, got %v", heights)
+	}
+}
+
+func TestWriteRasterImageBandsMatchSingleBand(t *testing.T) {
+	img := generateTestImage(500, 700)
+	for _, mode := range []DitherMode{DitherThreshold, DitherFloydSteinberg, DitherAtkinson, DitherOrdered} {
+		var single, banded bytes.Buffer
+		if _, err := WriteRasterImage(&single, img, ImageOptions{MaxWidthDots: 384, Dither: mode, Gamma: 1.2, BandHeight: MaxRasterBandHeight}); err != nil {
+			t.Fatalf("%s: unexpected error: %v", mode, err)
+		}
+		if _, err := WriteRasterImage(&banded, img, ImageOptions{MaxWidthDots: 384, Dither: mode, Gamma: 1.2, BandHeight: 24}); err != nil {
+			t.Fatalf("%s: unexpected error: %v", mode, err)
+		}
+
+		singleHeights, singlePixels := rasterBands(t, single.Bytes())
+		if len(singleHeights) != 1 {
+			t.Fatalf("%s: expected one band, got %v", mode, singleHeights)
+		}
+		if _, bandedPixels := rasterBands(t, banded.Bytes()); !bytes.Equal(singlePixels, bandedPixels) {
+			t.Fatalf("%s: banded output differs from a single band", mode)
+		}
+	}
+}
+
+func TestWriteRasterImageStreamsOneBandAtATime(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 384, 5000))
+
+	w := &recordingWriter{}
+	if _, err := WriteRasterImage(w, img, ImageOptions{MaxWidthDots: 384, BandHeight: 24}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := 8 + 48*24; w.largest != want {
+		t.Fatalf("expected writes of at most one %d byte band, got %d", want, w.largest)
+	}
+}
+
+func TestWriteRasterImageValidatesBandHeight(t *testing.T) {
+	_, err := WriteRasterImage(&bytes.Buffer{}, generateTestImage(8, 8), ImageOptions{BandHeight: MaxRasterBandHeight + 1})
+	if err == nil || !strings.Contains(err.Error(), "band height must be between 1 and 65535 rows") {
+		t.Fatalf("expected band height error, got %v", err)
+	}
+}
+
+func TestPrintImage(t *testing.T) {
+	buf := &bytes.Buffer{}
+	p := NewESCPOS(buf)
+
+	n, err := p.PrintImage(generateTestImage(16, 40), ImageOptions{MaxWidthDots: 16, BandHeight: 16})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	heights, _ := rasterBands(t, buf.Bytes())
+	if n != buf.Len() || !slices.Equal(heights, []int{16, 16, 8}) {
+		t.Fatalf("expected bands of 16, 16 and 8 rows in %d bytes, got %v in %d", buf.Len(), heights, n)
+	}
+}
+
+func TestRasterImageAlignment(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 16, 1))
+
+	cases := map[Alignment][]byte{
+		AlignLeft:   {0xFF, 0xFF, 0x00, 0x00, 0x00, 0x00},
+		AlignCenter: {0x00, 0x00, 0xFF, 0xFF, 0x00, 0x00},
+		AlignRight:  {0x00, 0x00, 0x00, 0x00, 0xFF, 0xFF},
+	}
+	for align, want := range cases {
+		raster, err := RasterImage(img, ImageOptions{MaxWidthDots: 48, Align: align})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !bytes.Equal(raster, append([]byte{0x1D, 0x76, 0x30, 0x00, 6, 0, 1, 0}, want...)) {
+			t.Fatalf("align %d: expected only the raster command with % X, got % X", align, want, raster)
+		}
+	}
+
+	if _, err := RasterImage(img, ImageOptions{Align: Alignment(3)}); err == nil || !strings.Contains(err.Error(), "unsupported alignment") {
+		t.Fatalf("expected unsupported alignment error, got %v", err)
+	}
+}
+
+func TestImageJobWrapsRaster(t *testing.T) {
+	raster := []byte{0x1D, 0x76, 0x30, 0x00, 1, 0, 1, 0, 0xFF}
+	want := append(append([]byte{0x1B, 0x40}, raster...), 0x1B, 0x64, 0x05)
+	if job := ImageJob(raster); !bytes.Equal(job, want) {
+		t.Fatalf("expected % X, got % X", want, job)
+	}
+
+	job, err := ImageToRasterBytes(image.NewGray(image.Rect(0, 0, 8, 1)), 24)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []byte{0x1B, 0x40, 0x1D, 0x76, 0x30, 0x00, 3, 0, 1, 0, 0x00, 0xFF, 0x00, 0x1B, 0x64, 0x05}; !bytes.Equal(job, want) {
+		t.Fatalf("expected a centered image job % X, got % X", want, job)
+	}
+}
+
+func TestWriteImageJobMatchesImageToRasterBytes(t *testing.T) {
+	img := generateTestImage(500, 700)
+	options := ImageOptions{MaxWidthDots: 384, Align: AlignCenter, BandHeight: 24, Dither: DitherAtkinson}
+
+	want, err := ImageToRasterBytesWithOptions(img, options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w := &recordingWriter{}
+	n, err := WriteImageJob(w, img, options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != len(want) || !bytes.Equal(w.Bytes(), want) {
+		t.Fatalf("expected the %d byte job ImageToRasterBytesWithOptions returns, got %d bytes", len(want), n)
+	}
+	if band := 8 + 48*24; w.largest > band {
+		t.Fatalf("expected writes of at most one %d byte band, got %d", band, w.largest)
+	}
+
+	size, err := ImageJobSize(img.Bounds(), options)
+	if err != nil || size != len(want) {
+		t.Fatalf("expected the size to be %d, got %d (%v)", len(want), size, err)
+	}
+}
+
+func TestParseAlignment(t *testing.T) {
+	for name, want := range map[string]Alignment{"left": AlignLeft, " Center ": AlignCenter, "right": AlignRight} {
+		if got, err := ParseAlignment(name); err != nil || got != want {
+			t.Fatalf("%q: expected %d, got %d (%v)", name, want, got, err)
+		}
+	}
+	if _, err := ParseAlignment("justify"); err == nil {
+		t.Fatal("expected an error for an unknown alignment")
+	}
+}
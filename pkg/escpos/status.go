@@ -0,0 +1,87 @@
+package escpos
+
+// StatusLevel summarizes a printer status.
+type StatusLevel string
+
+const (
+	// StatusReady: the printer can print
+	StatusReady StatusLevel = "ready"
+	// StatusWarning: the printer can print but needs attention soon
+	StatusWarning StatusLevel = "warning"
+	// StatusError: the printer cannot print until the problem is fixed
+	StatusError StatusLevel = "error"
+)
+
+// Status is the meaning of the four DLE EOT status replies.
+type Status struct {
+	DrawerSignalHigh bool
+	Offline          bool
+
+	CoverOpen       bool
+	FeedButton      bool
+	PrintingStopped bool
+
+	AutocutterError      bool
+	UnrecoverableError   bool
+	AutoRecoverableError bool
+
+	PaperNearEnd bool
+	PaperEnd     bool
+}
+
+// DecodeStatus decodes the replies of PrinterStatus, OfflineStatus,
+// ErrorStatus and ContinuousPaperStatus.
+func DecodeStatus(printerStatus, offlineStatus, errorStatus, paperStatus byte) Status {
+	return Status{
+		DrawerSignalHigh: printerStatus&StatusDrawerSignal != 0,
+		Offline:          printerStatus&StatusOffline != 0,
+
+		CoverOpen:       offlineStatus&StatusCoverOpen != 0,
+		FeedButton:      offlineStatus&StatusFeedButton != 0,
+		PrintingStopped: offlineStatus&StatusPrintingStopped != 0,
+
+		AutocutterError:      errorStatus&StatusAutocutterError != 0,
+		UnrecoverableError:   errorStatus&StatusUnrecoverableError != 0,
+		AutoRecoverableError: errorStatus&StatusAutoRecoverableError != 0,
+
+		PaperNearEnd: paperStatus&StatusPaperNearEnd != 0,
+		PaperEnd:     paperStatus&StatusPaperEnd != 0,
+	}
+}
+
+// Level returns StatusError when the printer cannot print, StatusWarning
+// when it can but the paper is running low or the feed button is held, and
+// StatusReady otherwise. The drawer signal does not count.
+func (s Status) Level() StatusLevel {
+	switch {
+	case s.Offline, s.CoverOpen, s.PrintingStopped, s.AutocutterError,
+		s.UnrecoverableError, s.AutoRecoverableError, s.PaperEnd:
+		return StatusError
+	case s.PaperNearEnd, s.FeedButton:
+		return StatusWarning
+	}
+	return StatusReady
+}
+
+// Messages describes every problem of the status for people, most severe
+// first. Offline is only mentioned when no cause is known, as the printer
+// also goes offline for an open cover or missing paper. It is empty when
+// the printer is ready.
+func (s Status) Messages() []string {
+	var messages []string
+	add := func(set bool, message string) {
+		if set {
+			messages = append(messages, message)
+		}
+	}
+	add(s.UnrecoverableError, "Unrecoverable error: turn the printer off and on again")
+	add(s.AutocutterError, "The autocutter is jammed or failed")
+	add(s.AutoRecoverableError, "Printing is paused until a recoverable error clears, such as an overheated print head")
+	add(s.CoverOpen, "The cover is open")
+	add(s.PaperEnd, "The paper has run out")
+	add(s.PrintingStopped, "Printing stopped because the paper ran out")
+	add(s.Offline && len(messages) == 0, "The printer is offline")
+	add(s.PaperNearEnd, "The paper is running low")
+	add(s.FeedButton, "Paper is being fed with the feed button")
+	return messages
+}
@@ -0,0 +1,42 @@
+package escpos
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDecodeStatus(t *testing.T) {
+	ready := DecodeStatus(0x12, 0x12, 0x12, 0x12)
+	if ready != (Status{}) || ready.Level() != StatusReady || len(ready.Messages()) != 0 {
+		t.Fatalf("expected a ready printer, got %+v", ready)
+	}
+
+	lowPaper := DecodeStatus(0x16, 0x12, 0x12, 0x1E)
+	if !lowPaper.DrawerSignalHigh || !lowPaper.PaperNearEnd || lowPaper.PaperEnd {
+		t.Fatalf("unexpected flags %+v", lowPaper)
+	}
+	if lowPaper.Level() != StatusWarning || !slices.Equal(lowPaper.Messages(), []string{"The paper is running low"}) {
+		t.Fatalf("expected a paper warning, got %s %q", lowPaper.Level(), lowPaper.Messages())
+	}
+
+	coverOpen := DecodeStatus(0x1A, 0x16, 0x12, 0x12)
+	if !coverOpen.Offline || !coverOpen.CoverOpen || coverOpen.Level() != StatusError {
+		t.Fatalf("expected an open cover error, got %+v", coverOpen)
+	}
+	// Offline is explained by the open cover
+	if want := []string{"The cover is open"}; !slices.Equal(coverOpen.Messages(), want) {
+		t.Fatalf("expected %q, got %q", want, coverOpen.Messages())
+	}
+
+	failed := DecodeStatus(0x1A, 0x32, 0x7A, 0x72)
+	if !failed.PrintingStopped || !failed.AutocutterError || !failed.UnrecoverableError || !failed.AutoRecoverableError || !failed.PaperEnd {
+		t.Fatalf("unexpected flags %+v", failed)
+	}
+	if messages := failed.Messages(); len(messages) != 5 || messages[0] != "Unrecoverable error: turn the printer off and on again" {
+		t.Fatalf("expected the unrecoverable error first, got %q", messages)
+	}
+
+	if offline := DecodeStatus(0x1A, 0x12, 0x12, 0x12); !slices.Equal(offline.Messages(), []string{"The printer is offline"}) {
+		t.Fatalf("expected a plain offline message, got %q", offline.Messages())
+	}
+}
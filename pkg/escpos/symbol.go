@@ -0,0 +1,105 @@
+package escpos
+
+import "fmt"
+
+// 2D symbols are printed with GS ( k: the data is stored in the printer's
+// symbol buffer and then printed, so only the data itself crosses the wire
+// instead of a raster image.
+
+const (
+	symbolQR     = 49
+	symbolPDF417 = 48
+
+	maxQRDataLen     = 7089
+	maxPDF417DataLen = 1850
+)
+
+// PDF417Options configure a native PDF417 symbol. Zero values select the
+// printer's automatic choice (columns, rows) or the documented defaults.
+type PDF417Options struct {
+	Columns     int // 0 (auto) or 1-30
+	Rows        int // 0 (auto) or 3-90
+	ModuleWidth int // 2-8 dots, default 3
+	RowHeight   int // 2-8 times the module width, default 3
+	ErrorLevel  int // 1-8, default 1
+	Truncated   bool
+}
+
+// symbolFunction returns GS ( k pL pH cn fn followed by params.
+func symbolFunction(cn, fn byte, params ...byte) []byte {
+	size := len(params) + 2
+	command := []byte{0x1D, 0x28, 0x6B, byte(size), byte(size >> 8), cn, fn}
+	return append(command, params...)
+}
+
+// QRCodeCommand returns the commands that select model 2, the module size
+// (1-16 dots) and error correction level, store data and print the symbol.
+func QRCodeCommand(data string, moduleSize int, level QRErrorCorrection) ([]byte, error) {
+	if data == "" {
+		return nil, fmt.Errorf("qr: data is required")
+	}
+	if len(data) > maxQRDataLen {
+		return nil, fmt.Errorf("qr: data is limited to %d bytes; got %d", maxQRDataLen, len(data))
+	}
+	if moduleSize < 1 || moduleSize > 16 {
+		return nil, fmt.Errorf("qr: module size must be between 1 and 16 dots; got %d", moduleSize)
+	}
+	if level < QRErrorCorrectionL || level > QRErrorCorrectionH {
+		return nil, fmt.Errorf("qr: unsupported error correction level %d", level)
+	}
+
+	var command []byte
+	command = append(command, symbolFunction(symbolQR, 65, 50, 0)...)
+	command = append(command, symbolFunction(symbolQR, 67, byte(moduleSize))...)
+	command = append(command, symbolFunction(symbolQR, 69, byte(level))...)
+	command = append(command, symbolFunction(symbolQR, 80, append([]byte{48}, data...)...)...)
+	command = append(command, symbolFunction(symbolQR, 81, 48)...)
+	return command, nil
+}
+
+// PDF417Command returns the commands that configure, store and print a
+// PDF417 symbol.
+func PDF417Command(data string, options PDF417Options) ([]byte, error) {
+	if options.ModuleWidth == 0 {
+		options.ModuleWidth = 3
+	}
+	if options.RowHeight == 0 {
+		options.RowHeight = 3
+	}
+	if options.ErrorLevel == 0 {
+		options.ErrorLevel = 1
+	}
+
+	switch {
+	case data == "":
+		return nil, fmt.Errorf("pdf417: data is required")
+	case len(data) > maxPDF417DataLen:
+		return nil, fmt.Errorf("pdf417: data is limited to %d bytes; got %d", maxPDF417DataLen, len(data))
+	case options.Columns < 0 || options.Columns > 30:
+		return nil, fmt.Errorf("pdf417: columns must be between 0 (auto) and 30; got %d", options.Columns)
+	case options.Rows != 0 && (options.Rows < 3 || options.Rows > 90):
+		return nil, fmt.Errorf("pdf417: rows must be 0 (auto) or between 3 and 90; got %d", options.Rows)
+	case options.ModuleWidth < 2 || options.ModuleWidth > 8:
+		return nil, fmt.Errorf("pdf417: module width must be between 2 and 8 dots; got %d", options.ModuleWidth)
+	case options.RowHeight < 2 || options.RowHeight > 8:
+		return nil, fmt.Errorf("pdf417: row height must be between 2 and 8; got %d", options.RowHeight)
+	case options.ErrorLevel < 1 || options.ErrorLevel > 8:
+		return nil, fmt.Errorf("pdf417: error correction level must be between 1 and 8; got %d", options.ErrorLevel)
+	}
+
+	var truncated byte
+	if options.Truncated {
+		truncated = 1
+	}
+
+	var command []byte
+	command = append(command, symbolFunction(symbolPDF417, 65, byte(options.Columns))...)
+	command = append(command, symbolFunction(symbolPDF417, 66, byte(options.Rows))...)
+	command = append(command, symbolFunction(symbolPDF417, 67, byte(options.ModuleWidth))...)
+	command = append(command, symbolFunction(symbolPDF417, 68, byte(options.RowHeight))...)
+	command = append(command, symbolFunction(symbolPDF417, 69, 48, byte(48+options.ErrorLevel))...)
+	command = append(command, symbolFunction(symbolPDF417, 70, truncated)...)
+	command = append(command, symbolFunction(symbolPDF417, 80, append([]byte{48}, data...)...)...)
+	command = append(command, symbolFunction(symbolPDF417, 81, 48)...)
+	return command, nil
+}
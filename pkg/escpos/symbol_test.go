@@ -0,0 +1,94 @@
+package escpos
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestQRCodeCommand(t *testing.T) {
+	command, err := QRCodeCommand("hi", 6, QRErrorCorrectionQ)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []byte{
+		0x1D, 0x28, 0x6B, 0x04, 0x00, 0x31, 0x41, 0x32, 0x00, // model 2
+		0x1D, 0x28, 0x6B, 0x03, 0x00, 0x31, 0x43, 0x06, // module size
+		0x1D, 0x28, 0x6B, 0x03, 0x00, 0x31, 0x45, 0x32, // error correction
+		0x1D, 0x28, 0x6B, 0x05, 0x00, 0x31, 0x50, 0x30, 'h', 'i', // store
+		0x1D, 0x28, 0x6B, 0x03, 0x00, 0x31, 0x51, 0x30, // print
+	}
+	if !bytes.Equal(command, want) {
+		t.Fatalf("unexpected command\n got % X\nwant % X", command, want)
+	}
+}
+
+func TestQRCodeCommandStoresLongDataLength(t *testing.T) {
+	data := strings.Repeat("x", 300)
+	command, err := QRCodeCommand(data, 3, QRErrorCorrectionM)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// pL pH count cn, fn, m and the data: 303 = 0x012F
+	store := []byte{0x1D, 0x28, 0x6B, 0x2F, 0x01, 0x31, 0x50, 0x30}
+	if !bytes.Contains(command, store) {
+		t.Fatalf("expected store header % X in command", store)
+	}
+}
+
+func TestPDF417CommandDefaults(t *testing.T) {
+	command, err := PDF417Command("hi", PDF417Options{Truncated: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []byte{
+		0x1D, 0x28, 0x6B, 0x03, 0x00, 0x30, 0x41, 0x00, // columns
+		0x1D, 0x28, 0x6B, 0x03, 0x00, 0x30, 0x42, 0x00, // rows
+		0x1D, 0x28, 0x6B, 0x03, 0x00, 0x30, 0x43, 0x03, // module width
+		0x1D, 0x28, 0x6B, 0x03, 0x00, 0x30, 0x44, 0x03, // row height
+		0x1D, 0x28, 0x6B, 0x04, 0x00, 0x30, 0x45, 0x30, 0x31, // error level
+		0x1D, 0x28, 0x6B, 0x03, 0x00, 0x30, 0x46, 0x01, // truncated
+		0x1D, 0x28, 0x6B, 0x05, 0x00, 0x30, 0x50, 0x30, 'h', 'i', // store
+		0x1D, 0x28, 0x6B, 0x03, 0x00, 0x30, 0x51, 0x30, // print
+	}
+	if !bytes.Equal(command, want) {
+		t.Fatalf("unexpected command\n got % X\nwant % X", command, want)
+	}
+}
+
+func TestSymbolCommandErrors(t *testing.T) {
+	cases := map[string]func() error{
+		"qr: data is required": func() error {
+			_, err := QRCodeCommand("", 3, QRErrorCorrectionM)
+			return err
+		},
+		"module size must be between 1 and 16": func() error {
+			_, err := QRCodeCommand("x", 17, QRErrorCorrectionM)
+			return err
+		},
+		"limited to 7089 bytes": func() error {
+			_, err := QRCodeCommand(strings.Repeat("x", 7090), 3, QRErrorCorrectionM)
+			return err
+		},
+		"columns must be between 0 (auto) and 30": func() error {
+			_, err := PDF417Command("x", PDF417Options{Columns: 31})
+			return err
+		},
+		"rows must be 0 (auto) or between 3 and 90": func() error {
+			_, err := PDF417Command("x", PDF417Options{Rows: 2})
+			return err
+		},
+		"error correction level must be between 1 and 8": func() error {
+			_, err := PDF417Command("x", PDF417Options{ErrorLevel: 9})
+			return err
+		},
+	}
+	for want, build := range cases {
+		if err := build(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error containing %q, got %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package escpos
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	tcpScheme             = "tcp://"
+	defaultTCPPrinterPort = "9100"
+)
+
+// TCPTransport streams raw ESC/POS bytes to a network printer listening on a
+// raw TCP socket (commonly port 9100, a.k.a. JetDirect/AppSocket). The
+// connection is opened lazily and re-established after it drops, so a printer
+// that was power-cycled between jobs does not require a service restart.
+type TCPTransport struct {
+	addr           string
+	connectTimeout time.Duration
+	readTimeout    time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// ParseTCPAddress extracts host:port from a tcp://host[:port] printer address.
+// The second return value reports whether the address uses the tcp scheme.
+func ParseTCPAddress(address string) (string, bool) {
+	if !strings.HasPrefix(strings.ToLower(address), tcpScheme) {
+		return "", false
+	}
+
+	hostPort := strings.TrimSuffix(address[len(tcpScheme):], "/")
+	if _, _, err := net.SplitHostPort(hostPort); err != nil {
+		hostPort = net.JoinHostPort(strings.Trim(hostPort, "[]"), defaultTCPPrinterPort)
+	}
+
+	return hostPort, true
+}
+
+// NewTCPTransport connects to the printer at addr (host:port). A zero
+// connectTimeout or readTimeout disables the respective timeout.
+func NewTCPTransport(addr string, connectTimeout, readTimeout time.Duration) (*TCPTransport, error) {
+	t := &TCPTransport{
+		addr:           addr,
+		connectTimeout: connectTimeout,
+		readTimeout:    readTimeout,
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, err := t.connect(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// connect returns the open connection, dialing the printer if needed.
+// Callers must hold t.mu.
+func (t *TCPTransport) connect() (net.Conn, error) {
+	if t.conn != nil {
+		return t.conn, nil
+	}
+
+	dialer := net.Dialer{Timeout: t.connectTimeout}
+	conn, err := dialer.Dial("tcp", t.addr)
+	if err != nil {
+		return nil, fmt.Errorf("connect tcp printer %s: %w", t.addr, err)
+	}
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		_ = tcpConn.SetKeepAlive(true)
+	}
+
+	t.conn = conn
+	return conn, nil
+}
+
+// drop closes and forgets the current connection so the next call reconnects.
+// Callers must hold t.mu.
+func (t *TCPTransport) drop() {
+	if t.conn != nil {
+		_ = t.conn.Close()
+		t.conn = nil
+	}
+}
+
+// Write sends p to the printer. When the connection turns out to be stale and
+// nothing has been written yet, the transport reconnects once and retries.
+func (t *TCPTransport) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		conn, err := t.connect()
+		if err != nil {
+			return 0, err
+		}
+
+		n, err := conn.Write(p)
+		if err == nil {
+			return n, nil
+		}
+
+		t.drop()
+		if n > 0 || attempt > 0 {
+			return n, fmt.Errorf("write tcp printer %s: %w", t.addr, err)
+		}
+	}
+}
+
+// Read reads status replies from the printer, honouring the read timeout.
+// A timeout leaves the connection open; any other failure drops it. The
+// transport is not locked while Read waits, so a goroutine that keeps
+// reading does not hold up writes.
+func (t *TCPTransport) Read(p []byte) (int, error) {
+	t.mu.Lock()
+	conn, err := t.connect()
+	t.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	var deadline time.Time
+	if t.readTimeout > 0 {
+		deadline = time.Now().Add(t.readTimeout)
+	}
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return 0, fmt.Errorf("set tcp read deadline: %w", err)
+	}
+
+	n, err := conn.Read(p)
+	if err != nil {
+		var netErr net.Error
+		if !(errors.As(err, &netErr) && netErr.Timeout()) {
+			t.mu.Lock()
+			if t.conn == conn {
+				t.drop()
+			}
+			t.mu.Unlock()
+		}
+		return n, fmt.Errorf("read tcp printer %s: %w", t.addr, err)
+	}
+
+	return n, nil
+}
+
+// ResetInputBuffer discards any unsolicited bytes the printer sent since the
+// last read, so the next status reply is not confused with stale data.
+func (t *TCPTransport) ResetInputBuffer() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.conn == nil {
+		return nil
+	}
+
+	buf := make([]byte, 64)
+	for {
+		if err := t.conn.SetReadDeadline(time.Now().Add(time.Millisecond)); err != nil {
+			return err
+		}
+		if _, err := t.conn.Read(buf); err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return nil
+			}
+			t.drop()
+			return err
+		}
+	}
+}
+
+func (t *TCPTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.conn == nil {
+		return nil
+	}
+	err := t.conn.Close()
+	t.conn = nil
+	return err
+}
@@ -0,0 +1,228 @@
+package escpos
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeNetworkPrinter is a local stand-in for a raw port 9100 printer. It
+// records everything it receives and answers DLE EOT n with statusReply.
+type fakeNetworkPrinter struct {
+	t           *testing.T
+	listener    net.Listener
+	statusReply byte
+
+	mu          sync.Mutex
+	received    bytes.Buffer
+	connections int
+	conns       []net.Conn
+}
+
+func newFakeNetworkPrinter(t *testing.T, statusReply byte) *fakeNetworkPrinter {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	fp := &fakeNetworkPrinter{t: t, listener: listener, statusReply: statusReply}
+	go fp.serve()
+	t.Cleanup(func() { _ = listener.Close() })
+
+	return fp
+}
+
+func (fp *fakeNetworkPrinter) addr() string {
+	return fp.listener.Addr().String()
+}
+
+func (fp *fakeNetworkPrinter) serve() {
+	for {
+		conn, err := fp.listener.Accept()
+		if err != nil {
+			return
+		}
+		fp.mu.Lock()
+		fp.connections++
+		fp.conns = append(fp.conns, conn)
+		fp.mu.Unlock()
+		go fp.handle(conn)
+	}
+}
+
+func (fp *fakeNetworkPrinter) handle(conn net.Conn) {
+	defer conn.Close()
+	buf := make([]byte, 256)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return
+		}
+		fp.mu.Lock()
+		fp.received.Write(buf[:n])
+		fp.mu.Unlock()
+		if bytes.Contains(buf[:n], []byte{0x10, 0x04}) {
+			_, _ = conn.Write([]byte{fp.statusReply})
+		}
+	}
+}
+
+// dropConnections closes every accepted connection, simulating a printer
+// reboot or an idle timeout on the printer side.
+func (fp *fakeNetworkPrinter) dropConnections() {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	for _, conn := range fp.conns {
+		_ = conn.Close()
+	}
+	fp.conns = nil
+}
+
+func (fp *fakeNetworkPrinter) snapshot() ([]byte, int) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	return append([]byte(nil), fp.received.Bytes()...), fp.connections
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("condition not met before deadline")
+}
+
+func TestParseTCPAddress(t *testing.T) {
+	cases := map[string]string{
+		"tcp://10.0.0.5:9100": "10.0.0.5:9100",
+		"tcp://printer.local": "printer.local:9100",
+		"TCP://10.0.0.5:9101": "10.0.0.5:9101",
+		"tcp://[fe80::1]":     "[fe80::1]:9100",
+	}
+	for input, want := range cases {
+		got, ok := ParseTCPAddress(input)
+		if !ok || got != want {
+			t.Fatalf("ParseTCPAddress(%q) = %q, %v; want %q", input, got, ok, want)
+		}
+	}
+
+	if _, ok := ParseTCPAddress("/dev/ttyUSB0"); ok {
+		t.Fatal("expected serial path not to be treated as tcp address")
+	}
+}
+
+func TestTCPTransportWritesAndReadsStatus(t *testing.T) {
+	fp := newFakeNetworkPrinter(t, 0x12)
+
+	transport, err := NewTCPTransport(fp.addr(), time.Second, time.Second)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer transport.Close()
+
+	esc := NewESCPOS(transport)
+	if _, err := esc.Text("hello\n"); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	status, err := esc.PrinterStatus()
+	if err != nil {
+		t.Fatalf("status failed: %v", err)
+	}
+	if status != 0x12 {
+		t.Fatalf("expected status 0x12, got %#x", status)
+	}
+
+	waitFor(t, func() bool {
+		received, _ := fp.snapshot()
+		return bytes.Contains(received, []byte("hello\n\x10\x04\x01"))
+	})
+}
+
+func TestTCPTransportReconnectsAfterDrop(t *testing.T) {
+	fp := newFakeNetworkPrinter(t, 0x12)
+
+	transport, err := NewTCPTransport(fp.addr(), time.Second, time.Second)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer transport.Close()
+
+	if _, err := transport.Write([]byte("first")); err != nil {
+		t.Fatalf("first write failed: %v", err)
+	}
+	waitFor(t, func() bool {
+		received, _ := fp.snapshot()
+		return bytes.Contains(received, []byte("first"))
+	})
+
+	fp.dropConnections()
+
+	// The first write after the peer closed may still be accepted by the
+	// kernel; keep writing until the transport notices and reconnects.
+	waitFor(t, func() bool {
+		_, _ = transport.Write([]byte("again"))
+		_, connections := fp.snapshot()
+		return connections >= 2
+	})
+	waitFor(t, func() bool {
+		received, _ := fp.snapshot()
+		return bytes.Contains(received, []byte("again"))
+	})
+}
+
+func TestTCPTransportReadTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// Never reply.
+		_, _ = conn.Read(make([]byte, 16))
+		time.Sleep(time.Second)
+	}()
+
+	transport, err := NewTCPTransport(listener.Addr().String(), time.Second, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer transport.Close()
+
+	start := time.Now()
+	_, err = NewESCPOS(transport).PrinterStatus()
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("read timeout took too long: %v", elapsed)
+	}
+}
+
+func TestNewTCPTransportConnectError(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	_ = listener.Close()
+
+	if _, err := NewTCPTransport(addr, 200*time.Millisecond, time.Second); err == nil {
+		t.Fatal("expected connect error for closed port")
+	}
+}
@@ -0,0 +1,318 @@
+// Package journal implements an append-only, fsync'd job journal so queued
+// print jobs survive restarts and crashes.
+//
+// Every state change is appended as one JSON line. On Open the journal is
+// replayed, jobs that never finished are returned as pending, and the file is
+// compacted so it only contains those pending jobs. While it is open, the
+// file is compacted again whenever finished jobs take up more of it than the
+// pending ones, and at least compactThreshold bytes.
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type Op string
+
+const (
+	OpEnqueue Op = "enqueue"
+	OpStart   Op = "start"
+	OpFinish  Op = "finish"
+)
+
+// maxLineLen bounds a single journal line, i.e. the size of one print job.
+const maxLineLen = 64 << 20
+
+// compactThreshold is how many bytes of finished jobs the journal holds at
+// least before it is compacted while open.
+const compactThreshold = 1 << 20
+
+type entry struct {
+	Op    Op        `json:"op"`
+	ID    string    `json:"id"`
+	Data  []byte    `json:"data,omitempty"`
+	Error string    `json:"error,omitempty"`
+	Time  time.Time `json:"time"`
+}
+
+// Pending is a job that was enqueued but never finished.
+type Pending struct {
+	ID        string
+	Data      []byte
+	CreatedAt time.Time
+	// Started is true when the job was being written to the printer when the
+	// previous process stopped, i.e. it may have been (partially) printed.
+	Started bool
+}
+
+type Journal struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+
+	// size is the length of the file; live holds how much of it each
+	// unfinished job takes up, and liveSize their sum
+	size      int64
+	live      map[string]int64
+	liveSize  int64
+	threshold int64
+}
+
+// Open replays and compacts the journal at path, creating it if needed, and
+// returns the jobs that were still pending in enqueue order.
+func Open(path string) (*Journal, []Pending, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, nil, fmt.Errorf("create journal directory: %w", err)
+	}
+
+	pending, err := replay(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, live, err := compact(path, pending)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	j := &Journal{path: path, file: file, threshold: compactThreshold}
+	j.setLive(live)
+	return j, pending, nil
+}
+
+// setLive records the sizes compact returned for a freshly compacted file.
+func (j *Journal) setLive(live map[string]int64) {
+	j.live = live
+	j.liveSize = 0
+	for _, size := range live {
+		j.liveSize += size
+	}
+	j.size = j.liveSize
+}
+
+func replay(path string) ([]Pending, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open journal: %w", err)
+	}
+	defer file.Close()
+
+	var (
+		order   []string
+		pending = make(map[string]*Pending)
+		line    int
+	)
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineLen)
+	for scanner.Scan() {
+		line++
+		var e entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// A torn write from a crash only affects the last line; skip it
+			log.Printf("journal: skipping unreadable entry %s:%d: %v", path, line, err)
+			continue
+		}
+
+		switch e.Op {
+		case OpEnqueue:
+			if _, ok := pending[e.ID]; !ok {
+				order = append(order, e.ID)
+			}
+			pending[e.ID] = &Pending{ID: e.ID, Data: e.Data, CreatedAt: e.Time}
+		case OpStart:
+			if p, ok := pending[e.ID]; ok {
+				p.Started = true
+			}
+		case OpFinish:
+			delete(pending, e.ID)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read journal: %w", err)
+	}
+
+	result := make([]Pending, 0, len(pending))
+	for _, id := range order {
+		if p, ok := pending[id]; ok {
+			result = append(result, *p)
+		}
+	}
+	return result, nil
+}
+
+// rename replaces the journal with its compacted copy; tests swap it out.
+var rename = os.Rename
+
+// compact atomically rewrites the journal so it only holds pending jobs. It
+// returns the rewritten file, open for appending, and how many bytes of it
+// each job takes up. The file is opened before it replaces the journal, so
+// there is nothing left to fail once it has.
+func compact(path string, pending []Pending) (*os.File, map[string]int64, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, nil, fmt.Errorf("compact journal: %w", err)
+	}
+	fail := func(err error) (*os.File, map[string]int64, error) {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, nil, fmt.Errorf("compact journal: %w", err)
+	}
+
+	live := make(map[string]int64, len(pending))
+	writer := bufio.NewWriter(tmp)
+	write := func(e entry) error {
+		line, err := encodeEntry(e)
+		if err != nil {
+			return err
+		}
+		live[e.ID] += int64(len(line))
+		_, err = writer.Write(line)
+		return err
+	}
+	for _, p := range pending {
+		if err := write(entry{Op: OpEnqueue, ID: p.ID, Data: p.Data, Time: p.CreatedAt}); err != nil {
+			return fail(err)
+		}
+		if p.Started {
+			if err := write(entry{Op: OpStart, ID: p.ID, Time: p.CreatedAt}); err != nil {
+				return fail(err)
+			}
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return fail(err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fail(err)
+	}
+
+	if err := rename(tmp.Name(), path); err != nil {
+		return fail(err)
+	}
+	// The rename only survives a crash once the directory is synced. Should
+	// that fail, the journal still has to follow the rename that happened.
+	if err := syncDir(filepath.Dir(path)); err != nil {
+		log.Printf("journal: %s: %v", path, err)
+	}
+	return tmp, live, nil
+}
+
+// syncDir flushes the entries of directory dir to disk.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("sync journal directory: %w", err)
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("sync journal directory: %w", err)
+	}
+	return nil
+}
+
+func encodeEntry(e entry) ([]byte, error) {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("encode journal entry: %w", err)
+	}
+	return append(line, '\n'), nil
+}
+
+func (j *Journal) append(e entry) error {
+	line, err := encodeEntry(e)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil {
+		return fmt.Errorf("journal %s is closed", j.path)
+	}
+	if _, err := j.file.Write(line); err != nil {
+		return fmt.Errorf("append journal entry: %w", err)
+	}
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("sync journal: %w", err)
+	}
+
+	j.size += int64(len(line))
+	switch e.Op {
+	case OpEnqueue, OpStart:
+		j.live[e.ID] += int64(len(line))
+		j.liveSize += int64(len(line))
+	case OpFinish:
+		j.liveSize -= j.live[e.ID]
+		delete(j.live, e.ID)
+		if finished := j.size - j.liveSize; finished >= j.threshold && finished > j.liveSize {
+			j.compact()
+		}
+	}
+	return nil
+}
+
+// compact rewrites the open journal without the finished jobs. The entry
+// that triggered it is already durable and a failed compaction leaves the
+// journal as it was, so a failure only leaves the file larger than it needs
+// to be. Callers must hold j.mu.
+func (j *Journal) compact() {
+	pending, err := replay(j.path)
+	if err != nil {
+		log.Printf("journal: %s: %v", j.path, err)
+		return
+	}
+	file, live, err := compact(j.path, pending)
+	if err != nil {
+		log.Printf("journal: %s: %v", j.path, err)
+		return
+	}
+
+	// The old file was replaced; append to the new one from now on
+	_ = j.file.Close()
+	j.file = file
+	j.setLive(live)
+}
+
+// Enqueued durably records a new job before it is handed to the worker.
+func (j *Journal) Enqueued(id string, data []byte, at time.Time) error {
+	return j.append(entry{Op: OpEnqueue, ID: id, Data: data, Time: at})
+}
+
+// Started records that the job is about to be written to the printer.
+func (j *Journal) Started(id string) error {
+	return j.append(entry{Op: OpStart, ID: id, Time: time.Now()})
+}
+
+// Finished records that the job reached a terminal state and must not be replayed.
+func (j *Journal) Finished(id string, jobErr error) error {
+	e := entry{Op: OpFinish, ID: id, Time: time.Now()}
+	if jobErr != nil {
+		e.Error = jobErr.Error()
+	}
+	return j.append(e)
+}
+
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
@@ -0,0 +1,203 @@
+package journal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJournalReplaysUnfinishedJobs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "printer.journal")
+
+	j, pending, err := Open(path)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("expected empty journal, got %d pending", len(pending))
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	mustOK(t, j.Enqueued("done", []byte("a"), now))
+	mustOK(t, j.Enqueued("failed", []byte("b"), now))
+	mustOK(t, j.Enqueued("inflight", []byte{0x1B, 0x40}, now))
+	mustOK(t, j.Enqueued("queued", []byte("d"), now))
+	mustOK(t, j.Started("done"))
+	mustOK(t, j.Finished("done", nil))
+	mustOK(t, j.Started("failed"))
+	mustOK(t, j.Finished("failed", errors.New("paper out")))
+	mustOK(t, j.Started("inflight"))
+	mustOK(t, j.Close())
+
+	j, pending, err = Open(path)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer j.Close()
+
+	if len(pending) != 2 {
+		t.Fatalf("expected 2 pending jobs, got %+v", pending)
+	}
+	if pending[0].ID != "inflight" || !pending[0].Started || !bytes.Equal(pending[0].Data, []byte{0x1B, 0x40}) {
+		t.Fatalf("unexpected in-flight job: %+v", pending[0])
+	}
+	if !pending[0].CreatedAt.Equal(now) {
+		t.Fatalf("expected creation time to survive replay, got %v", pending[0].CreatedAt)
+	}
+	if pending[1].ID != "queued" || pending[1].Started {
+		t.Fatalf("unexpected queued job: %+v", pending[1])
+	}
+}
+
+func TestJournalCompactsOnOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "printer.journal")
+
+	j, _, err := Open(path)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	for i := 0; i < 50; i++ {
+		mustOK(t, j.Enqueued("job", bytes.Repeat([]byte("x"), 100), time.Now()))
+		mustOK(t, j.Finished("job", nil))
+	}
+	mustOK(t, j.Enqueued("keep", []byte("k"), time.Now()))
+	mustOK(t, j.Close())
+
+	j, pending, err := Open(path)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer j.Close()
+
+	if len(pending) != 1 || pending[0].ID != "keep" {
+		t.Fatalf("unexpected pending jobs: %+v", pending)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read journal: %v", err)
+	}
+	if lines := strings.Count(string(content), "\n"); lines != 1 {
+		t.Fatalf("expected compacted journal with 1 line, got %d", lines)
+	}
+}
+
+func TestJournalCompactsWhileOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "printer.journal")
+
+	j, _, err := Open(path)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	j.threshold = 4096
+
+	payload := bytes.Repeat([]byte("x"), 1000)
+	mustOK(t, j.Enqueued("keep", []byte("k"), time.Now()))
+	mustOK(t, j.Started("keep"))
+	for i := 0; i < 200; i++ {
+		id := fmt.Sprintf("job-%d", i)
+		mustOK(t, j.Enqueued(id, payload, time.Now()))
+		mustOK(t, j.Started(id))
+		mustOK(t, j.Finished(id, nil))
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("stat journal: %v", err)
+		}
+		// Finished jobs never take up much more than the threshold
+		if info.Size() > 2*j.threshold {
+			t.Fatalf("expected the journal to be compacted, it is %d bytes after %d jobs", info.Size(), i+1)
+		}
+	}
+	// Entries after a compaction go to the new file
+	mustOK(t, j.Enqueued("last", []byte("l"), time.Now()))
+	mustOK(t, j.Close())
+
+	j, pending, err := Open(path)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer j.Close()
+
+	if len(pending) != 2 || pending[0].ID != "keep" || !pending[0].Started || pending[1].ID != "last" {
+		t.Fatalf("unexpected pending jobs: %+v", pending)
+	}
+}
+
+func TestJournalKeepsAppendingWhenCompactionFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "printer.journal")
+
+	j, _, err := Open(path)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	j.threshold = 4096
+
+	rename = func(string, string) error { return errors.New("disk full") }
+	t.Cleanup(func() { rename = os.Rename })
+
+	payload := bytes.Repeat([]byte("x"), 1000)
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("job-%d", i)
+		mustOK(t, j.Enqueued(id, payload, time.Now()))
+		mustOK(t, j.Finished(id, nil))
+	}
+	// Every failed compaction left the journal in place
+	mustOK(t, j.Enqueued("last", []byte("l"), time.Now()))
+	mustOK(t, j.Close())
+
+	matches, err := filepath.Glob(path + ".*.tmp")
+	if err != nil || len(matches) != 0 {
+		t.Fatalf("expected no leftover temporary files, got %v (%v)", matches, err)
+	}
+
+	rename = os.Rename
+	j, pending, err := Open(path)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer j.Close()
+
+	if len(pending) != 1 || pending[0].ID != "last" {
+		t.Fatalf("unexpected pending jobs: %+v", pending)
+	}
+}
+
+func TestJournalSkipsTornTrailingEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "printer.journal")
+
+	j, _, err := Open(path)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	mustOK(t, j.Enqueued("intact", []byte("ok"), time.Now()))
+	mustOK(t, j.Close())
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("open for append: %v", err)
+	}
+	_, _ = f.WriteString(`{"op":"enqueue","id":"torn","data":"AAA`)
+	_ = f.Close()
+
+	j, pending, err := Open(path)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer j.Close()
+
+	if len(pending) != 1 || pending[0].ID != "intact" {
+		t.Fatalf("expected only the intact job, got %+v", pending)
+	}
+}
+
+func mustOK(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
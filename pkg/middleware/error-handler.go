@@ -22,7 +22,11 @@ func (m *ErrorHandlerMiddleware) Add() gin.HandlerFunc {
 		for _, err := range c.Errors {
 			var appErr common.AppError
 			if errors.As(err, &appErr) {
-				errorResponse(c, appErr.HttpStatusCode(), appErr.Error())
+				var problems []string
+				if detailed, ok := appErr.(detailedError); ok {
+					problems = detailed.Details()
+				}
+				errorResponse(c, appErr.HttpStatusCode(), appErr.Error(), problems...)
 				return
 			}
 
@@ -32,9 +36,18 @@ func (m *ErrorHandlerMiddleware) Add() gin.HandlerFunc {
 	}
 }
 
-func errorResponse(c *gin.Context, statusCode int, message string) {
+// detailedError is an error that lists its individual problems.
+type detailedError interface {
+	Details() []string
+}
+
+func errorResponse(c *gin.Context, statusCode int, message string, problems ...string) {
 	if len(message) > 0 {
 		message = strings.ToUpper(message[:1]) + message[1:]
 	}
+	if len(problems) > 0 {
+		c.JSON(statusCode, gin.H{"error": message, "problems": problems})
+		return
+	}
 	c.JSON(statusCode, gin.H{"error": message})
 }
@@ -3,11 +3,14 @@ package middleware
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jonasclaes/go-thermal-printer/pkg/common"
 )
 
 func TestErrorHandlerMiddlewareSingleResponse(t *testing.T) {
@@ -43,3 +46,36 @@ func TestErrorHandlerMiddlewareSingleResponse(t *testing.T) {
 		t.Fatalf("expected first error message, got %q", payload["error"])
 	}
 }
+
+func TestErrorHandlerMiddlewareListsProblems(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(NewErrorHandlerMiddleware().Add())
+	router.GET("/test", func(c *gin.Context) {
+		_ = c.Error(fmt.Errorf("print: %w", &common.InvalidVariablesError{
+			Template: "receipt",
+			Problems: []string{"total: is required", "items: must be an array, got a string"},
+		}))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	var payload struct {
+		Error    string   `json:"error"`
+		Problems []string `json:"problems"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("failed to unmarshal response body: %v", err)
+	}
+	if !strings.HasPrefix(payload.Error, "Variables do not match the schema of template \"receipt\"") || len(payload.Problems) != 2 || payload.Problems[0] != "total: is required" {
+		t.Fatalf("expected the problems in the response, got %+v", payload)
+	}
+}
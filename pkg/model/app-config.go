@@ -1,10 +1,17 @@
 package model
 
 type AppConfig struct {
-	Server   ServerConfig  `toml:"server"`
-	Printer  PrinterConfig `toml:"printer"`
-	TestMode bool          `toml:"test_mode" default:"false"`
-	USBMode  bool          `toml:"usb_mode" default:"false"`
+	Server         ServerConfig    `toml:"server"`
+	Printer        PrinterConfig   `toml:"printer"`
+	Printers       []PrinterConfig `toml:"printers"`
+	Profiles       []ProfileConfig `toml:"profiles"`
+	Queue          QueueConfig     `toml:"queue"`
+	Font           FontConfig      `toml:"font"`
+	Templates      TemplatesConfig `toml:"templates"`
+	Webhooks       []WebhookConfig `toml:"webhooks"`
+	DefaultPrinter string          `toml:"default_printer" default:""`
+	TestMode       bool            `toml:"test_mode" default:"false"`
+	USBMode        bool            `toml:"usb_mode" default:"false"`
 }
 
 type ServerConfig struct {
@@ -14,10 +21,144 @@ type ServerConfig struct {
 	SwaggerHost string `toml:"swagger_host" default:"localhost:8080"`
 }
 
+type QueueConfig struct {
+	Persistent bool   `toml:"persistent" default:"false"`
+	DataDir    string `toml:"data_dir" default:"data"`
+	// Delivery decides what happens to a job that was printing when the
+	// process stopped: "at-least-once" prints it again, "at-most-once" marks it failed.
+	Delivery string `toml:"delivery" default:"at-least-once"`
+}
+
+// FontConfig selects the TrueType/OpenType fonts used to print text the
+// printer's code pages cannot represent.
+type FontConfig struct {
+	Regular string `toml:"regular" default:""`
+	// Bold is optional; without it bold text is emboldened synthetically
+	Bold string `toml:"bold" default:""`
+	// Fonts tried in order for runes the regular or bold font lacks
+	Fallbacks []string `toml:"fallbacks"`
+	// Default text size in dots; 24 matches the printer's font A
+	Size int `toml:"size" default:"24"`
+	// Print text the code page cannot encode with these fonts instead of
+	// replacing it with ASCII
+	AutoFallback bool `toml:"auto_fallback" default:"false"`
+}
+
+// TemplatesConfig locates the template store and limits what rendering a
+// template may do. Templates are printed by name and kept as <name>.tmpl
+// files in Dir.
+type TemplatesConfig struct {
+	Dir string `toml:"dir" default:"templates"`
+
+	// Render limits; 0 disables a limit. Paper length is estimated in lines
+	// of the default line spacing (30 dots, about 3.75mm at 203 dpi)
+	RenderTimeoutMs int `toml:"render_timeout_ms" default:"5000"`
+	MaxOutputBytes  int `toml:"max_output_bytes" default:"1048576"`
+	MaxImages       int `toml:"max_images" default:"32"`
+	MaxFeedLines    int `toml:"max_feed_lines" default:"1000"`
+}
+
 type PrinterConfig struct {
+	Name     string `toml:"name" default:"default"`
 	Port     string `toml:"port" default:"/dev/ttyUSB0"`
 	BaudRate int    `toml:"baud_rate" default:"19200"`
 	DataBits int    `toml:"data_bits" default:"8"`
 	StopBits int    `toml:"stop_bits" default:"1"`
 	Parity   int    `toml:"parity" default:"0"`
+
+	// Network printers (port = "tcp://host:9100")
+	ConnectTimeoutMs int `toml:"connect_timeout_ms" default:"5000"`
+	ReadTimeoutMs    int `toml:"read_timeout_ms" default:"2000"`
+
+	// Capability profile: a built-in model name or a [[profiles]] entry
+	Profile string `toml:"profile" default:"default"`
+
+	// Overrides of the profile: printable width of the paper in dots (0 keeps
+	// the profile's width) and support for the built-in QR/PDF417 generator
+	PaperWidthDots int  `toml:"paper_width_dots" default:"0"`
+	Native2DCodes  bool `toml:"native_2d_codes" default:"false"`
+
+	// ESC t code pages text may switch between, in order of preference; the
+	// first is selected after initialization. Empty keeps the profile's list
+	CodePages []int `toml:"code_pages"`
+
+	// Level of the drawer open/close signal (DLE EOT 1 bit 2) while the cash
+	// drawer is open: "high" or "low", depending on the drawer
+	DrawerOpenSignal string `toml:"drawer_open_signal" default:"high"`
+
+	// Poll the printer status this often between jobs and publish paper,
+	// cover and error alerts when it changes; 0 disables the monitor
+	StatusPollIntervalMs int `toml:"status_poll_interval_ms" default:"0"`
+
+	// Have the printer push its status whenever it changes (GS a, Automatic
+	// Status Back) instead of waiting to be polled. Status requests then wait
+	// up to read_timeout_ms for their reply
+	AutomaticStatusBack bool `toml:"automatic_status_back" default:"false"`
+
+	// Per-printer overrides of the top-level test_mode/usb_mode flags
+	TestMode bool `toml:"test_mode" default:"false"`
+	USBMode  bool `toml:"usb_mode" default:"false"`
+
+	// In test mode, save every emulated receipt as a PNG in this directory
+	TestOutputDir string `toml:"test_output_dir" default:""`
+}
+
+// ProfileConfig defines a custom printer profile. Settings that are left out
+// are inherited from the base profile.
+type ProfileConfig struct {
+	Name         string `toml:"name"`
+	Base         string `toml:"base"`
+	Description  string `toml:"description"`
+	DotsPerLine  int    `toml:"dots_per_line"`
+	ColumnsFontA int    `toml:"columns_font_a"`
+	ColumnsFontB int    `toml:"columns_font_b"`
+	CodePages    []int  `toml:"code_pages"`
+	Cutter       string `toml:"cutter"`
+	Barcodes     *bool  `toml:"barcodes"`
+	QRCode       *bool  `toml:"qr_code"`
+	PDF417       *bool  `toml:"pdf417"`
+	Buzzer       *bool  `toml:"buzzer"`
+
+	RasterBandHeight int `toml:"raster_band_height"`
 }
+
+// WebhookConfig is an HTTP endpoint the server POSTs job and printer events
+// to.
+type WebhookConfig struct {
+	// Name identifies the webhook in the delivery log; webhook-<n> when unset
+	Name string `toml:"name" default:""`
+	URL  string `toml:"url" default:""`
+	// Event types or categories to send, as for /api/v1/events; empty sends
+	// every event
+	Events []string `toml:"events"`
+	// Only send the events of these printers; empty sends those of all
+	Printers []string `toml:"printers"`
+	// Sign every payload with HMAC-SHA256 using this secret
+	Secret string `toml:"secret" default:""`
+	// "json" posts events as they appear on /api/v1/events, "slack" posts
+	// Slack messages
+	Format string `toml:"format" default:"json"`
+
+	TimeoutMs int `toml:"timeout_ms" default:"5000"`
+	// A failed delivery is tried up to max_attempts times in total, waiting
+	// retry_delay_ms before the first retry and twice as long before each
+	// next one, up to max_retry_delay_ms
+	MaxAttempts     int `toml:"max_attempts" default:"5"`
+	RetryDelayMs    int `toml:"retry_delay_ms" default:"1000"`
+	MaxRetryDelayMs int `toml:"max_retry_delay_ms" default:"60000"`
+}
+
+const (
+	WebhookFormatJSON  = "json"
+	WebhookFormatSlack = "slack"
+)
+
+const (
+	DeliveryAtLeastOnce = "at-least-once"
+	DeliveryAtMostOnce  = "at-most-once"
+)
+
+const (
+	DrawerSignalHigh = "high"
+	DrawerSignalLow  = "low"
+)
@@ -0,0 +1,220 @@
+// Package profile describes the capabilities of printer models: paper width,
+// characters per line, code pages, cutter and which barcode and 2D symbol
+// commands they understand.
+package profile
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/escpos"
+)
+
+// DefaultName is the profile used by printers that do not select one.
+const DefaultName = "default"
+
+// Cutter describes the paper cutter of a printer.
+type Cutter string
+
+const (
+	// CutterNone: the paper is torn off by hand; cut commands only feed.
+	CutterNone Cutter = "none"
+	// CutterPartial: the printer only leaves a hinge, a full cut is sent as partial.
+	CutterPartial Cutter = "partial"
+	// CutterFull: both full and partial cuts are supported.
+	CutterFull Cutter = "full"
+)
+
+// Profile describes what a printer model can do.
+type Profile struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+
+	// Printable width of the paper in dots
+	DotsPerLine int `json:"dotsPerLine"`
+	// Characters per line in font A and font B at normal size
+	ColumnsFontA int `json:"columnsFontA"`
+	ColumnsFontB int `json:"columnsFontB"`
+
+	// Code pages the printer supports; the first one is selected by default
+	CodePages []escpos.CharacterCodePage `json:"codePages"`
+
+	Cutter Cutter `json:"cutter"`
+
+	// Barcodes: GS k, QRCode and PDF417: GS ( k
+	Barcodes bool `json:"barcodes"`
+	QRCode   bool `json:"qrCode"`
+	PDF417   bool `json:"pdf417"`
+
+	// Buzzer: a built-in buzzer driven by ESC B
+	Buzzer bool `json:"buzzer"`
+
+	// Rows per GS v 0 command; taller images are sent in several bands
+	RasterBandHeight int `json:"rasterBandHeight"`
+}
+
+var builtins = map[string]Profile{
+	DefaultName: {
+		Description:      "Generic 58mm printer",
+		DotsPerLine:      384,
+		ColumnsFontA:     32,
+		ColumnsFontB:     42,
+		CodePages:        []escpos.CharacterCodePage{escpos.CharacterCodePagePC437},
+		Cutter:           CutterFull,
+		Barcodes:         true,
+		Buzzer:           true,
+		RasterBandHeight: escpos.DefaultRasterBandHeight,
+	},
+	"generic-80mm": {
+		Description:      "Generic 80mm printer",
+		DotsPerLine:      576,
+		ColumnsFontA:     48,
+		ColumnsFontB:     64,
+		CodePages:        []escpos.CharacterCodePage{escpos.CharacterCodePagePC437},
+		Cutter:           CutterFull,
+		Barcodes:         true,
+		Buzzer:           true,
+		RasterBandHeight: escpos.DefaultRasterBandHeight,
+	},
+	"pos-5890": {
+		Description:      "Zjiang POS-5890 and similar 58mm printers without a cutter",
+		DotsPerLine:      384,
+		ColumnsFontA:     32,
+		ColumnsFontB:     42,
+		CodePages:        []escpos.CharacterCodePage{escpos.CharacterCodePagePC437, escpos.CharacterCodePagePC850},
+		Cutter:           CutterNone,
+		Barcodes:         true,
+		Buzzer:           true,
+		RasterBandHeight: escpos.DefaultRasterBandHeight,
+	},
+	"tm-t88": {
+		Description:      "Epson TM-T88 (80mm, 512 dots)",
+		DotsPerLine:      512,
+		ColumnsFontA:     42,
+		ColumnsFontB:     56,
+		CodePages:        epsonCodePages,
+		Cutter:           CutterPartial,
+		Barcodes:         true,
+		QRCode:           true,
+		PDF417:           true,
+		RasterBandHeight: escpos.DefaultRasterBandHeight,
+	},
+	"tm-t20": {
+		Description:      "Epson TM-T20 (80mm, 576 dots)",
+		DotsPerLine:      576,
+		ColumnsFontA:     48,
+		ColumnsFontB:     64,
+		CodePages:        epsonCodePages,
+		Cutter:           CutterPartial,
+		Barcodes:         true,
+		QRCode:           true,
+		PDF417:           true,
+		RasterBandHeight: escpos.DefaultRasterBandHeight,
+	},
+	"tm-m30": {
+		Description:      "Epson TM-m30 (80mm, 576 dots)",
+		DotsPerLine:      576,
+		ColumnsFontA:     48,
+		ColumnsFontB:     64,
+		CodePages:        epsonCodePages,
+		Cutter:           CutterPartial,
+		Barcodes:         true,
+		QRCode:           true,
+		PDF417:           true,
+		RasterBandHeight: escpos.DefaultRasterBandHeight,
+	},
+}
+
+var epsonCodePages = []escpos.CharacterCodePage{
+	escpos.CharacterCodePagePC437,
+	escpos.CharacterCodePageKatakana,
+	escpos.CharacterCodePagePC850,
+	escpos.CharacterCodePagePC860,
+	escpos.CharacterCodePagePC863,
+	escpos.CharacterCodePagePC865,
+	escpos.CharacterCodePageWPC1252,
+	escpos.CharacterCodePagePC866,
+	escpos.CharacterCodePagePC852,
+	escpos.CharacterCodePagePC858,
+}
+
+// Builtin returns a copy of the built-in profile with the given name.
+func Builtin(name string) (Profile, bool) {
+	p, ok := builtins[strings.ToLower(name)]
+	if !ok {
+		return Profile{}, false
+	}
+	p.Name = strings.ToLower(name)
+	p.CodePages = slices.Clone(p.CodePages)
+	return p, true
+}
+
+// Default returns the profile used when a printer does not select one. It
+// matches the 58mm printers the service was originally written for.
+func Default() Profile {
+	p, _ := Builtin(DefaultName)
+	return p
+}
+
+// BuiltinNames returns the names of all built-in profiles, sorted.
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Columns returns the number of characters per line in the given font.
+func (p Profile) Columns(font escpos.CharacterFont) int {
+	if font == escpos.CharacterFontB {
+		return p.ColumnsFontB
+	}
+	return p.ColumnsFontA
+}
+
+// DefaultCodePage returns the code page selected after initialization.
+func (p Profile) DefaultCodePage() escpos.CharacterCodePage {
+	if len(p.CodePages) == 0 {
+		return escpos.CharacterCodePageDefault
+	}
+	return p.CodePages[0]
+}
+
+// SupportsCodePage reports whether the printer has the given code page.
+func (p Profile) SupportsCodePage(codePage escpos.CharacterCodePage) bool {
+	return slices.Contains(p.CodePages, codePage)
+}
+
+// Validate checks that the profile describes a usable printer.
+func (p Profile) Validate() error {
+	switch {
+	case p.DotsPerLine < 8 || p.DotsPerLine > 2048:
+		return fmt.Errorf("profile %q: dots_per_line must be between 8 and 2048; got %d", p.Name, p.DotsPerLine)
+	case p.ColumnsFontA < 1:
+		return fmt.Errorf("profile %q: columns_font_a must be positive; got %d", p.Name, p.ColumnsFontA)
+	case p.ColumnsFontB < 1:
+		return fmt.Errorf("profile %q: columns_font_b must be positive; got %d", p.Name, p.ColumnsFontB)
+	case len(p.CodePages) == 0:
+		return fmt.Errorf("profile %q: at least one code page is required", p.Name)
+	case p.RasterBandHeight < 1 || p.RasterBandHeight > escpos.MaxRasterBandHeight:
+		return fmt.Errorf("profile %q: raster_band_height must be between 1 and %d; got %d", p.Name, escpos.MaxRasterBandHeight, p.RasterBandHeight)
+	}
+
+	for _, codePage := range p.CodePages {
+		if codePage < 0 || codePage > 255 {
+			return fmt.Errorf("profile %q: invalid code page %d", p.Name, codePage)
+		}
+	}
+
+	switch p.Cutter {
+	case CutterNone, CutterPartial, CutterFull:
+	default:
+		return fmt.Errorf("profile %q: cutter must be %q, %q or %q; got %q", p.Name, CutterNone, CutterPartial, CutterFull, p.Cutter)
+	}
+
+	return nil
+}
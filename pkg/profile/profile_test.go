@@ -0,0 +1,66 @@
+package profile
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/escpos"
+)
+
+func TestBuiltinProfilesAreValid(t *testing.T) {
+	for _, name := range BuiltinNames() {
+		p, ok := Builtin(name)
+		if !ok {
+			t.Fatalf("%s: expected built-in profile", name)
+		}
+		if err := p.Validate(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if p.Name != name {
+			t.Errorf("%s: expected name to be set, got %q", name, p.Name)
+		}
+	}
+}
+
+func TestBuiltinReturnsCopies(t *testing.T) {
+	p, ok := Builtin("TM-T88")
+	if !ok {
+		t.Fatal("expected lookups to ignore case")
+	}
+	if p.DotsPerLine != 512 || p.Columns(escpos.CharacterFontA) != 42 || p.Columns(escpos.CharacterFontB) != 56 {
+		t.Fatalf("unexpected TM-T88 geometry: %+v", p)
+	}
+
+	p.CodePages[0] = escpos.CharacterCodePageWPC1250
+	again, _ := Builtin("tm-t88")
+	if again.DefaultCodePage() != escpos.CharacterCodePagePC437 {
+		t.Fatal("expected changes to a returned profile not to leak into the built-in")
+	}
+}
+
+func TestDefaultProfileMatchesLegacyBehaviour(t *testing.T) {
+	p := Default()
+	if p.DotsPerLine != 384 || p.ColumnsFontA != 32 || p.DefaultCodePage() != escpos.CharacterCodePageDefault {
+		t.Fatalf("unexpected default profile: %+v", p)
+	}
+	if p.QRCode || p.PDF417 || !p.Barcodes || p.Cutter != CutterFull {
+		t.Fatalf("unexpected default features: %+v", p)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := map[string]func(*Profile){
+		"dots_per_line must be between 8 and 2048":   func(p *Profile) { p.DotsPerLine = 4096 },
+		"columns_font_b must be positive":            func(p *Profile) { p.ColumnsFontB = 0 },
+		"at least one code page is required":         func(p *Profile) { p.CodePages = nil },
+		"invalid code page 300":                      func(p *Profile) { p.CodePages = []escpos.CharacterCodePage{300} },
+		`cutter must be "none", "partial" or "full"`: func(p *Profile) { p.Cutter = "guillotine" },
+	}
+	for want, mutate := range cases {
+		p := Default()
+		mutate(&p)
+		if err := p.Validate(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error containing %q, got %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,439 @@
+// Package schema validates template variables against a JSON Schema. It
+// supports the subset of the specification that describes template data:
+// type, properties, required, additionalProperties, items, enum, minimum,
+// maximum, minLength, maxLength, pattern, minItems, maxItems and default.
+// Annotations such as title, description and examples are kept for clients
+// but not interpreted; unknown keywords are ignored.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema is a parsed JSON Schema.
+type Schema struct {
+	Type        Types              `json:"type,omitempty"`
+	Title       string             `json:"title,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	// AdditionalProperties is false, true or a schema for properties not
+	// listed in Properties. Absent means any property is allowed.
+	AdditionalProperties json.RawMessage `json:"additionalProperties,omitempty"`
+	Items                *Schema         `json:"items,omitempty"`
+	Enum                 []any           `json:"enum,omitempty"`
+	Minimum              *float64        `json:"minimum,omitempty"`
+	Maximum              *float64        `json:"maximum,omitempty"`
+	MinLength            *int            `json:"minLength,omitempty"`
+	MaxLength            *int            `json:"maxLength,omitempty"`
+	Pattern              string          `json:"pattern,omitempty"`
+	MinItems             *int            `json:"minItems,omitempty"`
+	MaxItems             *int            `json:"maxItems,omitempty"`
+	Default              any             `json:"default,omitempty"`
+	Examples             []any           `json:"examples,omitempty"`
+
+	pattern    *regexp.Regexp
+	additional *Schema
+	closed     bool
+}
+
+// Types is the type keyword: one type name or a list of them.
+type Types []string
+
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = Types{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return errors.New("type must be a string or a list of strings")
+	}
+	*t = many
+	return nil
+}
+
+var typeNames = []string{"object", "array", "string", "number", "integer", "boolean", "null"}
+
+// Problem is a value that does not match the schema.
+type Problem struct {
+	// Path locates the value, such as items[0].price; empty for the root
+	Path    string
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Path == "" {
+		return p.Message
+	}
+	return p.Path + ": " + p.Message
+}
+
+// Parse reads a JSON Schema document and checks that it is usable.
+func Parse(data []byte) (*Schema, error) {
+	var s Schema
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&s); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	if err := s.compile(""); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return &s, nil
+}
+
+// compile checks the schema at path and prepares it for validation.
+func (s *Schema) compile(path string) error {
+	at := func(format string, args ...any) error {
+		if path != "" {
+			format = path + ": " + format
+		}
+		return fmt.Errorf(format, args...)
+	}
+
+	for _, name := range s.Type {
+		if !slices.Contains(typeNames, name) {
+			return at("unknown type %q", name)
+		}
+	}
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return at("invalid pattern: %v", err)
+		}
+		s.pattern = pattern
+	}
+	if len(s.AdditionalProperties) > 0 {
+		var allowed bool
+		if err := json.Unmarshal(s.AdditionalProperties, &allowed); err == nil {
+			s.closed = !allowed
+		} else {
+			additional, err := Parse(s.AdditionalProperties)
+			if err != nil {
+				return at("additionalProperties: %v", errors.Unwrap(err))
+			}
+			s.additional = additional
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(s.Properties)) {
+		property := s.Properties[name]
+		if property == nil {
+			return at("property %q has no schema", name)
+		}
+		if err := property.compile(joinPath(path, name)); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		if err := s.Items.compile(path + "[]"); err != nil {
+			return err
+		}
+	}
+
+	s.Default = normalize(s.Default)
+	for i := range s.Enum {
+		s.Enum[i] = normalize(s.Enum[i])
+	}
+	if s.Default != nil {
+		if problems := s.Validate(s.Default); len(problems) > 0 {
+			return at("default does not match the schema: %s", problems[0].Message)
+		}
+	}
+	return nil
+}
+
+// WithDefaults returns value with the defaults of missing object properties
+// filled in, recursively. value itself is not modified.
+func (s *Schema) WithDefaults(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		var out map[string]any
+		set := func(key string, property any) {
+			if out == nil {
+				out = maps.Clone(v)
+			}
+			out[key] = property
+		}
+		for _, name := range slices.Sorted(maps.Keys(s.Properties)) {
+			property := s.Properties[name]
+			current, ok := v[name]
+			switch {
+			case !ok && property.Default != nil:
+				set(name, cloneJSON(property.Default))
+			case ok:
+				if filled := property.WithDefaults(current); !sameValue(filled, current) {
+					set(name, filled)
+				}
+			}
+		}
+		if out == nil {
+			return v
+		}
+		return out
+
+	case []any:
+		if s.Items == nil {
+			return v
+		}
+		var out []any
+		for i, item := range v {
+			if filled := s.Items.WithDefaults(item); !sameValue(filled, item) {
+				if out == nil {
+					out = slices.Clone(v)
+				}
+				out[i] = filled
+			}
+		}
+		if out == nil {
+			return v
+		}
+		return out
+
+	default:
+		return value
+	}
+}
+
+// Validate returns every problem of value, in a stable order.
+func (s *Schema) Validate(value any) []Problem {
+	var problems []Problem
+	s.validate("", normalize(value), &problems)
+	return problems
+}
+
+func (s *Schema) validate(path string, value any, problems *[]Problem) {
+	report := func(format string, args ...any) {
+		*problems = append(*problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(name string) bool { return hasType(value, name) }) {
+		report("must be %s, got %s", strings.Join(withArticles(s.Type), " or "), describe(value))
+		return
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(allowed any) bool { return reflect.DeepEqual(allowed, value) }) {
+		report("must be one of %s", formatEnum(s.Enum))
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*problems = append(*problems, Problem{Path: joinPath(path, name), Message: "is required"})
+			}
+		}
+		for _, name := range slices.Sorted(maps.Keys(v)) {
+			property, ok := s.Properties[name]
+			switch {
+			case ok:
+				property.validate(joinPath(path, name), v[name], problems)
+			case s.additional != nil:
+				s.additional.validate(joinPath(path, name), v[name], problems)
+			case s.closed:
+				*problems = append(*problems, Problem{Path: joinPath(path, name), Message: "is not allowed"})
+			}
+		}
+
+	case []any:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			report("must have at least %d items, got %d", *s.MinItems, len(v))
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			report("must have at most %d items, got %d", *s.MaxItems, len(v))
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(path+"["+strconv.Itoa(i)+"]", item, problems)
+			}
+		}
+
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.MinLength != nil && length < *s.MinLength {
+			report("must be at least %d characters long", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			report("must be at most %d characters long", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			report("must match %s", s.Pattern)
+		}
+
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			report("must be at least %s", formatNumber(*s.Minimum))
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			report("must be at most %s", formatNumber(*s.Maximum))
+		}
+	}
+}
+
+// normalize converts value to the types encoding/json produces, so Go
+// values and decoded JSON validate the same way.
+func normalize(value any) any {
+	switch v := value.(type) {
+	case nil, bool, string, float64:
+		return v
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			out[key] = normalize(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = normalize(item)
+		}
+		return out
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return v.String()
+		}
+		return f
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	}
+
+	// Other Go values, such as structs or typed slices, through their JSON form
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var out any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&out); err != nil {
+		return value
+	}
+	return normalize(out)
+}
+
+func hasType(value any, name string) bool {
+	switch name {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f) && !math.IsInf(f, 0)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return false
+}
+
+func describe(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "an object"
+	case []any:
+		return "an array"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case float64:
+		return "the number " + formatNumber(v)
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func withArticles(types []string) []string {
+	out := make([]string, len(types))
+	for i, name := range types {
+		switch name {
+		case "null":
+			out[i] = "null"
+		case "object", "array", "integer":
+			out[i] = "an " + name
+		default:
+			out[i] = "a " + name
+		}
+	}
+	return out
+}
+
+func formatEnum(values []any) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		data, _ := json.Marshal(value)
+		parts[i] = string(data)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// cloneJSON copies a default so templates cannot share and modify it.
+func cloneJSON(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			out[key] = cloneJSON(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = cloneJSON(item)
+		}
+		return out
+	}
+	return value
+}
+
+// sameValue reports whether WithDefaults returned its input unchanged.
+func sameValue(a, b any) bool {
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		return ok && reflect.ValueOf(av).UnsafePointer() == reflect.ValueOf(bv).UnsafePointer()
+	case []any:
+		bv, ok := b.([]any)
+		return ok && len(av) == len(bv) && (len(av) == 0 || &av[0] == &bv[0])
+	}
+	return true
+}
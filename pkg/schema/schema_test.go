@@ -0,0 +1,134 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+const receiptSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"required": ["storeName", "items", "total"],
+	"properties": {
+		"storeName": {"type": "string", "minLength": 1, "examples": ["Coffee & More"]},
+		"currency": {"type": "string", "enum": ["EUR", "USD"], "default": "EUR"},
+		"items": {
+			"type": "array",
+			"minItems": 1,
+			"items": {
+				"type": "object",
+				"required": ["name", "price"],
+				"properties": {
+					"name": {"type": "string"},
+					"qty": {"type": "integer", "minimum": 1, "default": 1},
+					"price": {"type": "number", "minimum": 0}
+				},
+				"additionalProperties": false
+			}
+		},
+		"total": {"type": "number"},
+		"orderNumber": {"type": ["string", "integer"], "pattern": "^[0-9]+$"}
+	}
+}`
+
+func mustParse(t *testing.T, data string) *Schema {
+	t.Helper()
+	s, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	return s
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	s := mustParse(t, receiptSchema)
+
+	problems := s.Validate(map[string]any{
+		"storeName":   "",
+		"currency":    "GBP",
+		"items":       []any{map[string]any{"name": "Tea", "qty": 1.5, "price": -1.0, "size": "L"}, "x"},
+		"orderNumber": "A-1",
+	})
+	var got []string
+	for _, problem := range problems {
+		got = append(got, problem.String())
+	}
+	want := []string{
+		"total: is required",
+		`currency: must be one of "EUR", "USD"`,
+		"items[0].price: must be at least 0",
+		"items[0].qty: must be an integer, got the number 1.5",
+		"items[0].size: is not allowed",
+		"items[1]: must be an object, got a string",
+		"orderNumber: must match ^[0-9]+$",
+		"storeName: must be at least 1 characters long",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("expected\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+
+	valid := map[string]any{
+		"storeName":   "Corner",
+		"items":       []any{map[string]any{"name": "Tea", "price": 2.5}},
+		"total":       2.5,
+		"orderNumber": 42,
+	}
+	if problems := s.Validate(valid); len(problems) != 0 {
+		t.Fatalf("expected no problems, got %v", problems)
+	}
+	if problems := s.Validate(nil); len(problems) != 1 || problems[0].String() != "must be an object, got null" {
+		t.Fatalf("expected a type problem for missing variables, got %v", problems)
+	}
+}
+
+func TestValidateGoValues(t *testing.T) {
+	s := mustParse(t, `{"type": "object", "properties": {"qty": {"type": "integer"}, "lines": {"type": "array", "items": {"type": "string"}}}}`)
+	if problems := s.Validate(map[string]any{"qty": 3, "lines": []string{"a", "b"}}); len(problems) != 0 {
+		t.Fatalf("expected Go values to validate, got %v", problems)
+	}
+	if problems := s.Validate(map[string]any{"lines": []int{1}}); len(problems) != 1 || problems[0].Path != "lines[0]" {
+		t.Fatalf("expected a problem at lines[0], got %v", problems)
+	}
+}
+
+func TestWithDefaults(t *testing.T) {
+	s := mustParse(t, receiptSchema)
+
+	item := map[string]any{"name": "Tea", "price": 2.5}
+	variables := map[string]any{"items": []any{item}}
+	filled := s.WithDefaults(variables).(map[string]any)
+
+	if filled["currency"] != "EUR" {
+		t.Fatalf("expected the default currency, got %v", filled["currency"])
+	}
+	if qty := filled["items"].([]any)[0].(map[string]any)["qty"]; qty != 1.0 {
+		t.Fatalf("expected the default quantity in the item, got %v", qty)
+	}
+	if _, ok := variables["currency"]; ok {
+		t.Fatal("expected the input to be left alone")
+	}
+	if _, ok := item["qty"]; ok {
+		t.Fatal("expected the input item to be left alone")
+	}
+
+	unchanged := map[string]any{"currency": "USD", "items": []any{map[string]any{"qty": 2}}}
+	if out := s.WithDefaults(unchanged).(map[string]any); out["currency"] != "USD" || out["items"].([]any)[0].(map[string]any)["qty"] != 2 {
+		t.Fatalf("expected given values to be kept, got %v", out)
+	}
+}
+
+func TestParseRejectsInvalidSchemas(t *testing.T) {
+	cases := map[string]string{
+		`{"type": "decimal"}`: `unknown type "decimal"`,
+		`{"type": 5}`:         "type must be a string",
+		`{"properties": {"a": {"pattern": "("}}}`:                 "a: invalid pattern",
+		`{"properties": {"a": {"type": "string", "default": 1}}}`: "a: default does not match the schema",
+		`{"items": {"type": ["x"]}}`:                              `[]: unknown type "x"`,
+		`not json`:                                                "invalid schema",
+	}
+	for data, want := range cases {
+		if _, err := Parse([]byte(data)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%s: expected %q error, got %v", data, want, err)
+		}
+	}
+}
@@ -3,14 +3,28 @@ package service
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"net/url"
 	"os"
 	"reflect"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/jonasclaes/go-thermal-printer/pkg/escpos"
 	"github.com/jonasclaes/go-thermal-printer/pkg/model"
+	"github.com/jonasclaes/go-thermal-printer/pkg/profile"
+	"github.com/jonasclaes/go-thermal-printer/pkg/template"
+	"github.com/jonasclaes/go-thermal-printer/pkg/typeset"
 	"github.com/pelletier/go-toml/v2"
 )
 
+const defaultPrinterName = "default"
+
+var printerNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
 type ConfigService struct {
 	config *model.AppConfig
 }
@@ -39,8 +53,213 @@ func (cs *ConfigService) GetServerConfig() *model.ServerConfig {
 	return &cs.config.Server
 }
 
+func (cs *ConfigService) GetQueueConfig() *model.QueueConfig {
+	return &cs.config.Queue
+}
+
+func (cs *ConfigService) GetFontConfig() *model.FontConfig {
+	return &cs.config.Font
+}
+
+func (cs *ConfigService) GetTemplatesConfig() *model.TemplatesConfig {
+	return &cs.config.Templates
+}
+
+func (cs *ConfigService) GetWebhookConfigs() []model.WebhookConfig {
+	return cs.config.Webhooks
+}
+
+// GetRenderLimits returns the template render limits of the [templates] table.
+func (cs *ConfigService) GetRenderLimits() template.Limits {
+	templates := cs.GetTemplatesConfig()
+	return template.Limits{
+		Timeout:        time.Duration(templates.RenderTimeoutMs) * time.Millisecond,
+		MaxOutputBytes: templates.MaxOutputBytes,
+		MaxImages:      templates.MaxImages,
+		MaxFeedLines:   templates.MaxFeedLines,
+	}
+}
+
+// LoadTypesetter loads the fonts of the [font] table. It returns nil when no
+// regular font is configured.
+func (cs *ConfigService) LoadTypesetter() (*typeset.Typesetter, error) {
+	font := cs.GetFontConfig()
+	if font.Regular == "" {
+		return nil, nil
+	}
+	typesetter, err := typeset.Load(font.Regular, font.Bold, font.Fallbacks...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fonts: %w", err)
+	}
+	return typesetter, nil
+}
+
+// GetPrinterConfig returns the configuration of the default printer.
 func (cs *ConfigService) GetPrinterConfig() *model.PrinterConfig {
-	return &cs.config.Printer
+	printers := cs.GetPrinterConfigs()
+	name := cs.GetDefaultPrinterName()
+	for i := range printers {
+		if printers[i].Name == name {
+			return &printers[i]
+		}
+	}
+	return &printers[0]
+}
+
+// GetPrinterConfigs returns every configured printer. When no [[printers]]
+// array is present the legacy [printer] table is exposed as a single printer
+// named "default". The top-level test_mode/usb_mode flags apply to all printers.
+func (cs *ConfigService) GetPrinterConfigs() []model.PrinterConfig {
+	var printers []model.PrinterConfig
+	if len(cs.config.Printers) == 0 {
+		printers = []model.PrinterConfig{cs.config.Printer}
+	} else {
+		printers = append(printers, cs.config.Printers...)
+	}
+
+	for i := range printers {
+		if printers[i].Name == "" {
+			printers[i].Name = defaultPrinterName
+		}
+		printers[i].TestMode = printers[i].TestMode || cs.config.TestMode
+		printers[i].USBMode = printers[i].USBMode || cs.config.USBMode
+	}
+
+	return printers
+}
+
+// GetPrinterProfile returns the capability profile of a printer with its
+// paper_width_dots, native_2d_codes and code_pages overrides applied.
+func (cs *ConfigService) GetPrinterProfile(printer *model.PrinterConfig) (profile.Profile, error) {
+	profiles, err := resolveProfiles(cs.config.Profiles)
+	if err != nil {
+		return profile.Profile{}, err
+	}
+	return printerProfile(printer, profiles)
+}
+
+func printerProfile(printer *model.PrinterConfig, profiles map[string]profile.Profile) (profile.Profile, error) {
+	name := printer.Profile
+	if name == "" {
+		name = profile.DefaultName
+	}
+
+	p, ok := lookupProfile(name, profiles)
+	if !ok {
+		return profile.Profile{}, fmt.Errorf("printer %q: unknown profile %q (built-in profiles: %s)",
+			printer.Name, name, strings.Join(profile.BuiltinNames(), ", "))
+	}
+
+	if printer.PaperWidthDots > 0 {
+		p.DotsPerLine = printer.PaperWidthDots
+	}
+	if printer.Native2DCodes {
+		p.QRCode = true
+		p.PDF417 = true
+	}
+	if len(printer.CodePages) > 0 {
+		p.CodePages = codePages(printer.CodePages)
+	}
+	return p, nil
+}
+
+func lookupProfile(name string, profiles map[string]profile.Profile) (profile.Profile, bool) {
+	if p, ok := profiles[name]; ok {
+		p.CodePages = slices.Clone(p.CodePages)
+		return p, true
+	}
+	return profile.Builtin(name)
+}
+
+// resolveProfiles turns the [[profiles]] tables into profiles. Each one starts
+// as a copy of its base (a built-in or an earlier custom profile, "default"
+// when unset) and overrides the settings it defines.
+func resolveProfiles(configs []model.ProfileConfig) (map[string]profile.Profile, error) {
+	profiles := make(map[string]profile.Profile, len(configs))
+	for i, config := range configs {
+		if !printerNamePattern.MatchString(config.Name) {
+			return nil, fmt.Errorf("profiles[%d]: invalid name %q (use letters, digits, '-' and '_')", i, config.Name)
+		}
+		if _, ok := profile.Builtin(config.Name); ok {
+			return nil, fmt.Errorf("profiles[%d]: %q is a built-in profile; pick another name and set base = %q", i, config.Name, config.Name)
+		}
+		if _, ok := profiles[config.Name]; ok {
+			return nil, fmt.Errorf("profiles[%d]: duplicate name %q", i, config.Name)
+		}
+
+		base := config.Base
+		if base == "" {
+			base = profile.DefaultName
+		}
+		p, ok := lookupProfile(base, profiles)
+		if !ok {
+			return nil, fmt.Errorf("profiles[%d]: unknown base profile %q", i, base)
+		}
+
+		p.Name = config.Name
+		if config.Description != "" {
+			p.Description = config.Description
+		}
+		if config.DotsPerLine != 0 {
+			p.DotsPerLine = config.DotsPerLine
+		}
+		if config.ColumnsFontA != 0 {
+			p.ColumnsFontA = config.ColumnsFontA
+		}
+		if config.ColumnsFontB != 0 {
+			p.ColumnsFontB = config.ColumnsFontB
+		}
+		if config.CodePages != nil {
+			p.CodePages = codePages(config.CodePages)
+		}
+		if config.Cutter != "" {
+			p.Cutter = profile.Cutter(strings.ToLower(config.Cutter))
+		}
+		if config.Barcodes != nil {
+			p.Barcodes = *config.Barcodes
+		}
+		if config.QRCode != nil {
+			p.QRCode = *config.QRCode
+		}
+		if config.PDF417 != nil {
+			p.PDF417 = *config.PDF417
+		}
+		if config.Buzzer != nil {
+			p.Buzzer = *config.Buzzer
+		}
+		if config.RasterBandHeight != 0 {
+			p.RasterBandHeight = config.RasterBandHeight
+		}
+
+		if err := p.Validate(); err != nil {
+			return nil, fmt.Errorf("profiles[%d]: %w", i, err)
+		}
+		profiles[config.Name] = p
+	}
+
+	return profiles, nil
+}
+
+func codePages(values []int) []escpos.CharacterCodePage {
+	pages := make([]escpos.CharacterCodePage, len(values))
+	for i, value := range values {
+		pages[i] = escpos.CharacterCodePage(value)
+	}
+	return pages
+}
+
+// GetDefaultPrinterName returns the printer served by the /api/v1/printer/* routes.
+func (cs *ConfigService) GetDefaultPrinterName() string {
+	if cs.config.DefaultPrinter != "" {
+		return cs.config.DefaultPrinter
+	}
+	if len(cs.config.Printers) > 0 {
+		return cs.config.Printers[0].Name
+	}
+	if cs.config.Printer.Name != "" {
+		return cs.config.Printer.Name
+	}
+	return defaultPrinterName
 }
 
 func loadConfig(path string) (*model.AppConfig, error) {
@@ -66,9 +285,258 @@ func loadConfig(path string) (*model.AppConfig, error) {
 		return nil, fmt.Errorf("failed to parse TOML config: %w", err)
 	}
 
+	// [[printers]] and [[webhooks]] entries are created by the decoder, so
+	// decode them again on top of their defaults
+	var tables struct {
+		Printers []map[string]any `toml:"printers"`
+		Webhooks []map[string]any `toml:"webhooks"`
+	}
+	if err := toml.Unmarshal(data, &tables); err != nil {
+		return nil, fmt.Errorf("failed to parse TOML config: %w", err)
+	}
+	for i, table := range tables.Printers {
+		// Unlike [printer], every [[printers]] entry needs a name of its own
+		if _, ok := table["name"]; !ok {
+			return nil, fmt.Errorf("invalid printer configuration: printers[%d]: name is required", i)
+		}
+	}
+	if config.Printers, err = decodeTables[model.PrinterConfig](tables.Printers); err != nil {
+		return nil, fmt.Errorf("failed to parse TOML config: printers%w", err)
+	}
+	if config.Webhooks, err = decodeTables[model.WebhookConfig](tables.Webhooks); err != nil {
+		return nil, fmt.Errorf("failed to parse TOML config: webhooks%w", err)
+	}
+
+	if err := validatePrinters(config); err != nil {
+		return nil, fmt.Errorf("invalid printer configuration: %w", err)
+	}
+
+	switch config.Queue.Delivery {
+	case model.DeliveryAtLeastOnce, model.DeliveryAtMostOnce:
+	default:
+		return nil, fmt.Errorf("invalid queue delivery %q: use %q or %q",
+			config.Queue.Delivery, model.DeliveryAtLeastOnce, model.DeliveryAtMostOnce)
+	}
+
+	if err := validateProfiles(config); err != nil {
+		return nil, fmt.Errorf("invalid profile configuration: %w", err)
+	}
+
+	if err := validateFont(config.Font); err != nil {
+		return nil, fmt.Errorf("invalid font configuration: %w", err)
+	}
+
+	if err := validateRenderLimits(config.Templates); err != nil {
+		return nil, fmt.Errorf("invalid templates configuration: %w", err)
+	}
+
+	for i := range config.Webhooks {
+		if config.Webhooks[i].Name == "" {
+			config.Webhooks[i].Name = fmt.Sprintf("webhook-%d", i+1)
+		}
+	}
+	if err := validateWebhooks(config); err != nil {
+		return nil, fmt.Errorf("invalid webhook configuration: %w", err)
+	}
+
 	return config, nil
 }
 
+func validatePrinters(config *model.AppConfig) error {
+	if err := validatePrinter(config.Printer); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(config.Printers))
+	for i, printer := range config.Printers {
+		if err := validatePrinter(printer); err != nil {
+			return fmt.Errorf("printers[%d]: %w", i, err)
+		}
+		if !printerNamePattern.MatchString(printer.Name) {
+			return fmt.Errorf("printers[%d]: invalid name %q (use letters, digits, '-' and '_')", i, printer.Name)
+		}
+		if seen[printer.Name] {
+			return fmt.Errorf("printers[%d]: duplicate name %q", i, printer.Name)
+		}
+		seen[printer.Name] = true
+	}
+
+	if config.DefaultPrinter == "" {
+		return nil
+	}
+	if len(config.Printers) == 0 {
+		if config.DefaultPrinter != config.Printer.Name {
+			return fmt.Errorf("default_printer %q does not match the [printer] table", config.DefaultPrinter)
+		}
+		return nil
+	}
+	if !seen[config.DefaultPrinter] {
+		return fmt.Errorf("default_printer %q is not defined in [[printers]]", config.DefaultPrinter)
+	}
+
+	return nil
+}
+
+// validateDrawerSignal accepts an unset value, which is filled with the default later.
+// validatePrinter checks the settings of one printer table.
+func validatePrinter(printer model.PrinterConfig) error {
+	if printer.StatusPollIntervalMs < 0 {
+		return fmt.Errorf("status_poll_interval_ms must be 0 (off) or more; got %d", printer.StatusPollIntervalMs)
+	}
+	return validateDrawerSignal(printer)
+}
+
+func validateDrawerSignal(printer model.PrinterConfig) error {
+	switch printer.DrawerOpenSignal {
+	case "", model.DrawerSignalHigh, model.DrawerSignalLow:
+		return nil
+	default:
+		return fmt.Errorf("invalid drawer_open_signal %q: use %q or %q",
+			printer.DrawerOpenSignal, model.DrawerSignalHigh, model.DrawerSignalLow)
+	}
+}
+
+// validateProfiles checks the [[profiles]] tables and that every printer
+// selects a known profile.
+func validateProfiles(config *model.AppConfig) error {
+	profiles, err := resolveProfiles(config.Profiles)
+	if err != nil {
+		return err
+	}
+
+	printers := config.Printers
+	if len(printers) == 0 {
+		printers = []model.PrinterConfig{config.Printer}
+	}
+	for i := range printers {
+		p, err := printerProfile(&printers[i], profiles)
+		if err != nil {
+			return err
+		}
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("printer %q: %w", printers[i].Name, err)
+		}
+	}
+
+	return nil
+}
+
+// validateFont checks the [font] table. The font files are read when the
+// printers are opened, see LoadTypesetter.
+func validateFont(font model.FontConfig) error {
+	if font.Size < 0 || font.Size > typeset.MaxSize {
+		return fmt.Errorf("size must be between 1 and %d dots; got %d", typeset.MaxSize, font.Size)
+	}
+	if font.Regular == "" && (font.Bold != "" || len(font.Fallbacks) > 0 || font.AutoFallback) {
+		return errors.New("regular is required when bold, fallbacks or auto_fallback are set")
+	}
+	return nil
+}
+
+// validateRenderLimits checks the render limits of the [templates] table.
+func validateRenderLimits(templates model.TemplatesConfig) error {
+	limits := map[string]int{
+		"render_timeout_ms": templates.RenderTimeoutMs,
+		"max_output_bytes":  templates.MaxOutputBytes,
+		"max_images":        templates.MaxImages,
+		"max_feed_lines":    templates.MaxFeedLines,
+	}
+	for _, name := range slices.Sorted(maps.Keys(limits)) {
+		if limits[name] < 0 {
+			return fmt.Errorf("%s must be 0 (unlimited) or more; got %d", name, limits[name])
+		}
+	}
+	return nil
+}
+
+// validateWebhooks checks the [[webhooks]] tables, whose defaults must have
+// been filled in.
+func validateWebhooks(config *model.AppConfig) error {
+	printers := []string{config.Printer.Name}
+	if config.Printer.Name == "" {
+		printers = []string{defaultPrinterName}
+	}
+	if len(config.Printers) > 0 {
+		printers = nil
+		for _, printer := range config.Printers {
+			printers = append(printers, printer.Name)
+		}
+	}
+
+	seen := make(map[string]bool, len(config.Webhooks))
+	for i, webhook := range config.Webhooks {
+		if err := validateWebhook(webhook, printers); err != nil {
+			return fmt.Errorf("webhooks[%d]: %w", i, err)
+		}
+		if seen[webhook.Name] {
+			return fmt.Errorf("webhooks[%d]: duplicate name %q", i, webhook.Name)
+		}
+		seen[webhook.Name] = true
+	}
+	return nil
+}
+
+func validateWebhook(webhook model.WebhookConfig, printers []string) error {
+	if !printerNamePattern.MatchString(webhook.Name) {
+		return fmt.Errorf("invalid name %q (use letters, digits, '-' and '_')", webhook.Name)
+	}
+	target, err := url.Parse(webhook.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("url must be an absolute http or https URL; got %q", webhook.URL)
+	}
+	for _, eventType := range webhook.Events {
+		if !KnownEventFilter(eventType) {
+			return fmt.Errorf("unknown event type %q", eventType)
+		}
+	}
+	for _, printer := range webhook.Printers {
+		if !slices.Contains(printers, printer) {
+			return fmt.Errorf("unknown printer %q", printer)
+		}
+	}
+	switch webhook.Format {
+	case model.WebhookFormatJSON, model.WebhookFormatSlack:
+	default:
+		return fmt.Errorf("invalid format %q: use %q or %q", webhook.Format, model.WebhookFormatJSON, model.WebhookFormatSlack)
+	}
+	if webhook.MaxAttempts < 1 {
+		return fmt.Errorf("max_attempts must be 1 or more; got %d", webhook.MaxAttempts)
+	}
+
+	durations := map[string]int{
+		"timeout_ms":         webhook.TimeoutMs,
+		"retry_delay_ms":     webhook.RetryDelayMs,
+		"max_retry_delay_ms": webhook.MaxRetryDelayMs,
+	}
+	for _, name := range slices.Sorted(maps.Keys(durations)) {
+		if durations[name] < 0 {
+			return fmt.Errorf("%s must be 0 or more; got %d", name, durations[name])
+		}
+	}
+	return nil
+}
+
+// decodeTables decodes each table of a TOML array of tables into a struct
+// that already holds its defaults, so a setting written as 0 or "" keeps
+// that value instead of being taken for one that was left out.
+func decodeTables[T any](tables []map[string]any) ([]T, error) {
+	if tables == nil {
+		return nil, nil
+	}
+	values := make([]T, len(tables))
+	for i, table := range tables {
+		setStructDefaults(reflect.ValueOf(&values[i]).Elem())
+		data, err := toml.Marshal(table)
+		if err != nil {
+			return nil, fmt.Errorf("[%d]: %w", i, err)
+		}
+		if err := toml.Unmarshal(data, &values[i]); err != nil {
+			return nil, fmt.Errorf("[%d]: %w", i, err)
+		}
+	}
+	return values, nil
+}
+
 func setDefaultValues(config *model.AppConfig) {
 	setStructDefaults(reflect.ValueOf(config).Elem())
 }
@@ -90,6 +558,11 @@ func setStructDefaults(v reflect.Value) {
 			continue
 		}
 
+		// Keep values that were already set (e.g. decoded from TOML)
+		if !field.IsZero() {
+			continue
+		}
+
 		// Get default value from tag
 		defaultValue := fieldType.Tag.Get("default")
 		if defaultValue == "" {
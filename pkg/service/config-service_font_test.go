@@ -0,0 +1,70 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestLoadConfigFont(t *testing.T) {
+	fontPath := filepath.Join(t.TempDir(), "regular.ttf")
+	if err := os.WriteFile(fontPath, goregular.TTF, 0o644); err != nil {
+		t.Fatalf("write font: %v", err)
+	}
+	path := writeConfig(t, `
+[font]
+regular = "`+filepath.ToSlash(fontPath)+`"
+auto_fallback = true
+`)
+
+	config, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cs := &ConfigService{config: config}
+	if font := cs.GetFontConfig(); font.Size != 24 || !font.AutoFallback {
+		t.Fatalf("expected the default size and auto fallback, got %+v", font)
+	}
+
+	typesetter, err := cs.LoadTypesetter()
+	if err != nil || typesetter == nil {
+		t.Fatalf("expected the font to load, got %v", err)
+	}
+	if !typesetter.Covers("Ελληνικά") {
+		t.Fatalf("expected the Go font to cover Greek")
+	}
+}
+
+func TestLoadConfigWithoutFont(t *testing.T) {
+	config, err := loadConfig(writeConfig(t, ``))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	typesetter, err := (&ConfigService{config: config}).LoadTypesetter()
+	if err != nil || typesetter != nil {
+		t.Fatalf("expected no typesetter, got %v, %v", typesetter, err)
+	}
+}
+
+func TestLoadConfigRejectsInvalidFont(t *testing.T) {
+	cases := map[string]string{
+		"[font]\nauto_fallback = true\n":            "regular is required",
+		"[font]\nregular = \"a.ttf\"\nsize = 600\n": "size must be between 1 and 512",
+	}
+	for content, want := range cases {
+		if _, err := loadConfig(writeConfig(t, content)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%q: expected %q error, got %v", content, want, err)
+		}
+	}
+
+	config, err := loadConfig(writeConfig(t, "[font]\nregular = \"missing.ttf\"\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := (&ConfigService{config: config}).LoadTypesetter(); err == nil || !strings.Contains(err.Error(), "missing.ttf") {
+		t.Fatalf("expected a font read error, got %v", err)
+	}
+}
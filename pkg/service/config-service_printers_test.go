@@ -0,0 +1,170 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfigMultiplePrinters(t *testing.T) {
+	path := writeConfig(t, `
+default_printer = "bar"
+
+[[printers]]
+name = "kitchen"
+port = "tcp://10.0.0.5:9100"
+
+[[printers]]
+name = "bar"
+port = "/dev/ttyUSB1"
+baud_rate = 9600
+test_mode = true
+`)
+
+	config, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cs := &ConfigService{config: config}
+	printers := cs.GetPrinterConfigs()
+	if len(printers) != 2 {
+		t.Fatalf("expected 2 printers, got %d", len(printers))
+	}
+
+	kitchen := printers[0]
+	if kitchen.Name != "kitchen" || kitchen.BaudRate != 19200 || kitchen.DataBits != 8 || kitchen.ReadTimeoutMs != 2000 {
+		t.Fatalf("expected defaults to be applied to kitchen printer, got %+v", kitchen)
+	}
+	if kitchen.TestMode {
+		t.Fatalf("expected kitchen printer not to be in test mode")
+	}
+
+	bar := printers[1]
+	if bar.BaudRate != 9600 || !bar.TestMode {
+		t.Fatalf("expected bar overrides to be kept, got %+v", bar)
+	}
+
+	if cs.GetDefaultPrinterName() != "bar" {
+		t.Fatalf("expected default printer bar, got %s", cs.GetDefaultPrinterName())
+	}
+	if cs.GetPrinterConfig().Port != "/dev/ttyUSB1" {
+		t.Fatalf("expected GetPrinterConfig to return the default printer, got %+v", cs.GetPrinterConfig())
+	}
+}
+
+func TestLoadConfigLegacyPrinter(t *testing.T) {
+	path := writeConfig(t, `
+test_mode = true
+
+[printer]
+port = "/dev/ttyS3"
+`)
+
+	config, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cs := &ConfigService{config: config}
+	printers := cs.GetPrinterConfigs()
+	if len(printers) != 1 {
+		t.Fatalf("expected legacy printer only, got %d", len(printers))
+	}
+	if printers[0].Name != "default" || printers[0].Port != "/dev/ttyS3" || !printers[0].TestMode {
+		t.Fatalf("unexpected legacy printer config: %+v", printers[0])
+	}
+	if cs.GetDefaultPrinterName() != "default" {
+		t.Fatalf("expected default printer name, got %s", cs.GetDefaultPrinterName())
+	}
+}
+
+func TestLoadConfigKeepsExplicitZeroPrinterSettings(t *testing.T) {
+	path := writeConfig(t, `
+[[printers]]
+name = "kitchen"
+port = "tcp://10.0.0.5:9100"
+read_timeout_ms = 0
+connect_timeout_ms = 0
+data_bits = 7
+
+[[printers]]
+name = "bar"
+`)
+
+	config, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	kitchen := config.Printers[0]
+	if kitchen.ReadTimeoutMs != 0 || kitchen.ConnectTimeoutMs != 0 {
+		t.Fatalf("expected the timeouts to stay disabled, got read %d and connect %d", kitchen.ReadTimeoutMs, kitchen.ConnectTimeoutMs)
+	}
+	if kitchen.DataBits != 7 || kitchen.BaudRate != 19200 || kitchen.DrawerOpenSignal != "high" {
+		t.Fatalf("expected the other settings and defaults, got %+v", kitchen)
+	}
+
+	bar := config.Printers[1]
+	if bar.ReadTimeoutMs != 2000 || bar.ConnectTimeoutMs != 5000 || bar.Port != "/dev/ttyUSB0" {
+		t.Fatalf("expected the default timeouts for bar, got %+v", bar)
+	}
+}
+
+func TestLoadConfigRejectsInvalidPrinters(t *testing.T) {
+	cases := map[string]string{
+		"duplicate name": `
+[[printers]]
+name = "bar"
+[[printers]]
+name = "bar"
+`,
+		"missing name": `
+[[printers]]
+port = "/dev/ttyUSB0"
+name = ""
+`,
+		"omitted name": `
+[[printers]]
+port = "/dev/ttyUSB0"
+`,
+		"unsafe name": `
+[[printers]]
+name = "../bar"
+`,
+		"unknown default": `
+default_printer = "front"
+[[printers]]
+name = "bar"
+`,
+		"drawer signal": `
+[printer]
+drawer_open_signal = "up"
+`,
+		"printer drawer signal": `
+[[printers]]
+name = "bar"
+drawer_open_signal = "1"
+`,
+	}
+
+	for name, content := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := loadConfig(writeConfig(t, content))
+			if err == nil || !strings.Contains(err.Error(), "invalid printer configuration") {
+				t.Fatalf("expected printer validation error, got %v", err)
+			}
+		})
+	}
+}
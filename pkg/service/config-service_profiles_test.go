@@ -0,0 +1,95 @@
+package service
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/escpos"
+	"github.com/jonasclaes/go-thermal-printer/pkg/profile"
+)
+
+func TestLoadConfigProfiles(t *testing.T) {
+	path := writeConfig(t, `
+[[profiles]]
+name = "counter"
+base = "tm-t88"
+dots_per_line = 576
+code_pages = [19, 16]
+pdf417 = false
+raster_band_height = 24
+
+[[printers]]
+name = "counter"
+profile = "counter"
+
+[[printers]]
+name = "kitchen"
+profile = "generic-80mm"
+paper_width_dots = 512
+native_2d_codes = true
+code_pages = [16, 17]
+
+[[printers]]
+name = "bar"
+`)
+
+	config, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cs := &ConfigService{config: config}
+	printers := cs.GetPrinterConfigs()
+
+	counter, err := cs.GetPrinterProfile(&printers[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if counter.Name != "counter" || counter.DotsPerLine != 576 || counter.ColumnsFontA != 42 {
+		t.Fatalf("expected overrides on top of tm-t88, got %+v", counter)
+	}
+	if counter.DefaultCodePage() != escpos.CharacterCodePagePC858 || !counter.QRCode || counter.PDF417 {
+		t.Fatalf("expected code pages and symbol support from the profile table, got %+v", counter)
+	}
+	if counter.Cutter != profile.CutterPartial || counter.RasterBandHeight != 24 {
+		t.Fatalf("expected cutter to be inherited and band height overridden, got %+v", counter)
+	}
+
+	kitchen, err := cs.GetPrinterProfile(&printers[1])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if kitchen.DotsPerLine != 512 || kitchen.ColumnsFontA != 48 || !kitchen.QRCode || !kitchen.PDF417 {
+		t.Fatalf("expected printer overrides on top of generic-80mm, got %+v", kitchen)
+	}
+	if !slices.Equal(kitchen.CodePages, []escpos.CharacterCodePage{escpos.CharacterCodePageWPC1252, escpos.CharacterCodePagePC866}) {
+		t.Fatalf("expected the printer's code pages, got %v", kitchen.CodePages)
+	}
+
+	bar, err := cs.GetPrinterProfile(&printers[2])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bar.Name != profile.DefaultName || bar.DotsPerLine != 384 {
+		t.Fatalf("expected the default profile, got %+v", bar)
+	}
+}
+
+func TestLoadConfigRejectsInvalidProfiles(t *testing.T) {
+	cases := map[string]string{
+		"[printer]\nprofile = \"tm-9000\"\n":                       `unknown profile "tm-9000"`,
+		"[[profiles]]\nname = \"tm-t88\"\n":                        "is a built-in profile",
+		"[[profiles]]\nname = \"a\"\nbase = \"b\"\n":               `unknown base profile "b"`,
+		"[[profiles]]\nname = \"a\"\n[[profiles]]\nname = \"a\"\n": `duplicate name "a"`,
+		"[[profiles]]\nname = \"a\"\ncutter = \"laser\"\n":         "cutter must be",
+		"[[profiles]]\nname = \"a\"\nraster_band_height = -1\n":    "raster_band_height must be between 1 and 65535",
+		"[printer]\npaper_width_dots = 4\n":                        "dots_per_line must be between 8 and 2048",
+		"[printer]\ncode_pages = [300]\n":                          "invalid code page 300",
+	}
+	for content, want := range cases {
+		_, err := loadConfig(writeConfig(t, content))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: expected error containing %q, got %v", content, want, err)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/template"
+)
+
+func TestLoadConfigRenderLimits(t *testing.T) {
+	config, err := loadConfig(writeConfig(t, ``))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := template.Limits{Timeout: 5 * time.Second, MaxOutputBytes: 1 << 20, MaxImages: 32, MaxFeedLines: 1000}
+	if limits := (&ConfigService{config: config}).GetRenderLimits(); limits != want {
+		t.Fatalf("expected the default limits %+v, got %+v", want, limits)
+	}
+
+	config, err = loadConfig(writeConfig(t, "[templates]\nrender_timeout_ms = 250\nmax_images = 0\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if limits := (&ConfigService{config: config}).GetRenderLimits(); limits.Timeout != 250*time.Millisecond || limits.MaxImages != 0 {
+		t.Fatalf("expected the configured limits, got %+v", limits)
+	}
+
+	if _, err := loadConfig(writeConfig(t, "[templates]\nmax_feed_lines = -1\n")); err == nil || !strings.Contains(err.Error(), "max_feed_lines must be 0") {
+		t.Fatalf("expected a negative limit to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/model"
+)
+
+func TestLoadConfigWebhooks(t *testing.T) {
+	config, err := loadConfig(writeConfig(t, `
+[[printers]]
+name = "kitchen"
+port = "tcp://10.0.0.5:9100"
+
+[[webhooks]]
+url = "https://example.com/hooks/printer"
+events = ["job.failed", "printer"]
+printers = ["kitchen"]
+secret = "s3cret"
+
+[[webhooks]]
+name = "ops"
+url = "https://hooks.slack.com/services/T0/B0/XXXX"
+format = "slack"
+max_attempts = 2
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	webhooks := (&ConfigService{config: config}).GetWebhookConfigs()
+	if len(webhooks) != 2 {
+		t.Fatalf("expected 2 webhooks, got %+v", webhooks)
+	}
+	first := webhooks[0]
+	if first.Name != "webhook-1" || first.Format != model.WebhookFormatJSON || first.TimeoutMs != 5000 ||
+		first.MaxAttempts != 5 || first.RetryDelayMs != 1000 || first.MaxRetryDelayMs != 60000 {
+		t.Fatalf("expected the defaults to be filled in, got %+v", first)
+	}
+	if second := webhooks[1]; second.Name != "ops" || second.Format != model.WebhookFormatSlack || second.MaxAttempts != 2 {
+		t.Fatalf("expected the configured values, got %+v", second)
+	}
+}
+
+func TestLoadConfigKeepsExplicitZeroWebhookSettings(t *testing.T) {
+	config, err := loadConfig(writeConfig(t, `
+[[webhooks]]
+url = "https://example.com/hooks/printer"
+retry_delay_ms = 0
+timeout_ms = 0
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	webhook := (&ConfigService{config: config}).GetWebhookConfigs()[0]
+	if webhook.RetryDelayMs != 0 || webhook.TimeoutMs != 0 {
+		t.Fatalf("expected no retry delay and no timeout, got %+v", webhook)
+	}
+	if webhook.MaxAttempts != 5 || webhook.MaxRetryDelayMs != 60000 || webhook.Name != "webhook-1" {
+		t.Fatalf("expected the defaults of the other settings, got %+v", webhook)
+	}
+}
+
+func TestLoadConfigRejectsInvalidWebhooks(t *testing.T) {
+	tests := map[string]struct {
+		webhook string
+		want    string
+	}{
+		"relative url":    {`url = "/hooks"`, "absolute http or https URL"},
+		"other scheme":    {`url = "ftp://example.com/hooks"`, "absolute http or https URL"},
+		"unknown event":   {"url = \"https://example.com\"\nevents = [\"job.printed\"]", `unknown event type "job.printed"`},
+		"unknown printer": {"url = \"https://example.com\"\nprinters = [\"bar\"]", `unknown printer "bar"`},
+		"format":          {"url = \"https://example.com\"\nformat = \"xml\"", `invalid format "xml"`},
+		"name":            {"url = \"https://example.com\"\nname = \"my hook\"", `invalid name "my hook"`},
+		"attempts":        {"url = \"https://example.com\"\nmax_attempts = -1", "max_attempts must be 1 or more"},
+		"no attempts":     {"url = \"https://example.com\"\nmax_attempts = 0", "max_attempts must be 1 or more"},
+		"delay":           {"url = \"https://example.com\"\nretry_delay_ms = -5", "retry_delay_ms must be 0 or more"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := loadConfig(writeConfig(t, "[[webhooks]]\n"+tt.webhook+"\n"))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	_, err := loadConfig(writeConfig(t, "[[webhooks]]\nname = \"a\"\nurl = \"https://example.com\"\n\n[[webhooks]]\nname = \"a\"\nurl = \"https://example.org\"\n"))
+	if err == nil || !strings.Contains(err.Error(), `duplicate name "a"`) {
+		t.Fatalf("expected duplicate names to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultEventBuffer = 64
+
+// Event types published on the event bus.
+const (
+	// EventPrinterStatus: the status bytes or the reachability of a printer
+	// changed; Data is a PrinterState
+	EventPrinterStatus = "printer.status"
+	// EventPrinterAlert: an alert condition was raised or cleared; Data is a
+	// StatusAlertEvent
+	EventPrinterAlert = "printer.alert"
+
+	// Job lifecycle events; Data is the Job as it was after the change
+	EventJobQueued    = "job.queued"
+	EventJobStarted   = "job.started"
+	EventJobCompleted = "job.completed"
+	EventJobFailed    = "job.failed"
+	EventJobCancelled = "job.cancelled"
+
+	// EventQueueDepth: jobs were added to or left a printer's queue; Data is
+	// a QueueDepth
+	EventQueueDepth = "queue.depth"
+)
+
+// EventTypes lists every event type published on the bus.
+var EventTypes = []string{
+	EventPrinterStatus,
+	EventPrinterAlert,
+	EventJobQueued,
+	EventJobStarted,
+	EventJobCompleted,
+	EventJobFailed,
+	EventJobCancelled,
+	EventQueueDepth,
+}
+
+// EventTypeMatches reports whether eventType is selected by filter: the
+// type itself or its category, such as "job" for "job.failed".
+func EventTypeMatches(eventType, filter string) bool {
+	return eventType == filter || strings.HasPrefix(eventType, filter+".")
+}
+
+// KnownEventFilter reports whether filter selects any event type.
+func KnownEventFilter(filter string) bool {
+	return slices.ContainsFunc(EventTypes, func(eventType string) bool {
+		return EventTypeMatches(eventType, filter)
+	})
+}
+
+// QueueDepth is the data of an EventQueueDepth event.
+type QueueDepth struct {
+	// Queued is how many jobs wait to be printed, not counting the one that
+	// is printing
+	Queued int
+}
+
+// Event is something that happened to a printer or a job.
+type Event struct {
+	// ID increases by one for every event published on the bus
+	ID      uint64
+	Type    string
+	Printer string
+	Time    time.Time
+	Data    any
+}
+
+// EventBus fans events out to its subscribers. Publishing never blocks: a
+// subscriber that falls behind misses events rather than stalling the
+// printer worker. A nil EventBus discards everything.
+type EventBus struct {
+	mu          sync.Mutex
+	lastID      uint64
+	subscribers map[*Subscription]struct{}
+	now         func() time.Time
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{
+		subscribers: make(map[*Subscription]struct{}),
+		now:         time.Now,
+	}
+}
+
+// Publish stamps the event with its ID and time and delivers it to every
+// subscriber.
+func (b *EventBus) Publish(eventType, printer string, data any) Event {
+	if b == nil {
+		return Event{}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	event := Event{
+		ID:      b.lastID,
+		Type:    eventType,
+		Printer: printer,
+		Time:    b.now(),
+		Data:    data,
+	}
+	for subscription := range b.subscribers {
+		select {
+		case subscription.events <- event:
+		default:
+			subscription.dropped++
+		}
+	}
+	return event
+}
+
+// Subscribe returns a subscription that receives every event published from
+// now on, buffering up to size events (0 selects a default).
+func (b *EventBus) Subscribe(size int) *Subscription {
+	if size <= 0 {
+		size = defaultEventBuffer
+	}
+	subscription := &Subscription{
+		bus:    b,
+		events: make(chan Event, size),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[subscription] = struct{}{}
+	return subscription
+}
+
+// Subscription receives the events of an EventBus until it is closed.
+type Subscription struct {
+	bus     *EventBus
+	events  chan Event
+	dropped int
+}
+
+// Events returns the channel events are delivered on. It is closed by Close.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Dropped returns how many events were missed because the buffer was full.
+func (s *Subscription) Dropped() int {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	return s.dropped
+}
+
+// Close stops the subscription and closes its channel.
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	if _, ok := s.bus.subscribers[s]; !ok {
+		return
+	}
+	delete(s.bus.subscribers, s)
+	close(s.events)
+}
@@ -0,0 +1,42 @@
+package service
+
+import "testing"
+
+func TestEventBusDeliversToSubscribers(t *testing.T) {
+	bus := NewEventBus()
+	first := bus.Subscribe(2)
+	second := bus.Subscribe(1)
+
+	bus.Publish(EventPrinterAlert, "kitchen", StatusAlertEvent{Alert: AlertPaperOut, Active: true})
+	bus.Publish(EventPrinterAlert, "kitchen", StatusAlertEvent{Alert: AlertPaperOut, Active: false})
+
+	for i, want := range []bool{true, false} {
+		event := <-first.Events()
+		if event.ID != uint64(i+1) || event.Type != EventPrinterAlert || event.Printer != "kitchen" || event.Time.IsZero() {
+			t.Fatalf("unexpected event %+v", event)
+		}
+		if data := event.Data.(StatusAlertEvent); data.Active != want {
+			t.Fatalf("expected active %v, got %+v", want, data)
+		}
+	}
+
+	// The full subscriber misses the second event instead of blocking
+	if event := <-second.Events(); event.ID != 1 || second.Dropped() != 1 {
+		t.Fatalf("expected the first event and one dropped, got %+v and %d", event, second.Dropped())
+	}
+
+	second.Close()
+	second.Close()
+	if _, ok := <-second.Events(); ok {
+		t.Fatalf("expected the closed subscription's channel to be closed")
+	}
+	bus.Publish(EventPrinterStatus, "kitchen", nil)
+	if event := <-first.Events(); event.ID != 3 {
+		t.Fatalf("expected the remaining subscriber to get event 3, got %+v", event)
+	}
+
+	var none *EventBus
+	if event := none.Publish(EventPrinterStatus, "kitchen", nil); event.ID != 0 {
+		t.Fatalf("expected a nil bus to discard events, got %+v", event)
+	}
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/common"
+)
+
+const defaultMaxRetainedJobs = 1000
+
+type JobState string
+
+const (
+	JobStateQueued    JobState = "queued"
+	JobStatePrinting  JobState = "printing"
+	JobStateDone      JobState = "done"
+	JobStateFailed    JobState = "failed"
+	JobStateCancelled JobState = "cancelled"
+)
+
+// Job is a snapshot of a print job's lifecycle.
+type Job struct {
+	ID         string
+	Printer    string
+	State      JobState
+	Bytes      int
+	Error      string
+	CreatedAt  time.Time
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+}
+
+// Finished reports whether the job reached a terminal state.
+func (j Job) Finished() bool {
+	return j.State == JobStateDone || j.State == JobStateFailed || j.State == JobStateCancelled
+}
+
+// JobStore tracks print jobs across all printers. Finished jobs are evicted
+// oldest-first once more than maxJobs are retained.
+type JobStore struct {
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	order   []string
+	maxJobs int
+	now     func() time.Time
+}
+
+func NewJobStore(maxJobs int) *JobStore {
+	if maxJobs <= 0 {
+		maxJobs = defaultMaxRetainedJobs
+	}
+	return &JobStore{
+		jobs:    make(map[string]*Job),
+		maxJobs: maxJobs,
+		now:     time.Now,
+	}
+}
+
+// Get returns a snapshot of the job with the given ID.
+func (s *JobStore) Get(id string) (Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return Job{}, &common.JobNotFoundError{ID: id}
+	}
+	return *job, nil
+}
+
+// add registers a new queued job and returns its snapshot.
+func (s *JobStore) add(printer string, size int) Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job := &Job{
+		ID:        newJobID(),
+		Printer:   printer,
+		State:     JobStateQueued,
+		Bytes:     size,
+		CreatedAt: s.now(),
+	}
+	s.jobs[job.ID] = job
+	s.order = append(s.order, job.ID)
+	s.evict()
+
+	return *job
+}
+
+// restore re-registers a queued job replayed from the journal.
+func (s *JobStore) restore(id, printer string, size int, createdAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.jobs[id]; !ok {
+		s.order = append(s.order, id)
+	}
+	s.jobs[id] = &Job{
+		ID:        id,
+		Printer:   printer,
+		State:     JobStateQueued,
+		Bytes:     size,
+		CreatedAt: createdAt,
+	}
+	s.evict()
+}
+
+// start moves a queued job to printing. It returns false when the job was
+// cancelled while waiting in the queue and must not be printed.
+func (s *JobStore) start(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return true
+	}
+	if job.State != JobStateQueued {
+		return false
+	}
+	now := s.now()
+	job.State = JobStatePrinting
+	job.StartedAt = &now
+	return true
+}
+
+// finish records the outcome of a job that was printing.
+func (s *JobStore) finish(id string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return
+	}
+	now := s.now()
+	job.FinishedAt = &now
+	if err != nil {
+		job.State = JobStateFailed
+		job.Error = err.Error()
+		return
+	}
+	job.State = JobStateDone
+}
+
+// cancel marks a still-queued job as cancelled so the worker skips it. It
+// returns false if the job already left the queue.
+func (s *JobStore) cancel(id string, reason error) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok || job.State != JobStateQueued {
+		return false
+	}
+	now := s.now()
+	job.State = JobStateCancelled
+	job.FinishedAt = &now
+	if reason != nil {
+		job.Error = reason.Error()
+	}
+	return true
+}
+
+// queued counts the jobs of printer that wait to be printed.
+func (s *JobStore) queued(printer string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, job := range s.jobs {
+		if job.Printer == printer && job.State == JobStateQueued {
+			count++
+		}
+	}
+	return count
+}
+
+// evict drops the oldest finished jobs while over capacity. Callers must hold s.mu.
+func (s *JobStore) evict() {
+	excess := len(s.order) - s.maxJobs
+	if excess <= 0 {
+		return
+	}
+
+	kept := s.order[:0]
+	for _, id := range s.order {
+		if excess > 0 && s.jobs[id].Finished() {
+			delete(s.jobs, id)
+			excess--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	s.order = kept
+}
+
+func newJobID() string {
+	var b [12]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/common"
+	"github.com/jonasclaes/go-thermal-printer/pkg/escpos"
+)
+
+// gatedWriter blocks every write until release is closed, so tests can hold
+// the worker busy while other jobs wait in the queue.
+type gatedWriter struct {
+	release chan struct{}
+	mu      sync.Mutex
+	written bytes.Buffer
+	// largest is the size of the largest write
+	largest int
+}
+
+func (g *gatedWriter) Write(p []byte) (int, error) {
+	<-g.release
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.largest = max(g.largest, len(p))
+	return g.written.Write(p)
+}
+
+func (g *gatedWriter) Read(p []byte) (int, error) { return 0, io.EOF }
+
+func (g *gatedWriter) String() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.written.String()
+}
+
+func newGatedPrintService(t *testing.T, queueSize int) (*PrintService, *gatedWriter) {
+	t.Helper()
+
+	writer := &gatedWriter{release: make(chan struct{})}
+	ps := &PrintService{
+		name:        "test",
+		port:        writer,
+		printer:     escpos.NewESCPOS(writer),
+		printQueue:  make(chan PrintJob, queueSize),
+		statusQueue: make(chan StatusRequest, 1),
+		quit:        make(chan struct{}),
+		jobs:        NewJobStore(0),
+	}
+	go ps.worker()
+
+	previousWriter := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() {
+		log.SetOutput(previousWriter)
+		_ = ps.Close()
+	})
+
+	return ps, writer
+}
+
+func waitForJobState(t *testing.T, jobs *JobStore, id string, state JobState) Job {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		job, err := jobs.Get(id)
+		if err != nil {
+			t.Fatalf("job lookup failed: %v", err)
+		}
+		if job.State == state {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s stuck in state %s, want %s", id, job.State, state)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestPrintServiceSubmitTracksLifecycle(t *testing.T) {
+	ps, writer := newGatedPrintService(t, 4)
+
+	job, err := ps.Submit([]byte("async"))
+	if err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+	if job.State != JobStateQueued || job.Bytes != 5 || job.Printer != "test" {
+		t.Fatalf("unexpected queued job: %+v", job)
+	}
+
+	printing := waitForJobState(t, ps.jobs, job.ID, JobStatePrinting)
+	if printing.StartedAt == nil {
+		t.Fatalf("expected StartedAt to be set once printing")
+	}
+
+	close(writer.release)
+	done := waitForJobState(t, ps.jobs, job.ID, JobStateDone)
+	if done.FinishedAt == nil || done.Error != "" {
+		t.Fatalf("unexpected finished job: %+v", done)
+	}
+	if writer.String() != "async" {
+		t.Fatalf("expected payload to be printed, got %q", writer.String())
+	}
+}
+
+func TestPrintServicePrintCancelsQueuedJobWhenCallerGivesUp(t *testing.T) {
+	ps, writer := newGatedPrintService(t, 4)
+
+	first, err := ps.Submit([]byte("first"))
+	if err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+	waitForJobState(t, ps.jobs, first.ID, JobStatePrinting)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err = ps.Print(ctx, []byte("second"))
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "cancelled while queued") {
+		t.Fatalf("expected queued job to be cancelled, got %v", err)
+	}
+
+	close(writer.release)
+	waitForJobState(t, ps.jobs, first.ID, JobStateDone)
+
+	// Give the worker a chance to (wrongly) pick up the cancelled job.
+	time.Sleep(20 * time.Millisecond)
+	if writer.String() != "first" {
+		t.Fatalf("cancelled job must not be printed, printer saw %q", writer.String())
+	}
+}
+
+func TestPrintServicePrintReportsJobStillPrinting(t *testing.T) {
+	ps, writer := newGatedPrintService(t, 4)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := ps.Print(ctx, []byte("slow"))
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "still printing") {
+		t.Fatalf("expected still-printing error, got %v", err)
+	}
+
+	close(writer.release)
+	id := strings.Fields(strings.TrimPrefix(err.Error(), "print job "))[0]
+	waitForJobState(t, ps.jobs, id, JobStateDone)
+}
+
+func TestPrintServiceSubmitRejectsWhenQueueFull(t *testing.T) {
+	ps, writer := newGatedPrintService(t, 1)
+	defer close(writer.release)
+
+	first, err := ps.Submit([]byte("a"))
+	if err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+	waitForJobState(t, ps.jobs, first.ID, JobStatePrinting)
+
+	if _, err := ps.Submit([]byte("b")); err != nil {
+		t.Fatalf("expected second job to fit the queue: %v", err)
+	}
+
+	_, err = ps.Submit([]byte("c"))
+	var full *common.PrintQueueFullError
+	if !errors.As(err, &full) {
+		t.Fatalf("expected PrintQueueFullError, got %v", err)
+	}
+}
+
+func TestPrintServicePublishesJobEvents(t *testing.T) {
+	ps, writer := newGatedPrintService(t, 4)
+	bus := NewEventBus()
+	events := bus.Subscribe(0)
+	ps.SetEventBus(bus)
+
+	// expect takes the next event and checks its type and its job ID or
+	// queue depth
+	expect := func(eventType string, want any) {
+		t.Helper()
+		event := nextEvent(t, events)
+		if event.Type != eventType || event.Printer != "test" {
+			t.Fatalf("expected %s, got %+v", eventType, event)
+		}
+		switch want := want.(type) {
+		case string:
+			if job, ok := event.Data.(Job); !ok || job.ID != want {
+				t.Fatalf("expected %s of job %s, got %+v", eventType, want, event.Data)
+			}
+		case int:
+			if depth, ok := event.Data.(QueueDepth); !ok || depth.Queued != want {
+				t.Fatalf("expected queue depth %d, got %+v", want, event.Data)
+			}
+		}
+	}
+
+	first, err := ps.Submit([]byte("first"))
+	if err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+	expect(EventJobQueued, first.ID)
+	expect(EventQueueDepth, 1)
+	expect(EventJobStarted, first.ID)
+	expect(EventQueueDepth, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := ps.Print(ctx, []byte("second")); err == nil {
+		t.Fatal("expected the second job to be cancelled")
+	}
+	queued := nextEvent(t, events)
+	if queued.Type != EventJobQueued {
+		t.Fatalf("expected the second job to be queued, got %+v", queued)
+	}
+	expect(EventQueueDepth, 1)
+	expect(EventJobCancelled, queued.Data.(Job).ID)
+	expect(EventQueueDepth, 0)
+
+	close(writer.release)
+	expect(EventJobCompleted, first.ID)
+}
+
+func TestPrintServicePrintImageStreamsBands(t *testing.T) {
+	ps, writer := newGatedPrintService(t, 4)
+	close(writer.release)
+
+	img := image.NewGray(image.Rect(0, 0, 384, 2000))
+	options := escpos.ImageOptions{MaxWidthDots: 384, BandHeight: 24, Align: escpos.AlignCenter}
+	want, err := escpos.ImageToRasterBytesWithOptions(img, options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	written, err := ps.PrintImage(context.Background(), img, options)
+	if err != nil {
+		t.Fatalf("print failed: %v", err)
+	}
+	if written != len(want) || writer.String() != string(want) {
+		t.Fatalf("expected the %d byte image job, got %d bytes reported and %d written", len(want), written, len(writer.String()))
+	}
+	writer.mu.Lock()
+	largest := writer.largest
+	writer.mu.Unlock()
+	if band := 8 + 48*24; largest > band {
+		t.Fatalf("expected the image to be written a band of at most %d bytes at a time, got a %d byte write", band, largest)
+	}
+
+	job, err := ps.SubmitImage(img, options)
+	if err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+	if job.Bytes != len(want) {
+		t.Fatalf("expected the job size to be %d, got %d", len(want), job.Bytes)
+	}
+	waitForJobState(t, ps.jobs, job.ID, JobStateDone)
+}
+
+func TestJobStoreEvictsOldestFinishedJobs(t *testing.T) {
+	store := NewJobStore(2)
+
+	first := store.add("p", 1)
+	store.start(first.ID)
+	store.finish(first.ID, nil)
+	second := store.add("p", 1)
+	third := store.add("p", 1)
+
+	if _, err := store.Get(first.ID); err == nil {
+		t.Fatalf("expected oldest finished job to be evicted")
+	}
+	for _, id := range []string{second.ID, third.ID} {
+		if _, err := store.Get(id); err != nil {
+			t.Fatalf("expected unfinished job %s to be retained: %v", id, err)
+		}
+	}
+
+	var notFound *common.JobNotFoundError
+	if _, err := store.Get("missing"); !errors.As(err, &notFound) {
+		t.Fatalf("expected JobNotFoundError, got %v", err)
+	}
+}
@@ -1,32 +1,68 @@
 package service
 
 import (
-	"bytes"
 	"context"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"image"
 	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/jonasclaes/go-thermal-printer/pkg/common"
+	"github.com/jonasclaes/go-thermal-printer/pkg/emulator"
 	"github.com/jonasclaes/go-thermal-printer/pkg/escpos"
+	"github.com/jonasclaes/go-thermal-printer/pkg/journal"
+	"github.com/jonasclaes/go-thermal-printer/pkg/model"
+	"github.com/jonasclaes/go-thermal-printer/pkg/profile"
 	"github.com/jonasclaes/go-thermal-printer/pkg/template"
+	"github.com/jonasclaes/go-thermal-printer/pkg/typeset"
 	"go.bug.st/serial"
 )
 
+const defaultPrintQueueSize = 100
+
 var (
 	serialOpenFunc      = serial.Open
 	usbTransportFactory = func(path string) (escpos.Transport, error) {
 		return escpos.NewUSBTransport(path)
 	}
+	tcpTransportFactory = func(addr string, connectTimeout, readTimeout time.Duration) (io.ReadWriteCloser, error) {
+		return escpos.NewTCPTransport(addr, connectTimeout, readTimeout)
+	}
+	testTransportFactory = func(printerConfig *model.PrinterConfig, widthDots int) io.ReadWriter {
+		options := emulator.Options{WidthDots: widthDots}
+		if printerConfig.TestOutputDir != "" {
+			options.OnPage = emulator.PNGWriter(printerConfig.TestOutputDir, printerConfig.Name)
+		} else {
+			// Nobody looks at the receipts, so don't keep them in memory
+			options.OnPage = func(*image.Gray) {}
+		}
+		return emulator.New(options)
+	}
 )
 
 type PrintJob struct {
-	Data     []byte
+	ID   string
+	Data []byte
+	// Image, when set, is printed instead of Data
+	Image    *ImagePrint
 	Response chan error
 }
 
+// ImagePrint is an image printed as a stand-alone job, see
+// escpos.WriteImageJob. Its raster bands are produced while they are written
+// to the printer, so the bitmap is never held in memory as a whole.
+type ImagePrint struct {
+	Image   image.Image
+	Options escpos.ImageOptions
+}
+
 type StatusResponse struct {
 	PrinterStatus         byte
 	OfflineStatus         byte
@@ -35,17 +71,39 @@ type StatusResponse struct {
 	Error                 error
 }
 
+// Decode returns the meaning of the status bytes.
+func (s StatusResponse) Decode() escpos.Status {
+	return escpos.DecodeStatus(s.PrinterStatus, s.OfflineStatus, s.ErrorStatus, s.ContinuousPaperStatus)
+}
+
 type StatusRequest struct {
 	Response chan StatusResponse
 }
 
 type PrintService struct {
+	name            string
 	port            io.ReadWriter
 	printer         *escpos.ESCPOS
 	printQueue      chan PrintJob
 	statusQueue     chan StatusRequest
 	quit            chan struct{}
 	statusSupported bool
+	drawerOpenHigh  bool
+	profile         profile.Profile
+	jobs            *JobStore
+	journal         *journal.Journal
+
+	typesetter   *typeset.Typesetter
+	textSize     int
+	textFallback bool
+	renderLimits template.Limits
+
+	events  *EventBus
+	stateMu sync.Mutex
+	state   *PrinterState
+	// asb splits Automatic Status Back blocks from status replies; nil when
+	// the printer is polled only
+	asb *escpos.StatusDemux
 }
 
 type usbReadWriter struct {
@@ -64,10 +122,21 @@ func (u *usbReadWriter) Close() error {
 	return u.transport.Close()
 }
 
+// NewPrintService opens the default printer from the configuration.
 func NewPrintService(configService *ConfigService) (*PrintService, error) {
 	printerConfig := configService.GetPrinterConfig()
-	appConfig := configService.GetConfig()
+	printerProfile, err := configService.GetPrinterProfile(printerConfig)
+	if err != nil {
+		return nil, err
+	}
+	return NewPrintServiceForPrinter(printerConfig, printerProfile, configService.GetQueueConfig(), NewJobStore(0))
+}
 
+// NewPrintServiceForPrinter opens the printer described by printerConfig and
+// starts its worker goroutine. Templates are rendered for printerProfile.
+// Jobs are tracked in the given store and, when the queue is persistent,
+// replayed from the printer's journal first.
+func NewPrintServiceForPrinter(printerConfig *model.PrinterConfig, printerProfile profile.Profile, queueConfig *model.QueueConfig, jobs *JobStore) (*PrintService, error) {
 	mode := &serial.Mode{
 		BaudRate: printerConfig.BaudRate,
 		DataBits: printerConfig.DataBits,
@@ -104,17 +173,29 @@ func NewPrintService(configService *ConfigService) (*PrintService, error) {
 		statusSupported bool
 	)
 
-	if appConfig.TestMode {
-		var buff bytes.Buffer
-		port = &buff
+	if printerConfig.TestMode {
+		// The emulator renders what would have been printed and answers status requests
+		port = testTransportFactory(printerConfig, printerProfile.DotsPerLine)
+		statusSupported = true
 	} else {
 		path := printerConfig.Port
-		if appConfig.USBMode {
+		if printerConfig.USBMode {
 			transport, err := usbTransportFactory(path)
 			if err != nil {
 				return nil, fmt.Errorf("failed to open usb printer: %w", err)
 			}
 			port = &usbReadWriter{transport: transport}
+		} else if addr, ok := escpos.ParseTCPAddress(path); ok {
+			transport, err := tcpTransportFactory(
+				addr,
+				time.Duration(printerConfig.ConnectTimeoutMs)*time.Millisecond,
+				time.Duration(printerConfig.ReadTimeoutMs)*time.Millisecond,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open network printer: %w", err)
+			}
+			port = transport
+			statusSupported = true
 		} else {
 			if strings.HasPrefix(path, "/dev/usb") || strings.HasPrefix(path, "/dev/lp") {
 				file, err := os.OpenFile(path, os.O_RDWR, 0)
@@ -133,15 +214,54 @@ func NewPrintService(configService *ConfigService) (*PrintService, error) {
 		}
 	}
 
+	var (
+		jobJournal *journal.Journal
+		pending    []journal.Pending
+	)
+	if queueConfig.Persistent {
+		path := filepath.Join(queueConfig.DataDir, printerConfig.Name+".journal")
+		var err error
+		jobJournal, pending, err = journal.Open(path)
+		if err != nil {
+			if c, ok := port.(interface{ Close() error }); ok {
+				_ = c.Close()
+			}
+			return nil, fmt.Errorf("failed to open job journal: %w", err)
+		}
+	}
+
+	var asb *escpos.StatusDemux
 	printer := escpos.NewESCPOS(port)
+	if printerConfig.AutomaticStatusBack {
+		if statusSupported {
+			asb = escpos.NewStatusDemux(port, time.Duration(printerConfig.ReadTimeoutMs)*time.Millisecond)
+			printer = escpos.NewESCPOS(asb)
+		} else {
+			log.Printf("print-service: %s: automatic status back needs a transport that reads status replies; ignoring it", printerConfig.Name)
+		}
+	}
 
 	pm := &PrintService{
+		name:            printerConfig.Name,
 		port:            port,
 		printer:         printer,
-		printQueue:      make(chan PrintJob, 100),
+		printQueue:      make(chan PrintJob, defaultPrintQueueSize+len(pending)),
 		statusQueue:     make(chan StatusRequest, 10),
 		quit:            make(chan struct{}),
 		statusSupported: statusSupported,
+		drawerOpenHigh:  printerConfig.DrawerOpenSignal != model.DrawerSignalLow,
+		profile:         printerProfile,
+		jobs:            jobs,
+		journal:         jobJournal,
+		asb:             asb,
+	}
+
+	pm.restore(pending, queueConfig.Delivery)
+
+	if asb != nil {
+		// Nothing else writes to the printer until the worker starts
+		pm.enableAutomaticStatusBack()
+		go pm.automaticStatusBack()
 	}
 
 	// Start the worker goroutine
@@ -150,16 +270,171 @@ func NewPrintService(configService *ConfigService) (*PrintService, error) {
 	return pm, nil
 }
 
+// restore re-queues jobs left unfinished by a previous run. Jobs that were
+// already being printed are only printed again with at-least-once delivery.
+func (ps *PrintService) restore(pending []journal.Pending, delivery string) {
+	for _, p := range pending {
+		ps.jobs.restore(p.ID, ps.name, len(p.Data), p.CreatedAt)
+
+		if p.Started && delivery == model.DeliveryAtMostOnce {
+			err := fmt.Errorf("interrupted by restart while printing")
+			ps.journalFinished(p.ID, err)
+			ps.jobs.finish(p.ID, err)
+			continue
+		}
+
+		ps.printQueue <- PrintJob{ID: p.ID, Data: p.Data, Response: make(chan error, 1)}
+	}
+
+	if len(pending) > 0 {
+		log.Printf("print-service: %s: restored %d unfinished job(s) from journal", ps.name, len(pending))
+	}
+}
+
+// track registers a new job of size bytes in the job store and, when the
+// queue is persistent, durably records its data before it is handed to the
+// worker.
+func (ps *PrintService) track(size int, data []byte) (Job, error) {
+	job := ps.jobs.add(ps.name, size)
+	if ps.journal != nil {
+		if err := ps.journal.Enqueued(job.ID, data, job.CreatedAt); err != nil {
+			err = fmt.Errorf("failed to persist print job: %w", err)
+			ps.jobs.cancel(job.ID, err)
+			return Job{}, err
+		}
+	}
+	ps.publishJob(EventJobQueued, job.ID)
+	return job, nil
+}
+
+// abandon cancels a job that is still waiting in the queue. It returns false
+// if the worker already picked it up.
+func (ps *PrintService) abandon(id string, reason error) bool {
+	if !ps.jobs.cancel(id, reason) {
+		return false
+	}
+	ps.journalFinished(id, reason)
+	ps.publishJob(EventJobCancelled, id)
+	return true
+}
+
+func (ps *PrintService) journalStarted(id string) {
+	if ps.journal == nil {
+		return
+	}
+	if err := ps.journal.Started(id); err != nil {
+		log.Printf("print-service: %s: journal: %v", ps.name, err)
+	}
+}
+
+func (ps *PrintService) journalFinished(id string, jobErr error) {
+	if ps.journal == nil {
+		return
+	}
+	if err := ps.journal.Finished(id, jobErr); err != nil {
+		log.Printf("print-service: %s: journal: %v", ps.name, err)
+	}
+}
+
+// publishJob publishes a lifecycle event of the job with the given ID and,
+// when the job entered or left the queue, the new queue depth.
+func (ps *PrintService) publishJob(eventType, id string) {
+	events := ps.eventBus()
+	if events == nil {
+		return
+	}
+
+	job, err := ps.jobs.Get(id)
+	if err != nil {
+		return
+	}
+	events.Publish(eventType, ps.name, job)
+
+	switch eventType {
+	case EventJobQueued, EventJobStarted, EventJobCancelled:
+		events.Publish(EventQueueDepth, ps.name, QueueDepth{Queued: ps.jobs.queued(ps.name)})
+	}
+}
+
+// Name returns the configured printer name.
+func (ps *PrintService) Name() string {
+	return ps.name
+}
+
+// Profile returns the capability profile of the printer.
+func (ps *PrintService) Profile() profile.Profile {
+	return ps.profile
+}
+
+// StatusSupported reports whether the transport can read status replies.
+func (ps *PrintService) StatusSupported() bool {
+	return ps.statusSupported
+}
+
+// DrawerOpen interprets the drawer open/close signal of a printer status
+// byte according to the configured drawer_open_signal.
+func (ps *PrintService) DrawerOpen(printerStatus byte) bool {
+	high := printerStatus&escpos.StatusDrawerSignal != 0
+	return high == ps.drawerOpenHigh
+}
+
+// SetTypesetter makes templates print text with the given fonts through the
+// textImage helper and, when fallback is set, whenever a text helper's code
+// page cannot encode its text. Call it before the printer is used.
+func (ps *PrintService) SetTypesetter(typesetter *typeset.Typesetter, size int, fallback bool) {
+	ps.typesetter = typesetter
+	ps.textSize = size
+	ps.textFallback = fallback
+}
+
+// SetRenderLimits bounds the time, output, images and paper of every
+// template this printer renders. Call it before the printer is used.
+func (ps *PrintService) SetRenderLimits(limits template.Limits) {
+	ps.renderLimits = limits
+}
+
+// RenderOptions returns the options templates are rendered with for this printer.
+func (ps *PrintService) RenderOptions() template.RenderOptions {
+	return template.RenderOptions{
+		Profile:      ps.profile,
+		Typesetter:   ps.typesetter,
+		TextSize:     ps.textSize,
+		TextFallback: ps.textFallback,
+		Limits:       ps.renderLimits,
+	}
+}
+
 // worker processes all serial communication sequentially
 func (ps *PrintService) worker() {
 	for {
 		select {
 		case job := <-ps.printQueue:
-			err := ps.print(job.Data)
+			if !ps.jobs.start(job.ID) {
+				// The caller gave up while the job was queued
+				continue
+			}
+			ps.journalStarted(job.ID)
+			ps.publishJob(EventJobStarted, job.ID)
+			var err error
+			if job.Image != nil {
+				err = ps.printImage(job.Image)
+			} else {
+				err = ps.print(job.Data)
+			}
+			// The job most likely reset the printer with ESC @
+			ps.enableAutomaticStatusBack()
+			ps.journalFinished(job.ID, err)
+			ps.jobs.finish(job.ID, err)
+			if err != nil {
+				ps.publishJob(EventJobFailed, job.ID)
+			} else {
+				ps.publishJob(EventJobCompleted, job.ID)
+			}
 			job.Response <- err
 
 		case statusReq := <-ps.statusQueue:
 			status := ps.status()
+			ps.recordStatus(status)
 			statusReq.Response <- status
 
 		case <-ps.quit:
@@ -203,6 +478,20 @@ func (ps *PrintService) print(data []byte) error {
 	return nil
 }
 
+// printImage converts an image while it is sent to the printer
+func (ps *PrintService) printImage(job *ImagePrint) error {
+	bounds := job.Image.Bounds()
+	log.Printf("print-service: writing %dx%d image in bands of %d rows", bounds.Dx(), bounds.Dy(), job.Options.BandHeight)
+
+	if _, err := escpos.WriteImageJob(ps.printer, job.Image, job.Options); err != nil {
+		return fmt.Errorf("failed to write to printer: %w", err)
+	}
+
+	log.Printf("print-service: write complete")
+
+	return nil
+}
+
 // status retrieves the printer status
 func (ps *PrintService) status() StatusResponse {
 	if !ps.statusSupported {
@@ -248,14 +537,23 @@ func (ps *PrintService) status() StatusResponse {
 	}
 }
 
-// Print queues a print job and waits for the response
+// Print queues a print job and waits for the response. If ctx ends while the
+// job is still queued it is cancelled and never printed; if it is already
+// printing, the job keeps running and its outcome is recorded in the job store.
 func (ps *PrintService) Print(ctx context.Context, data []byte) error {
-	response := make(chan error, 1)
-	job := PrintJob{
-		Data:     data,
-		Response: response,
+	tracked, err := ps.track(len(data), data)
+	if err != nil {
+		return err
 	}
 
+	return ps.wait(ctx, PrintJob{ID: tracked.ID, Data: data})
+}
+
+// wait queues a tracked job and waits for it like Print.
+func (ps *PrintService) wait(ctx context.Context, job PrintJob) error {
+	response := make(chan error, 1)
+	job.Response = response
+
 	select {
 	case ps.printQueue <- job:
 		// Job queued successfully, wait for response
@@ -263,13 +561,83 @@ func (ps *PrintService) Print(ctx context.Context, data []byte) error {
 		case err := <-response:
 			return err
 		case <-ctx.Done():
-			return ctx.Err()
+			if ps.abandon(job.ID, ctx.Err()) {
+				return fmt.Errorf("print job %s cancelled while queued: %w", job.ID, ctx.Err())
+			}
+			return fmt.Errorf("print job %s is still printing: %w", job.ID, ctx.Err())
 		}
 	case <-ctx.Done():
+		ps.abandon(job.ID, ctx.Err())
 		return ctx.Err()
 	}
 }
 
+// Submit queues a print job without waiting for it to be printed. The job's
+// progress can be followed through the job store.
+func (ps *PrintService) Submit(data []byte) (Job, error) {
+	job, err := ps.track(len(data), data)
+	if err != nil {
+		return Job{}, err
+	}
+
+	return ps.enqueue(job, PrintJob{ID: job.ID, Data: data})
+}
+
+// enqueue queues a tracked job without waiting like Submit.
+func (ps *PrintService) enqueue(job Job, printJob PrintJob) (Job, error) {
+	printJob.Response = make(chan error, 1)
+	select {
+	case ps.printQueue <- printJob:
+		return job, nil
+	default:
+		err := &common.PrintQueueFullError{Printer: ps.name}
+		ps.abandon(job.ID, err)
+		return Job{}, err
+	}
+}
+
+// PrintImage prints img as a stand-alone job and waits like Print. It
+// returns the size of the job. When the queue is persistent the job is
+// converted up front, as the journal needs its bytes to replay it;
+// otherwise it is converted while it is written, see ImagePrint.
+func (ps *PrintService) PrintImage(ctx context.Context, img image.Image, options escpos.ImageOptions) (int, error) {
+	job, printJob, err := ps.trackImage(img, options)
+	if err != nil {
+		return 0, err
+	}
+	return job.Bytes, ps.wait(ctx, printJob)
+}
+
+// SubmitImage queues img like PrintImage without waiting.
+func (ps *PrintService) SubmitImage(img image.Image, options escpos.ImageOptions) (Job, error) {
+	job, printJob, err := ps.trackImage(img, options)
+	if err != nil {
+		return Job{}, err
+	}
+	return ps.enqueue(job, printJob)
+}
+
+func (ps *PrintService) trackImage(img image.Image, options escpos.ImageOptions) (Job, PrintJob, error) {
+	if img == nil {
+		return Job{}, PrintJob{}, errors.New("nil image")
+	}
+	if ps.journal != nil {
+		data, err := escpos.ImageToRasterBytesWithOptions(img, options)
+		if err != nil {
+			return Job{}, PrintJob{}, err
+		}
+		job, err := ps.track(len(data), data)
+		return job, PrintJob{ID: job.ID, Data: data}, err
+	}
+
+	size, err := escpos.ImageJobSize(img.Bounds(), options)
+	if err != nil {
+		return Job{}, PrintJob{}, err
+	}
+	job, err := ps.track(size, nil)
+	return job, PrintJob{ID: job.ID, Image: &ImagePrint{Image: img, Options: options}}, err
+}
+
 // Status retrieves the printer status and waits for the response
 func (ps *PrintService) Status(ctx context.Context) (StatusResponse, error) {
 	response := make(chan StatusResponse, 1)
@@ -293,7 +661,7 @@ func (ps *PrintService) Status(ctx context.Context) (StatusResponse, error) {
 
 // PrintTemplate renders a template and prints it to the thermal printer
 func (ps *PrintService) PrintTemplate(ctx context.Context, templateContent string, data any) error {
-	renderedData, err := template.RenderToBytes(templateContent, data)
+	renderedData, err := template.RenderToBytesWithOptions(templateContent, data, ps.RenderOptions())
 	if err != nil {
 		return fmt.Errorf("failed to render template: %w", err)
 	}
@@ -301,9 +669,9 @@ func (ps *PrintService) PrintTemplate(ctx context.Context, templateContent strin
 	return ps.Print(ctx, renderedData)
 }
 
-// PrintTemplateWithVariables renders a template file with variables and prints it to the thermal printer
-func (ps *PrintService) PrintTemplateWithVariables(ctx context.Context, templateFile string, variables map[string]any) error {
-	renderedData, err := template.RenderTemplateFileWithVariables(templateFile, variables)
+// PrintTemplateWithVariables renders a parsed template with variables and prints it to the thermal printer
+func (ps *PrintService) PrintTemplateWithVariables(ctx context.Context, tmpl *template.Template, variables map[string]any) error {
+	renderedData, err := template.NewRenderer().RenderContext(ctx, tmpl, variables, ps.RenderOptions())
 	if err != nil {
 		return fmt.Errorf("failed to render template with variables: %w", err)
 	}
@@ -311,8 +679,24 @@ func (ps *PrintService) PrintTemplateWithVariables(ctx context.Context, template
 	return ps.Print(ctx, renderedData)
 }
 
+// SubmitTemplateWithVariables renders a parsed template with variables and queues it without waiting
+func (ps *PrintService) SubmitTemplateWithVariables(tmpl *template.Template, variables map[string]any) (Job, error) {
+	renderedData, err := template.NewRenderer().RenderWithOptions(tmpl, variables, ps.RenderOptions())
+	if err != nil {
+		return Job{}, fmt.Errorf("failed to render template with variables: %w", err)
+	}
+
+	return ps.Submit(renderedData)
+}
+
 func (ps *PrintService) Close() error {
 	close(ps.quit)
+	if ps.asb != nil {
+		_ = ps.asb.Close()
+	}
+	if ps.journal != nil {
+		_ = ps.journal.Close()
+	}
 	if c, ok := ps.port.(interface{ Close() error }); ok {
 		return c.Close()
 	}
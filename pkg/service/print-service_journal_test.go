@@ -0,0 +1,145 @@
+package service
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/escpos"
+	"github.com/jonasclaes/go-thermal-printer/pkg/journal"
+	"github.com/jonasclaes/go-thermal-printer/pkg/model"
+	"github.com/jonasclaes/go-thermal-printer/pkg/profile"
+)
+
+// seedJournal simulates a crash that left one queued job and one job that
+// was being written to the printer.
+func seedJournal(t *testing.T, dir string) {
+	t.Helper()
+
+	j, _, err := journal.Open(filepath.Join(dir, "kitchen.journal"))
+	if err != nil {
+		t.Fatalf("failed to open journal: %v", err)
+	}
+	now := time.Now()
+	if err := j.Enqueued("inflight", []byte("IN"), now); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Started("inflight"); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Enqueued("queued", []byte("QU"), now); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newJournaledPrintService(t *testing.T, dir, delivery string) *PrintService {
+	t.Helper()
+
+	previousWriter := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(previousWriter) })
+
+	previousFactory := testTransportFactory
+	testTransportFactory = func(*model.PrinterConfig, int) io.ReadWriter { return &bytes.Buffer{} }
+	t.Cleanup(func() { testTransportFactory = previousFactory })
+
+	svc, err := NewPrintServiceForPrinter(
+		&model.PrinterConfig{Name: "kitchen", TestMode: true},
+		profile.Default(),
+		&model.QueueConfig{Persistent: true, DataDir: dir, Delivery: delivery},
+		NewJobStore(0),
+	)
+	if err != nil {
+		t.Fatalf("failed to create print service: %v", err)
+	}
+	t.Cleanup(func() { _ = svc.Close() })
+	return svc
+}
+
+func TestPrintServiceReplaysJournalAtLeastOnce(t *testing.T) {
+	dir := t.TempDir()
+	seedJournal(t, dir)
+
+	svc := newJournaledPrintService(t, dir, model.DeliveryAtLeastOnce)
+
+	waitForJobState(t, svc.jobs, "inflight", JobStateDone)
+	waitForJobState(t, svc.jobs, "queued", JobStateDone)
+
+	if got := svc.port.(*bytes.Buffer).String(); got != "INQU" {
+		t.Fatalf("expected both jobs to be printed in order, got %q", got)
+	}
+}
+
+func TestPrintServiceReplaysJournalAtMostOnce(t *testing.T) {
+	dir := t.TempDir()
+	seedJournal(t, dir)
+
+	svc := newJournaledPrintService(t, dir, model.DeliveryAtMostOnce)
+
+	failed := waitForJobState(t, svc.jobs, "inflight", JobStateFailed)
+	if failed.Error == "" {
+		t.Fatalf("expected interrupted job to carry an error")
+	}
+	waitForJobState(t, svc.jobs, "queued", JobStateDone)
+
+	if got := svc.port.(*bytes.Buffer).String(); got != "QU" {
+		t.Fatalf("expected only the queued job to be printed, got %q", got)
+	}
+}
+
+func TestPrintServiceJournalForgetsFinishedJobs(t *testing.T) {
+	dir := t.TempDir()
+
+	svc := newJournaledPrintService(t, dir, model.DeliveryAtLeastOnce)
+	job, err := svc.Submit([]byte("done"))
+	if err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+	waitForJobState(t, svc.jobs, job.ID, JobStateDone)
+	_ = svc.journal.Close()
+
+	j, pending, err := journal.Open(filepath.Join(dir, "kitchen.journal"))
+	if err != nil {
+		t.Fatalf("failed to reopen journal: %v", err)
+	}
+	defer j.Close()
+	if len(pending) != 0 {
+		t.Fatalf("expected finished job not to be replayed, got %+v", pending)
+	}
+}
+
+func TestPrintServiceJournalsImageJobs(t *testing.T) {
+	dir := t.TempDir()
+
+	svc := newJournaledPrintService(t, dir, model.DeliveryAtLeastOnce)
+	img := image.NewGray(image.Rect(0, 0, 64, 300))
+	options := escpos.ImageOptions{MaxWidthDots: 64, BandHeight: 24}
+	job, err := svc.SubmitImage(img, options)
+	if err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+	waitForJobState(t, svc.jobs, job.ID, JobStateDone)
+
+	// The journal holds the converted job, so it could be replayed
+	want, err := escpos.ImageToRasterBytesWithOptions(img, options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "kitchen.journal"))
+	if err != nil {
+		t.Fatalf("read journal: %v", err)
+	}
+	if job.Bytes != len(want) || !strings.Contains(string(content), base64.StdEncoding.EncodeToString(want)) {
+		t.Fatalf("expected the %d byte image job to be journaled, got a %d byte job", len(want), job.Bytes)
+	}
+}
@@ -177,3 +177,75 @@ func TestNewPrintServiceUSBModeInitializes(t *testing.T) {
 		t.Fatalf("expected response error to be populated in USB mode")
 	}
 }
+
+type stubNetworkTransport struct {
+	stubUSBTransport
+}
+
+func (s *stubNetworkTransport) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	p[0] = 0x12
+	return 1, nil
+}
+
+func TestNewPrintServiceTCPModeInitializes(t *testing.T) {
+	originalSerial := serialOpenFunc
+	serialOpenFunc = func(name string, mode *serial.Mode) (serial.Port, error) {
+		t.Fatalf("serialOpenFunc should not be called for tcp printers")
+		return nil, nil
+	}
+	t.Cleanup(func() { serialOpenFunc = originalSerial })
+
+	transport := &stubNetworkTransport{}
+	var (
+		receivedAddr    string
+		receivedConnect time.Duration
+		receivedRead    time.Duration
+	)
+	originalTCPFactory := tcpTransportFactory
+	tcpTransportFactory = func(addr string, connectTimeout, readTimeout time.Duration) (io.ReadWriteCloser, error) {
+		receivedAddr = addr
+		receivedConnect = connectTimeout
+		receivedRead = readTimeout
+		return transport, nil
+	}
+	t.Cleanup(func() { tcpTransportFactory = originalTCPFactory })
+
+	cfg := &ConfigService{config: &model.AppConfig{
+		Printer: model.PrinterConfig{
+			Port:             "tcp://10.0.0.5:9100",
+			ConnectTimeoutMs: 1500,
+			ReadTimeoutMs:    250,
+		},
+	}}
+
+	svc, err := NewPrintService(cfg)
+	if err != nil {
+		t.Fatalf("expected tcp print service to initialize: %v", err)
+	}
+	t.Cleanup(func() { _ = svc.Close() })
+
+	if receivedAddr != "10.0.0.5:9100" {
+		t.Fatalf("tcp transport factory received wrong address: %s", receivedAddr)
+	}
+	if receivedConnect != 1500*time.Millisecond || receivedRead != 250*time.Millisecond {
+		t.Fatalf("unexpected timeouts: connect=%v read=%v", receivedConnect, receivedRead)
+	}
+
+	if !svc.statusSupported {
+		t.Fatalf("expected tcp transport to support status polling")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	status, err := svc.Status(ctx)
+	if err != nil {
+		t.Fatalf("expected status over tcp to succeed: %v", err)
+	}
+	if status.PrinterStatus != 0x12 {
+		t.Fatalf("expected printer status 0x12, got %#x", status.PrinterStatus)
+	}
+}
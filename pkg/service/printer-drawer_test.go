@@ -0,0 +1,142 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/common"
+	"github.com/jonasclaes/go-thermal-printer/pkg/dto"
+	"github.com/jonasclaes/go-thermal-printer/pkg/model"
+	"github.com/jonasclaes/go-thermal-printer/pkg/profile"
+)
+
+// drawerPrinter answers DLE EOT requests like a printer whose drawer reports
+// open for the first openPolls printer status requests.
+type drawerPrinter struct {
+	mu        sync.Mutex
+	written   bytes.Buffer
+	replies   []byte
+	polls     int
+	openPolls int
+}
+
+func (d *drawerPrinter) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(p) == 3 && p[0] == 0x10 && p[1] == 0x04 {
+		reply := byte(0x12)
+		if p[2] == 0x01 {
+			d.polls++
+			if d.polls <= d.openPolls {
+				reply |= 0x04
+			}
+		}
+		d.replies = append(d.replies, reply)
+		return len(p), nil
+	}
+
+	return d.written.Write(p)
+}
+
+func (d *drawerPrinter) Read(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.replies) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, d.replies)
+	d.replies = d.replies[n:]
+	return n, nil
+}
+
+func newDrawerPrinterService(t *testing.T, printer *drawerPrinter, config model.PrinterConfig) *PrinterService {
+	t.Helper()
+
+	previousWriter := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(previousWriter) })
+
+	previousFactory := testTransportFactory
+	testTransportFactory = func(*model.PrinterConfig, int) io.ReadWriter { return printer }
+	t.Cleanup(func() { testTransportFactory = previousFactory })
+
+	previousInterval, previousGrace := drawerPollInterval, drawerOpenGrace
+	drawerPollInterval, drawerOpenGrace = time.Millisecond, 20*time.Millisecond
+	t.Cleanup(func() { drawerPollInterval, drawerOpenGrace = previousInterval, previousGrace })
+
+	config.Name = "till"
+	config.TestMode = true
+	svc, err := NewPrintServiceForPrinter(&config, profile.Default(), &model.QueueConfig{}, NewJobStore(0))
+	if err != nil {
+		t.Fatalf("failed to create print service: %v", err)
+	}
+	t.Cleanup(func() { _ = svc.Close() })
+
+	printerService, _ := NewPrinterService(svc, nil)
+	return printerService
+}
+
+func TestKickDrawerWaitsForClose(t *testing.T) {
+	printer := &drawerPrinter{openPolls: 3}
+	svc := newDrawerPrinterService(t, printer, model.PrinterConfig{DrawerOpenSignal: model.DrawerSignalHigh})
+
+	result, err := svc.KickDrawer(context.Background(), dto.PrinterDrawerDto{Pin: 5, OnMs: 100, WaitForClose: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Opened || !result.Closed {
+		t.Fatalf("expected the drawer to open and close, got %+v", result)
+	}
+	if printer.polls != 4 {
+		t.Fatalf("expected polling to stop once the drawer closed, got %d polls", printer.polls)
+	}
+	if want := []byte{0x1B, 0x70, 0x01, 50, 250}; !bytes.Equal(printer.written.Bytes(), want) {
+		t.Fatalf("expected kick % X, got % X", want, printer.written.Bytes())
+	}
+}
+
+func TestKickDrawerTimesOutWhileOpen(t *testing.T) {
+	printer := &drawerPrinter{openPolls: 1 << 30}
+	svc := newDrawerPrinterService(t, printer, model.PrinterConfig{})
+
+	result, err := svc.KickDrawer(context.Background(), dto.PrinterDrawerDto{WaitForClose: true, TimeoutMs: 30})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Opened || result.Closed || result.Waited < 30*time.Millisecond {
+		t.Fatalf("expected the drawer to stay open until the timeout, got %+v", result)
+	}
+}
+
+func TestKickDrawerReportsDrawerThatNeverOpened(t *testing.T) {
+	// With a low open signal, a high signal on every poll is a drawer that stays shut
+	printer := &drawerPrinter{openPolls: 1 << 30}
+	svc := newDrawerPrinterService(t, printer, model.PrinterConfig{DrawerOpenSignal: model.DrawerSignalLow})
+
+	result, err := svc.KickDrawer(context.Background(), dto.PrinterDrawerDto{WaitForClose: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Opened || !result.Closed {
+		t.Fatalf("expected a closed drawer that never opened, got %+v", result)
+	}
+}
+
+func TestKickDrawerWaitRequiresStatus(t *testing.T) {
+	ps := &PrintService{name: "usb"}
+	svc, _ := NewPrinterService(ps, nil)
+
+	_, err := svc.KickDrawer(context.Background(), dto.PrinterDrawerDto{WaitForClose: true})
+	var notSupported *common.StatusNotSupportedError
+	if !errors.As(err, &notSupported) {
+		t.Fatalf("expected StatusNotSupportedError, got %v", err)
+	}
+}
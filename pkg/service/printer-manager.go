@@ -0,0 +1,122 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/common"
+)
+
+// PrinterManager owns one PrintService (worker + queue) per configured
+// printer and resolves printers by name.
+type PrinterManager struct {
+	printers    map[string]*PrinterService
+	names       []string
+	defaultName string
+	jobs        *JobStore
+	templates   *TemplateStore
+	events      *EventBus
+}
+
+func NewPrinterManager(configService *ConfigService) (*PrinterManager, error) {
+	pm := &PrinterManager{
+		printers:    make(map[string]*PrinterService),
+		defaultName: configService.GetDefaultPrinterName(),
+		jobs:        NewJobStore(0),
+		templates:   NewTemplateStore(configService.GetTemplatesConfig().Dir),
+		events:      NewEventBus(),
+	}
+
+	typesetter, err := configService.LoadTypesetter()
+	if err != nil {
+		return nil, err
+	}
+	fontConfig := configService.GetFontConfig()
+	renderLimits := configService.GetRenderLimits()
+
+	for _, printerConfig := range configService.GetPrinterConfigs() {
+		printerProfile, err := configService.GetPrinterProfile(&printerConfig)
+		if err != nil {
+			_ = pm.Close()
+			return nil, fmt.Errorf("failed to initialize printer %q: %w", printerConfig.Name, err)
+		}
+
+		printService, err := NewPrintServiceForPrinter(&printerConfig, printerProfile, configService.GetQueueConfig(), pm.jobs)
+		if err != nil {
+			_ = pm.Close()
+			return nil, fmt.Errorf("failed to initialize printer %q: %w", printerConfig.Name, err)
+		}
+		printService.SetTypesetter(typesetter, fontConfig.Size, fontConfig.AutoFallback)
+		printService.SetRenderLimits(renderLimits)
+		printService.SetEventBus(pm.events)
+		printService.StartMonitor(time.Duration(printerConfig.StatusPollIntervalMs) * time.Millisecond)
+
+		printerService, err := NewPrinterService(printService, pm.templates)
+		if err != nil {
+			_ = printService.Close()
+			_ = pm.Close()
+			return nil, fmt.Errorf("failed to initialize printer %q: %w", printerConfig.Name, err)
+		}
+
+		pm.printers[printerConfig.Name] = printerService
+		pm.names = append(pm.names, printerConfig.Name)
+	}
+
+	if _, ok := pm.printers[pm.defaultName]; !ok {
+		_ = pm.Close()
+		return nil, fmt.Errorf("default printer %q is not configured", pm.defaultName)
+	}
+
+	return pm, nil
+}
+
+// Get returns the printer with the given name.
+func (pm *PrinterManager) Get(name string) (*PrinterService, error) {
+	printer, ok := pm.printers[name]
+	if !ok {
+		return nil, &common.PrinterNotFoundError{Name: name}
+	}
+	return printer, nil
+}
+
+// Default returns the printer served by the legacy /api/v1/printer/* routes.
+func (pm *PrinterManager) Default() *PrinterService {
+	return pm.printers[pm.defaultName]
+}
+
+// DefaultName returns the name of the default printer.
+func (pm *PrinterManager) DefaultName() string {
+	return pm.defaultName
+}
+
+// Jobs returns the job store shared by all printers.
+func (pm *PrinterManager) Jobs() *JobStore {
+	return pm.jobs
+}
+
+// Templates returns the template store shared by all printers.
+func (pm *PrinterManager) Templates() *TemplateStore {
+	return pm.templates
+}
+
+// Events returns the bus the printers publish their status changes and
+// alerts on.
+func (pm *PrinterManager) Events() *EventBus {
+	return pm.events
+}
+
+// Names returns the configured printer names in configuration order.
+func (pm *PrinterManager) Names() []string {
+	return append([]string(nil), pm.names...)
+}
+
+func (pm *PrinterManager) Close() error {
+	var errs []error
+	for _, name := range pm.names {
+		if err := pm.printers[name].printService.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close printer %q: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/common"
+	"github.com/jonasclaes/go-thermal-printer/pkg/dto"
+	"github.com/jonasclaes/go-thermal-printer/pkg/model"
+)
+
+func TestPrinterManagerResolvesPrinters(t *testing.T) {
+	cfg := &ConfigService{config: &model.AppConfig{
+		TestMode:       true,
+		DefaultPrinter: "bar",
+		Printers: []model.PrinterConfig{
+			{Name: "kitchen"},
+			{Name: "bar"},
+		},
+	}}
+
+	pm, err := NewPrinterManager(cfg)
+	if err != nil {
+		t.Fatalf("failed to create printer manager: %v", err)
+	}
+	t.Cleanup(func() { _ = pm.Close() })
+
+	if names := pm.Names(); len(names) != 2 || names[0] != "kitchen" || names[1] != "bar" {
+		t.Fatalf("unexpected printer names: %v", names)
+	}
+	if pm.Default().Name() != "bar" {
+		t.Fatalf("expected default printer bar, got %s", pm.Default().Name())
+	}
+
+	kitchen, err := pm.Get("kitchen")
+	if err != nil {
+		t.Fatalf("expected kitchen printer: %v", err)
+	}
+	if err := kitchen.Print(context.Background(), dto.PrinterPrintDto{Data: "SGVsbG8K"}); err != nil {
+		t.Fatalf("print to kitchen failed: %v", err)
+	}
+
+	_, err = pm.Get("front")
+	var notFound *common.PrinterNotFoundError
+	if !errors.As(err, &notFound) || notFound.HttpStatusCode() != 404 {
+		t.Fatalf("expected PrinterNotFoundError, got %v", err)
+	}
+}
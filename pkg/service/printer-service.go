@@ -3,23 +3,42 @@ package service
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"image"
 	"strings"
 	"time"
 
+	"github.com/jonasclaes/go-thermal-printer/pkg/common"
 	"github.com/jonasclaes/go-thermal-printer/pkg/dto"
+	"github.com/jonasclaes/go-thermal-printer/pkg/emulator"
+	"github.com/jonasclaes/go-thermal-printer/pkg/escpos"
+	"github.com/jonasclaes/go-thermal-printer/pkg/profile"
+	"github.com/jonasclaes/go-thermal-printer/pkg/template"
 )
 
 type PrinterService struct {
 	printService *PrintService
+	templates    *TemplateStore
 }
 
-func NewPrinterService(printService *PrintService) (*PrinterService, error) {
+func NewPrinterService(printService *PrintService, templates *TemplateStore) (*PrinterService, error) {
 	return &PrinterService{
 		printService: printService,
+		templates:    templates,
 	}, nil
 }
 
+// Name returns the name of the printer this service talks to.
+func (ps *PrinterService) Name() string {
+	return ps.printService.Name()
+}
+
+// Profile returns the capability profile of the printer.
+func (ps *PrinterService) Profile() profile.Profile {
+	return ps.printService.Profile()
+}
+
 func (ps *PrinterService) GetPrinterStatus(c context.Context) (StatusResponse, error) {
 	ctx, cancel := context.WithTimeout(c, 10*time.Second)
 	defer cancel()
@@ -29,6 +48,12 @@ func (ps *PrinterService) GetPrinterStatus(c context.Context) (StatusResponse, e
 	return status, err
 }
 
+// DrawerOpen reports whether a printer status byte says the cash drawer is
+// open, following the printer's drawer_open_signal.
+func (ps *PrinterService) DrawerOpen(printerStatus byte) bool {
+	return ps.printService.DrawerOpen(printerStatus)
+}
+
 func (ps *PrinterService) Print(c context.Context, input dto.PrinterPrintDto) error {
 	data, err := decodePrintPayload(input.Data)
 	if err != nil {
@@ -47,12 +72,186 @@ func (ps *PrinterService) PrintBytes(c context.Context, data []byte) error {
 }
 
 func (ps *PrinterService) PrintTemplate(c context.Context, input dto.PrinterPrintTemplateDto) error {
+	tmpl, variables, err := ps.loadTemplate(input)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(c, 10*time.Second)
+	defer cancel()
+
+	return ps.printService.PrintTemplateWithVariables(ctx, tmpl, variables)
+}
+
+// PrintAsync queues a raw payload and returns immediately with the queued job.
+func (ps *PrinterService) PrintAsync(input dto.PrinterPrintDto) (Job, error) {
+	data, err := decodePrintPayload(input.Data)
+	if err != nil {
+		return Job{}, err
+	}
+
+	return ps.PrintBytesAsync(data)
+}
+
+// PrintBytesAsync queues a raw ESC/POS payload and returns immediately with the queued job.
+func (ps *PrinterService) PrintBytesAsync(data []byte) (Job, error) {
+	return ps.printService.Submit(data)
+}
+
+// PrintImage prints an image as a stand-alone job with the standard timeout
+// and returns the size of the job.
+func (ps *PrinterService) PrintImage(c context.Context, img image.Image, options escpos.ImageOptions) (int, error) {
 	ctx, cancel := context.WithTimeout(c, 10*time.Second)
 	defer cancel()
 
-	err := ps.printService.PrintTemplateWithVariables(ctx, input.TemplateFile, input.Variables)
+	return ps.printService.PrintImage(ctx, img, options)
+}
+
+// PrintImageAsync queues an image and returns immediately with the queued job.
+func (ps *PrinterService) PrintImageAsync(img image.Image, options escpos.ImageOptions) (Job, error) {
+	return ps.printService.SubmitImage(img, options)
+}
+
+// PrintTemplateAsync renders the template and queues it, returning immediately with the queued job.
+func (ps *PrinterService) PrintTemplateAsync(input dto.PrinterPrintTemplateDto) (Job, error) {
+	tmpl, variables, err := ps.loadTemplate(input)
+	if err != nil {
+		return Job{}, err
+	}
+
+	return ps.printService.SubmitTemplateWithVariables(tmpl, variables)
+}
+
+// PreviewTemplate renders the template exactly as PrintTemplate would and
+// returns the receipts the emulator produces for the paper width of this
+// printer's profile, one image per cut. Nothing is sent to the printer.
+func (ps *PrinterService) PreviewTemplate(input dto.PrinterPrintTemplateDto) ([]*image.Gray, error) {
+	tmpl, variables, err := ps.loadTemplate(input)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := template.NewRenderer().RenderWithOptions(tmpl, variables, ps.printService.RenderOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to render template with variables: %w", err)
+	}
+
+	return emulator.Render(data, ps.printService.Profile().DotsPerLine), nil
+}
+
+// loadTemplate returns the requested template and its variables, checked
+// against the template's schema and completed with its defaults.
+func (ps *PrinterService) loadTemplate(input dto.PrinterPrintTemplateDto) (*template.Template, map[string]any, error) {
+	tmpl, err := ps.templates.Template(input.Template)
+	if err != nil {
+		return nil, nil, err
+	}
+	variables, err := ps.templates.Variables(input.Template, input.Variables)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tmpl, variables, nil
+}
+
+var (
+	// drawerPollInterval is how often the drawer status is read while waiting
+	drawerPollInterval = 250 * time.Millisecond
+	// drawerOpenGrace is how long the drawer may take to report open after the kick
+	drawerOpenGrace = 2 * time.Second
+)
+
+const defaultDrawerTimeout = 30 * time.Second
+
+// DrawerResult reports what happened to the drawer after KickDrawer.
+type DrawerResult struct {
+	// Opened: the drawer reported open after the kick
+	Opened bool
+	// Closed: the drawer was closed (again) before the timeout
+	Closed bool
+	Waited time.Duration
+}
+
+// KickDrawer pulses the drawer kick-out connector. With WaitForClose it then
+// polls the drawer status until the drawer has been opened and closed again,
+// or the timeout expires. A drawer that never reports open within a short
+// grace period is reported as closed and not opened.
+func (ps *PrinterService) KickDrawer(c context.Context, input dto.PrinterDrawerDto) (DrawerResult, error) {
+	pin := escpos.DrawerPin2
+	if input.Pin != 0 {
+		var err error
+		if pin, err = escpos.ParseDrawerPin(input.Pin); err != nil {
+			return DrawerResult{}, err
+		}
+	}
+	onMs, offMs := input.OnMs, input.OffMs
+	if onMs == 0 {
+		onMs = escpos.DefaultDrawerOnMs
+	}
+	if offMs == 0 {
+		offMs = escpos.DefaultDrawerOffMs
+	}
+
+	command, err := escpos.DrawerKickCommand(pin, onMs, offMs)
+	if err != nil {
+		return DrawerResult{}, err
+	}
+
+	if input.WaitForClose && !ps.printService.StatusSupported() {
+		return DrawerResult{}, &common.StatusNotSupportedError{Printer: ps.Name()}
+	}
+
+	if err := ps.PrintBytes(c, command); err != nil {
+		return DrawerResult{}, err
+	}
+	if !input.WaitForClose {
+		return DrawerResult{}, nil
+	}
+
+	timeout := defaultDrawerTimeout
+	if input.TimeoutMs > 0 {
+		timeout = time.Duration(input.TimeoutMs) * time.Millisecond
+	}
+	return ps.waitForDrawerClose(c, timeout)
+}
+
+func (ps *PrinterService) waitForDrawerClose(c context.Context, timeout time.Duration) (DrawerResult, error) {
+	ctx, cancel := context.WithTimeout(c, timeout)
+	defer cancel()
+
+	var result DrawerResult
+	start := time.Now()
+	ticker := time.NewTicker(drawerPollInterval)
+	defer ticker.Stop()
+
+	for {
+		status, err := ps.printService.Status(ctx)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) && c.Err() == nil {
+				break
+			}
+			return DrawerResult{}, fmt.Errorf("failed to read drawer status: %w", err)
+		}
+
+		if ps.printService.DrawerOpen(status.PrinterStatus) {
+			result.Opened = true
+		} else if result.Opened || time.Since(start) >= drawerOpenGrace {
+			result.Closed = true
+			break
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			if c.Err() != nil {
+				return DrawerResult{}, c.Err()
+			}
+			result.Waited = time.Since(start)
+			return result, nil
+		}
+	}
 
-	return err
+	result.Waited = time.Since(start)
+	return result, nil
 }
 
 func decodePrintPayload(encoded string) ([]byte, error) {
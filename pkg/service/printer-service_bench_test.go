@@ -22,11 +22,12 @@ func newBenchmarkPrinterService(b testing.TB) *PrinterService {
 		printQueue:  make(chan PrintJob, 16),
 		statusQueue: make(chan StatusRequest, 1),
 		quit:        make(chan struct{}),
+		jobs:        NewJobStore(0),
 	}
 
 	go ps.worker()
 
-	printerService, err := NewPrinterService(ps)
+	printerService, err := NewPrinterService(ps, nil)
 	if err != nil {
 		b.Fatalf("failed to create printer service: %v", err)
 	}
@@ -3,7 +3,12 @@ package service
 import (
 	"bytes"
 	"encoding/base64"
+	"errors"
 	"testing"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/common"
+	"github.com/jonasclaes/go-thermal-printer/pkg/dto"
+	"github.com/jonasclaes/go-thermal-printer/pkg/profile"
 )
 
 func TestDecodePrintPayloadPreservesDecodedBytes(t *testing.T) {
@@ -48,3 +53,38 @@ func TestDecodePrintPayloadInvalidBase64(t *testing.T) {
 	}
 }
 
+func TestPreviewTemplateHonoursPaperWidth(t *testing.T) {
+	templates := NewTemplateStore(t.TempDir())
+	content := "{{ bold .title }}\n{{ cut }}{{ fontb \"second\" }}\n"
+	if _, _, err := templates.Put("preview", content); err != nil {
+		t.Fatalf("failed to store template: %v", err)
+	}
+
+	printerProfile, _ := profile.Builtin("generic-80mm")
+	printer := &PrinterService{printService: &PrintService{profile: printerProfile}, templates: templates}
+	pages, err := printer.PreviewTemplate(dto.PrinterPrintTemplateDto{
+		Template:  "preview",
+		Variables: map[string]any{"title": "Preview"},
+	})
+	if err != nil {
+		t.Fatalf("preview failed: %v", err)
+	}
+
+	if len(pages) != 2 {
+		t.Fatalf("expected one page per cut, got %d", len(pages))
+	}
+	for _, page := range pages {
+		if page.Rect.Dx() != 576 {
+			t.Fatalf("expected 576 dot wide page, got %d", page.Rect.Dx())
+		}
+	}
+}
+
+func TestPreviewTemplateMissingTemplate(t *testing.T) {
+	printer := &PrinterService{printService: &PrintService{}, templates: NewTemplateStore(t.TempDir())}
+	_, err := printer.PreviewTemplate(dto.PrinterPrintTemplateDto{Template: "does-not-exist"})
+	var notFound *common.TemplateNotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("expected TemplateNotFoundError, got %v", err)
+	}
+}
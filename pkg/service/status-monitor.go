@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/escpos"
+)
+
+// StatusAlert names a printer condition that needs attention.
+type StatusAlert string
+
+const (
+	// AlertUnreachable: the printer did not answer the last status request
+	AlertUnreachable        StatusAlert = "unreachable"
+	AlertOffline            StatusAlert = "offline"
+	AlertCoverOpen          StatusAlert = "cover_open"
+	AlertPaperNearEnd       StatusAlert = "paper_near_end"
+	AlertPaperOut           StatusAlert = "paper_out"
+	AlertAutocutterError    StatusAlert = "autocutter_error"
+	AlertUnrecoverableError StatusAlert = "unrecoverable_error"
+)
+
+// PrinterState is the last known status of a printer.
+type PrinterState struct {
+	PrinterStatus         byte
+	OfflineStatus         byte
+	ErrorStatus           byte
+	ContinuousPaperStatus byte
+	// Alerts are the active alert conditions
+	Alerts []StatusAlert
+	// Error is why the last status request failed, if it did; the status
+	// bytes are then those of the last successful request
+	Error     string
+	UpdatedAt time.Time
+}
+
+// StatusAlertEvent is the data of an EventPrinterAlert event.
+type StatusAlertEvent struct {
+	Alert StatusAlert
+	// Active is true when the condition was raised and false when it cleared
+	Active bool
+}
+
+// statusAlerts returns the alert conditions of the status bytes of state.
+func statusAlerts(state PrinterState) []StatusAlert {
+	status := escpos.DecodeStatus(state.PrinterStatus, state.OfflineStatus, state.ErrorStatus, state.ContinuousPaperStatus)
+
+	var alerts []StatusAlert
+	add := func(set bool, alert StatusAlert) {
+		if set {
+			alerts = append(alerts, alert)
+		}
+	}
+	add(status.Offline, AlertOffline)
+	add(status.CoverOpen, AlertCoverOpen)
+	add(status.PaperNearEnd, AlertPaperNearEnd)
+	add(status.PaperEnd, AlertPaperOut)
+	add(status.AutocutterError, AlertAutocutterError)
+	add(status.UnrecoverableError, AlertUnrecoverableError)
+	return alerts
+}
+
+// SetEventBus makes the printer publish its status changes, alerts and job
+// events on events. Call it before the printer is used. A printer with
+// Automatic Status Back may already have pushed its status; that status and
+// its alerts are published on events now, so a printer that starts with the
+// paper out still raises the alert.
+func (ps *PrintService) SetEventBus(events *EventBus) {
+	ps.stateMu.Lock()
+	defer ps.stateMu.Unlock()
+	ps.events = events
+	if ps.state != nil {
+		ps.publishStatus(*ps.state, nil)
+	}
+}
+
+// eventBus returns the bus set by SetEventBus, if any.
+func (ps *PrintService) eventBus() *EventBus {
+	ps.stateMu.Lock()
+	defer ps.stateMu.Unlock()
+	return ps.events
+}
+
+// StartMonitor polls the printer status every interval so status changes
+// and alerts are published even when nobody asks for the status. The polls
+// are queued like status requests, so the worker runs them between jobs. It
+// does nothing when interval is not positive or the transport cannot read
+// status replies.
+func (ps *PrintService) StartMonitor(interval time.Duration) {
+	if interval <= 0 || !ps.statusSupported {
+		return
+	}
+	go ps.monitor(interval)
+}
+
+func (ps *PrintService) monitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// A poll that waits for a long job is dropped; the worker still
+			// records the status once it gets to it
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			_, _ = ps.Status(ctx)
+			cancel()
+		case <-ps.quit:
+			return
+		}
+	}
+}
+
+// enableAutomaticStatusBack asks the printer to send its status whenever it
+// changes. Only the worker may call it once it runs.
+func (ps *PrintService) enableAutomaticStatusBack() {
+	if ps.asb == nil {
+		return
+	}
+	if _, err := ps.printer.AutomaticStatusBack(escpos.ASBAll); err != nil {
+		log.Printf("print-service: %s: failed to enable automatic status back: %v", ps.name, err)
+	}
+}
+
+// automaticStatusBack records every status block the printer pushes, until
+// the printer is closed.
+func (ps *PrintService) automaticStatusBack() {
+	for block := range ps.asb.Blocks() {
+		printerStatus, offlineStatus, errorStatus, paperStatus := block.StatusBytes()
+		ps.recordStatus(StatusResponse{
+			PrinterStatus:         printerStatus,
+			OfflineStatus:         offlineStatus,
+			ErrorStatus:           errorStatus,
+			ContinuousPaperStatus: paperStatus,
+		})
+	}
+}
+
+// LastStatus returns the last status read from or pushed by the printer. ok
+// is false until the status is known.
+func (ps *PrintService) LastStatus() (state PrinterState, ok bool) {
+	ps.stateMu.Lock()
+	defer ps.stateMu.Unlock()
+
+	if ps.state == nil {
+		return PrinterState{}, false
+	}
+	state = *ps.state
+	state.Alerts = slices.Clone(state.Alerts)
+	return state, true
+}
+
+// recordStatus keeps status as the last known state and publishes what
+// changed since the previous one. A failed request keeps the previous status
+// bytes, as nothing is known about them, and raises AlertUnreachable. The
+// lock is held while publishing, so the events of a status polled by the
+// worker and one pushed by the printer do not interleave.
+func (ps *PrintService) recordStatus(status StatusResponse) {
+	if !ps.statusSupported {
+		return
+	}
+
+	ps.stateMu.Lock()
+	defer ps.stateMu.Unlock()
+
+	previous := ps.state
+	state := PrinterState{
+		PrinterStatus:         status.PrinterStatus,
+		OfflineStatus:         status.OfflineStatus,
+		ErrorStatus:           status.ErrorStatus,
+		ContinuousPaperStatus: status.ContinuousPaperStatus,
+		UpdatedAt:             time.Now(),
+	}
+	if status.Error != nil {
+		if previous != nil {
+			state.PrinterStatus = previous.PrinterStatus
+			state.OfflineStatus = previous.OfflineStatus
+			state.ErrorStatus = previous.ErrorStatus
+			state.ContinuousPaperStatus = previous.ContinuousPaperStatus
+		}
+		state.Error = status.Error.Error()
+	}
+	state.Alerts = statusAlerts(state)
+	if status.Error != nil {
+		state.Alerts = append(state.Alerts, AlertUnreachable)
+	}
+	ps.state = &state
+
+	var before []StatusAlert
+	if previous != nil {
+		before = previous.Alerts
+		if previous.PrinterStatus == state.PrinterStatus &&
+			previous.OfflineStatus == state.OfflineStatus &&
+			previous.ErrorStatus == state.ErrorStatus &&
+			previous.ContinuousPaperStatus == state.ContinuousPaperStatus &&
+			(previous.Error == "") == (state.Error == "") {
+			return
+		}
+	}
+
+	ps.publishStatus(state, before)
+}
+
+// publishStatus publishes state and the alerts raised and cleared since the
+// alerts before. The caller holds stateMu.
+func (ps *PrintService) publishStatus(state PrinterState, before []StatusAlert) {
+	published := state
+	published.Alerts = slices.Clone(state.Alerts)
+	ps.events.Publish(EventPrinterStatus, ps.name, published)
+
+	for _, alert := range state.Alerts {
+		if !slices.Contains(before, alert) {
+			log.Printf("print-service: %s: alert raised: %s", ps.name, alert)
+			ps.events.Publish(EventPrinterAlert, ps.name, StatusAlertEvent{Alert: alert, Active: true})
+		}
+	}
+	for _, alert := range before {
+		if !slices.Contains(state.Alerts, alert) {
+			log.Printf("print-service: %s: alert cleared: %s", ps.name, alert)
+			ps.events.Publish(EventPrinterAlert, ps.name, StatusAlertEvent{Alert: alert, Active: false})
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/model"
+	"github.com/jonasclaes/go-thermal-printer/pkg/profile"
+)
+
+// monitoredPrinter answers DLE EOT n with status[n-1] and GS a with the
+// block asb, and records whether each write was a status request, an ASB
+// request or print data. While block is set, print data waits for it to be
+// closed.
+type monitoredPrinter struct {
+	mu      sync.Mutex
+	status  [4]byte
+	asb     [4]byte
+	fail    bool
+	replies []byte
+	writes  []string
+	printed bytes.Buffer
+
+	block   chan struct{}
+	started chan struct{}
+}
+
+func newMonitoredPrinter() *monitoredPrinter {
+	return &monitoredPrinter{
+		status: [4]byte{0x12, 0x12, 0x12, 0x12},
+		asb:    [4]byte{0x10, 0x00, 0x00, 0x00},
+	}
+}
+
+func (m *monitoredPrinter) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	if len(p) == 3 && p[0] == 0x10 && p[1] == 0x04 && p[2] >= 1 && p[2] <= 4 {
+		defer m.mu.Unlock()
+		m.writes = append(m.writes, "status")
+		m.replies = append(m.replies, m.status[p[2]-1])
+		return len(p), nil
+	}
+	if len(p) == 3 && p[0] == 0x1D && p[1] == 0x61 {
+		defer m.mu.Unlock()
+		m.writes = append(m.writes, "asb")
+		m.replies = append(m.replies, m.asb[:]...)
+		return len(p), nil
+	}
+	m.writes = append(m.writes, "print")
+	block, started := m.block, m.started
+	m.mu.Unlock()
+
+	if block != nil {
+		close(started)
+		<-block
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.printed.Write(p)
+}
+
+func (m *monitoredPrinter) Read(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.fail {
+		m.replies = nil
+		return 0, errors.New("no reply")
+	}
+	if len(m.replies) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, m.replies)
+	m.replies = m.replies[n:]
+	return n, nil
+}
+
+func (m *monitoredPrinter) set(update func(m *monitoredPrinter)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	update(m)
+}
+
+func newMonitoredPrintService(t *testing.T, printer *monitoredPrinter) (*PrintService, *Subscription) {
+	t.Helper()
+
+	previousWriter := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(previousWriter) })
+
+	previousFactory := testTransportFactory
+	testTransportFactory = func(*model.PrinterConfig, int) io.ReadWriter { return printer }
+	t.Cleanup(func() { testTransportFactory = previousFactory })
+
+	svc, err := NewPrintServiceForPrinter(&model.PrinterConfig{Name: "kitchen", TestMode: true}, profile.Default(), &model.QueueConfig{}, NewJobStore(0))
+	if err != nil {
+		t.Fatalf("failed to create print service: %v", err)
+	}
+	t.Cleanup(func() { _ = svc.Close() })
+
+	bus := NewEventBus()
+	events := bus.Subscribe(100)
+	svc.SetEventBus(bus)
+	svc.StartMonitor(2 * time.Millisecond)
+	return svc, events
+}
+
+func nextEvent(t *testing.T, events *Subscription) Event {
+	t.Helper()
+	select {
+	case event := <-events.Events():
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for an event")
+		return Event{}
+	}
+}
+
+func expectAlert(t *testing.T, events *Subscription, alert StatusAlert, active bool) {
+	t.Helper()
+	event := nextEvent(t, events)
+	data, ok := event.Data.(StatusAlertEvent)
+	if event.Type != EventPrinterAlert || !ok || data.Alert != alert || data.Active != active {
+		t.Fatalf("expected alert %s active=%v, got %+v", alert, active, event)
+	}
+}
+
+func expectStatus(t *testing.T, events *Subscription, alerts ...StatusAlert) PrinterState {
+	t.Helper()
+	event := nextEvent(t, events)
+	state, ok := event.Data.(PrinterState)
+	if event.Type != EventPrinterStatus || !ok || !slices.Equal(state.Alerts, alerts) {
+		t.Fatalf("expected a status event with alerts %v, got %+v", alerts, event)
+	}
+	return state
+}
+
+func TestStatusMonitorPublishesAlertTransitions(t *testing.T) {
+	printer := newMonitoredPrinter()
+	svc, events := newMonitoredPrintService(t, printer)
+
+	expectStatus(t, events)
+	if state, ok := svc.LastStatus(); !ok || len(state.Alerts) != 0 || state.PrinterStatus != 0x12 {
+		t.Fatalf("expected a ready printer, got %+v, %v", state, ok)
+	}
+
+	printer.set(func(m *monitoredPrinter) { m.status[3] |= 0x0C })
+	expectStatus(t, events, AlertPaperNearEnd)
+	expectAlert(t, events, AlertPaperNearEnd, true)
+
+	printer.set(func(m *monitoredPrinter) {
+		m.status[0] |= 0x08
+		m.status[1] |= 0x04
+	})
+	expectStatus(t, events, AlertOffline, AlertCoverOpen, AlertPaperNearEnd)
+	expectAlert(t, events, AlertOffline, true)
+	expectAlert(t, events, AlertCoverOpen, true)
+
+	// A printer that stops answering keeps its last known conditions
+	printer.set(func(m *monitoredPrinter) { m.fail = true })
+	state := expectStatus(t, events, AlertOffline, AlertCoverOpen, AlertPaperNearEnd, AlertUnreachable)
+	if state.Error == "" || state.OfflineStatus != 0x16 {
+		t.Fatalf("expected the error and the previous status bytes, got %+v", state)
+	}
+	expectAlert(t, events, AlertUnreachable, true)
+
+	printer.set(func(m *monitoredPrinter) {
+		m.fail = false
+		m.status = [4]byte{0x12, 0x12, 0x12, 0x12 | 0x60}
+	})
+	expectStatus(t, events, AlertPaperOut)
+	expectAlert(t, events, AlertPaperOut, true)
+	for _, alert := range []StatusAlert{AlertOffline, AlertCoverOpen, AlertPaperNearEnd, AlertUnreachable} {
+		expectAlert(t, events, alert, false)
+	}
+}
+
+func TestStatusMonitorWaitsForPrintJobs(t *testing.T) {
+	printer := newMonitoredPrinter()
+	svc, events := newMonitoredPrintService(t, printer)
+	expectStatus(t, events)
+
+	printer.set(func(m *monitoredPrinter) {
+		m.block = make(chan struct{})
+		m.started = make(chan struct{})
+	})
+	job, err := svc.Submit([]byte("receipt"))
+	if err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+	<-printer.started
+
+	// Several polls are due while the job is printing
+	time.Sleep(20 * time.Millisecond)
+	printer.set(func(m *monitoredPrinter) {
+		if last := m.writes[len(m.writes)-1]; last != "print" {
+			t.Errorf("expected no status request while printing, got %v", m.writes)
+		}
+		close(m.block)
+		m.block = nil
+	})
+
+	waitForJobState(t, svc.jobs, job.ID, JobStateDone)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var polled bool
+		printer.set(func(m *monitoredPrinter) { polled = m.writes[len(m.writes)-1] == "status" })
+		if polled {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected polling to resume after the job")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAutomaticStatusBackKeepsStatusCurrent(t *testing.T) {
+	previousWriter := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(previousWriter) })
+
+	printer := newMonitoredPrinter()
+	previousFactory := testTransportFactory
+	testTransportFactory = func(*model.PrinterConfig, int) io.ReadWriter { return printer }
+	t.Cleanup(func() { testTransportFactory = previousFactory })
+
+	bus := NewEventBus()
+	events := bus.Subscribe(100)
+	config := &model.PrinterConfig{Name: "kitchen", TestMode: true, AutomaticStatusBack: true, ReadTimeoutMs: 1000}
+	svc, err := NewPrintServiceForPrinter(config, profile.Default(), &model.QueueConfig{}, NewJobStore(0))
+	if err != nil {
+		t.Fatalf("failed to create print service: %v", err)
+	}
+	t.Cleanup(func() { _ = svc.Close() })
+	svc.SetEventBus(bus)
+
+	// The block sent when ASB is enabled is published once, whether it
+	// arrives before or after the bus is set
+	expectStatus(t, events)
+
+	// The printer pushes a change without being asked
+	printer.set(func(m *monitoredPrinter) { m.replies = append(m.replies, 0x10, 0x00, 0x0C, 0x00) })
+	state := expectStatus(t, events, AlertPaperOut)
+	if state.ContinuousPaperStatus != 0x72 || state.OfflineStatus != 0x32 {
+		t.Fatalf("expected the paper end status bytes, got %+v", state)
+	}
+	expectAlert(t, events, AlertPaperOut, true)
+
+	// Status requests still get their replies from the same stream
+	status, err := svc.Status(context.Background())
+	if err != nil || status.ContinuousPaperStatus != 0x12 {
+		t.Fatalf("expected the polled status, got %+v, %v", status, err)
+	}
+	expectStatus(t, events)
+	expectAlert(t, events, AlertPaperOut, false)
+
+	// ASB is enabled again after every job
+	if err := svc.Print(context.Background(), []byte("receipt")); err != nil {
+		t.Fatalf("print failed: %v", err)
+	}
+	printer.set(func(m *monitoredPrinter) {
+		if want := []string{"asb", "status", "status", "status", "status", "print", "asb"}; !slices.Equal(m.writes, want) {
+			t.Errorf("expected writes %v, got %v", want, m.writes)
+		}
+	})
+}
+
+func TestAutomaticStatusBackPublishesAlertsPresentAtStartup(t *testing.T) {
+	previousWriter := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(previousWriter) })
+
+	printer := newMonitoredPrinter()
+	printer.asb = [4]byte{0x10, 0x00, 0x0C, 0x00}
+	previousFactory := testTransportFactory
+	testTransportFactory = func(*model.PrinterConfig, int) io.ReadWriter { return printer }
+	t.Cleanup(func() { testTransportFactory = previousFactory })
+
+	config := &model.PrinterConfig{Name: "kitchen", TestMode: true, AutomaticStatusBack: true, ReadTimeoutMs: 1000}
+	svc, err := NewPrintServiceForPrinter(config, profile.Default(), &model.QueueConfig{}, NewJobStore(0))
+	if err != nil {
+		t.Fatalf("failed to create print service: %v", err)
+	}
+	t.Cleanup(func() { _ = svc.Close() })
+
+	// The printer reports the paper out before there is a bus to publish on
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, ok := svc.LastStatus(); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the printer to push its status")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	bus := NewEventBus()
+	events := bus.Subscribe(100)
+	svc.SetEventBus(bus)
+
+	expectStatus(t, events, AlertPaperOut)
+	expectAlert(t, events, AlertPaperOut, true)
+	select {
+	case event := <-events.Events():
+		t.Fatalf("expected no more events, got %+v", event)
+	default:
+	}
+}
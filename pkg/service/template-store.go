@@ -0,0 +1,417 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/common"
+	"github.com/jonasclaes/go-thermal-printer/pkg/schema"
+	"github.com/jonasclaes/go-thermal-printer/pkg/template"
+)
+
+const (
+	// templateExt is the extension of template files in the store directory.
+	templateExt = ".tmpl"
+	// schemaExt is the extension of the JSON Schema next to a template that
+	// describes its variables.
+	schemaExt = ".schema.json"
+)
+
+var templateNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+
+// TemplateInfo describes a stored template.
+type TemplateInfo struct {
+	Name       string
+	Size       int64
+	ModifiedAt time.Time
+	HasSchema  bool
+}
+
+// cachedTemplate is a parsed template with the versions of the files it was
+// parsed from: its own and those of the partials it uses.
+type cachedTemplate struct {
+	template *template.Template
+	files    map[string]fileVersion
+}
+
+type cachedSchema struct {
+	schema  *schema.Schema
+	version fileVersion
+}
+
+type fileVersion struct {
+	size    int64
+	modTime time.Time
+}
+
+// TemplateStore keeps the templates clients print by name as <name>.tmpl
+// files in one directory. Templates may invoke each other with {{template}},
+// see template.NewTemplateWithPartials. A <name>.schema.json file next to a
+// template declares its variables. Parsed templates and schemas are cached
+// until one of their files changes, whether through the store or on disk.
+type TemplateStore struct {
+	dir string
+
+	mu      sync.Mutex
+	cache   map[string]cachedTemplate
+	schemas map[string]cachedSchema
+}
+
+func NewTemplateStore(dir string) *TemplateStore {
+	return &TemplateStore{
+		dir:     dir,
+		cache:   make(map[string]cachedTemplate),
+		schemas: make(map[string]cachedSchema),
+	}
+}
+
+// path returns the file of the named template. Names are restricted so the
+// path cannot leave the store directory.
+func (ts *TemplateStore) path(name string) (string, error) {
+	if !templateNamePattern.MatchString(name) {
+		return "", &common.InvalidTemplateNameError{Name: name}
+	}
+	return filepath.Join(ts.dir, name+templateExt), nil
+}
+
+// stat returns the file info of the named template.
+func (ts *TemplateStore) stat(name string) (string, os.FileInfo, error) {
+	path, err := ts.path(name)
+	if err != nil {
+		return "", nil, err
+	}
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) || err == nil && !info.Mode().IsRegular() {
+		return "", nil, &common.TemplateNotFoundError{Name: name}
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read template %q: %w", name, err)
+	}
+	return path, info, nil
+}
+
+// List returns the stored templates sorted by name.
+func (ts *TemplateStore) List() ([]TemplateInfo, error) {
+	entries, err := os.ReadDir(ts.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []TemplateInfo{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+
+	schemas := make(map[string]bool)
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), schemaExt); ok && entry.Type().IsRegular() {
+			schemas[name] = true
+		}
+	}
+
+	templates := make([]TemplateInfo, 0, len(entries))
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), templateExt)
+		if !ok || !entry.Type().IsRegular() || !templateNamePattern.MatchString(name) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// Removed since the directory was read
+			continue
+		}
+		templateInfo := templateInfo(name, info)
+		templateInfo.HasSchema = schemas[name]
+		templates = append(templates, templateInfo)
+	}
+	return templates, nil
+}
+
+// Get returns the source of the named template.
+func (ts *TemplateStore) Get(name string) (TemplateInfo, string, error) {
+	path, info, err := ts.stat(name)
+	if err != nil {
+		return TemplateInfo{}, "", err
+	}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return TemplateInfo{}, "", &common.TemplateNotFoundError{Name: name}
+	}
+	if err != nil {
+		return TemplateInfo{}, "", fmt.Errorf("failed to read template %q: %w", name, err)
+	}
+	templateInfo := templateInfo(name, info)
+	_, err = os.Stat(filepath.Join(ts.dir, name+schemaExt))
+	templateInfo.HasSchema = err == nil
+	return templateInfo, string(content), nil
+}
+
+// Template returns the parsed template with the partials it invokes, from the
+// cache when none of their files changed since they were last parsed.
+func (ts *TemplateStore) Template(name string) (*template.Template, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	path, info, err := ts.stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if cached, ok := ts.cache[name]; ok && ts.unchanged(cached) {
+		return cached.template, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template %q: %w", name, err)
+	}
+	files := map[string]fileVersion{name: versionOf(info)}
+	tmpl, err := template.NewTemplateWithPartials(name, string(content), func(partial string) (string, bool, error) {
+		path, info, err := ts.stat(partial)
+		var notFound *common.TemplateNotFoundError
+		var invalidName *common.InvalidTemplateNameError
+		if errors.As(err, &notFound) || errors.As(err, &invalidName) {
+			return "", false, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to read template %q: %w", partial, err)
+		}
+		files[partial] = versionOf(info)
+		return string(content), true, nil
+	})
+	if err != nil {
+		delete(ts.cache, name)
+		return nil, &common.InvalidTemplateError{Name: name, Err: err}
+	}
+	ts.cache[name] = cachedTemplate{template: tmpl, files: files}
+	return tmpl, nil
+}
+
+// unchanged reports whether the files of a cached template are as they were
+// when it was parsed.
+func (ts *TemplateStore) unchanged(cached cachedTemplate) bool {
+	for name, version := range cached.files {
+		_, info, err := ts.stat(name)
+		if err != nil || info.Size() != version.size || !info.ModTime().Equal(version.modTime) {
+			return false
+		}
+	}
+	return true
+}
+
+// invalidate drops the cached templates that use the named template.
+func (ts *TemplateStore) invalidate(name string) {
+	for cachedName, cached := range ts.cache {
+		if _, ok := cached.files[name]; ok {
+			delete(ts.cache, cachedName)
+		}
+	}
+}
+
+// Put creates or replaces the named template. Content that does not parse is
+// rejected. created reports whether the template is new.
+func (ts *TemplateStore) Put(name, content string) (info TemplateInfo, created bool, err error) {
+	path, err := ts.path(name)
+	if err != nil {
+		return TemplateInfo{}, false, err
+	}
+	// Partials are resolved when the template is printed, as they may be
+	// stored later; only the syntax is checked here
+	if _, err := template.NewTemplate(content); err != nil {
+		return TemplateInfo{}, false, &common.InvalidTemplateError{Name: name, Err: err}
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	_, err = os.Stat(path)
+	created = errors.Is(err, os.ErrNotExist)
+
+	if err := writeFileAtomic(ts.dir, path, []byte(content)); err != nil {
+		return TemplateInfo{}, false, fmt.Errorf("failed to save template %q: %w", name, err)
+	}
+
+	ts.invalidate(name)
+	stat, err := os.Stat(path)
+	if err != nil {
+		return TemplateInfo{}, false, fmt.Errorf("failed to save template %q: %w", name, err)
+	}
+	return templateInfo(name, stat), created, nil
+}
+
+// Delete removes the named template.
+func (ts *TemplateStore) Delete(name string) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	path, _, err := ts.stat(name)
+	if err != nil {
+		return err
+	}
+	ts.invalidate(name)
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete template %q: %w", name, err)
+	}
+	delete(ts.schemas, name)
+	if err := os.Remove(filepath.Join(ts.dir, name+schemaExt)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete the schema of template %q: %w", name, err)
+	}
+	return nil
+}
+
+// Schema returns the JSON Schema of the named template as stored.
+func (ts *TemplateStore) Schema(name string) ([]byte, error) {
+	if _, _, err := ts.stat(name); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(ts.dir, name+schemaExt))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, &common.SchemaNotFoundError{Template: name}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the schema of template %q: %w", name, err)
+	}
+	return data, nil
+}
+
+// PutSchema creates or replaces the JSON Schema of the named template.
+// created reports whether the template had no schema before.
+func (ts *TemplateStore) PutSchema(name string, data []byte) (created bool, err error) {
+	if _, _, err := ts.stat(name); err != nil {
+		return false, err
+	}
+	if _, err := schema.Parse(data); err != nil {
+		return false, &common.InvalidSchemaError{Template: name, Err: err}
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	path := filepath.Join(ts.dir, name+schemaExt)
+	_, err = os.Stat(path)
+	created = errors.Is(err, os.ErrNotExist)
+	if err := writeFileAtomic(ts.dir, path, data); err != nil {
+		return false, fmt.Errorf("failed to save the schema of template %q: %w", name, err)
+	}
+	delete(ts.schemas, name)
+	return created, nil
+}
+
+// DeleteSchema removes the JSON Schema of the named template.
+func (ts *TemplateStore) DeleteSchema(name string) error {
+	if _, _, err := ts.stat(name); err != nil {
+		return err
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	delete(ts.schemas, name)
+	err := os.Remove(filepath.Join(ts.dir, name+schemaExt))
+	if errors.Is(err, os.ErrNotExist) {
+		return &common.SchemaNotFoundError{Template: name}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete the schema of template %q: %w", name, err)
+	}
+	return nil
+}
+
+// Variables checks variables against the schema of the named template and
+// returns them with the schema's defaults filled in. Templates without a
+// schema accept any variables. Every problem is reported in one
+// InvalidVariablesError.
+func (ts *TemplateStore) Variables(name string, variables map[string]any) (map[string]any, error) {
+	s, err := ts.parsedSchema(name)
+	if err != nil || s == nil {
+		return variables, err
+	}
+
+	var value any = variables
+	if variables == nil {
+		// Missing variables are an empty object, so required lists the fields
+		value = map[string]any{}
+	}
+	value = s.WithDefaults(value)
+	if problems := s.Validate(value); len(problems) > 0 {
+		messages := make([]string, len(problems))
+		for i, problem := range problems {
+			messages[i] = problem.String()
+		}
+		return nil, &common.InvalidVariablesError{Template: name, Problems: messages}
+	}
+	filled, _ := value.(map[string]any)
+	return filled, nil
+}
+
+// parsedSchema returns the parsed schema of the named template, or nil when
+// it has none.
+func (ts *TemplateStore) parsedSchema(name string) (*schema.Schema, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	path := filepath.Join(ts.dir, name+schemaExt)
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		delete(ts.schemas, name)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the schema of template %q: %w", name, err)
+	}
+	if cached, ok := ts.schemas[name]; ok && cached.version.size == info.Size() && cached.version.modTime.Equal(info.ModTime()) {
+		return cached.schema, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the schema of template %q: %w", name, err)
+	}
+	s, err := schema.Parse(data)
+	if err != nil {
+		return nil, &common.InvalidSchemaError{Template: name, Err: err}
+	}
+	ts.schemas[name] = cachedSchema{schema: s, version: versionOf(info)}
+	return s, nil
+}
+
+// writeFileAtomic writes a temporary file in dir and renames it to path, so
+// readers never see half a file.
+func writeFileAtomic(dir, path string, data []byte) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(dir, "."+filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(file.Name(), 0o644)
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+	}
+	return err
+}
+
+func versionOf(info os.FileInfo) fileVersion {
+	return fileVersion{size: info.Size(), modTime: info.ModTime()}
+}
+
+func templateInfo(name string, info os.FileInfo) TemplateInfo {
+	return TemplateInfo{Name: name, Size: info.Size(), ModifiedAt: info.ModTime()}
+}
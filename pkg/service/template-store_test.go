@@ -0,0 +1,260 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/common"
+	"github.com/jonasclaes/go-thermal-printer/pkg/template"
+)
+
+func TestTemplateStoreCRUD(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "templates")
+	store := NewTemplateStore(dir)
+
+	if templates, err := store.List(); err != nil || len(templates) != 0 {
+		t.Fatalf("expected an empty store before the directory exists, got %v, %v", templates, err)
+	}
+
+	info, created, err := store.Put("receipt", "{{ bold .title }}")
+	if err != nil || !created || info.Name != "receipt" || info.Size != 17 {
+		t.Fatalf("expected the template to be created, got %+v, %v, %v", info, created, err)
+	}
+	if _, created, err = store.Put("receipt", "{{ .title }}"); err != nil || created {
+		t.Fatalf("expected the template to be replaced, got %v, %v", created, err)
+	}
+	if _, _, err := store.Put("banner", "Banner"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Files that are not templates are left out of the list
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	templates, err := store.List()
+	if err != nil || len(templates) != 2 || templates[0].Name != "banner" || templates[1].Name != "receipt" {
+		t.Fatalf("expected banner and receipt, got %+v, %v", templates, err)
+	}
+
+	_, content, err := store.Get("receipt")
+	if err != nil || content != "{{ .title }}" {
+		t.Fatalf("expected the replaced content, got %q, %v", content, err)
+	}
+
+	if err := store.Delete("receipt"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	var notFound *common.TemplateNotFoundError
+	if _, _, err := store.Get("receipt"); !errors.As(err, &notFound) {
+		t.Fatalf("expected TemplateNotFoundError after delete, got %v", err)
+	}
+	if err := store.Delete("receipt"); !errors.As(err, &notFound) {
+		t.Fatalf("expected TemplateNotFoundError for a second delete, got %v", err)
+	}
+	if _, err := store.Template("receipt"); !errors.As(err, &notFound) {
+		t.Fatalf("expected the cached template to be gone, got %v", err)
+	}
+}
+
+func TestTemplateStoreRejectsInvalidNamesAndContent(t *testing.T) {
+	store := NewTemplateStore(t.TempDir())
+
+	for _, name := range []string{"../secret", "a/b", "", ".hidden", "receipt.tmpl", "/etc/passwd"} {
+		var invalid *common.InvalidTemplateNameError
+		if _, _, err := store.Put(name, "x"); !errors.As(err, &invalid) || invalid.HttpStatusCode() != 400 {
+			t.Fatalf("%q: expected InvalidTemplateNameError, got %v", name, err)
+		}
+		if _, err := store.Template(name); !errors.As(err, &invalid) {
+			t.Fatalf("%q: expected InvalidTemplateNameError, got %v", name, err)
+		}
+	}
+
+	var invalid *common.InvalidTemplateError
+	if _, _, err := store.Put("broken", "{{ .title "); !errors.As(err, &invalid) || invalid.HttpStatusCode() != 400 {
+		t.Fatalf("expected InvalidTemplateError, got %v", err)
+	}
+	if _, _, err := store.Get("broken"); err == nil {
+		t.Fatal("expected a template that does not parse not to be stored")
+	}
+}
+
+func TestTemplateStoreCachesUntilTheFileChanges(t *testing.T) {
+	dir := t.TempDir()
+	store := NewTemplateStore(dir)
+	if _, _, err := store.Put("note", "first"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	first, err := store.Template("note")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again, _ := store.Template("note"); again != first {
+		t.Fatal("expected the parsed template to be cached")
+	}
+
+	// An edit on disk, outside the store, invalidates the cached template
+	path := filepath.Join(dir, "note.tmpl")
+	if err := os.WriteFile(path, []byte("second edit"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	changed, err := store.Template("note")
+	if err != nil || changed == first {
+		t.Fatalf("expected the template to be parsed again, got %v", err)
+	}
+
+	if _, _, err := store.Put("note", "third"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if replaced, _ := store.Template("note"); replaced == changed {
+		t.Fatal("expected Put to replace the cached template")
+	}
+}
+
+func TestTemplateStoreResolvesPartials(t *testing.T) {
+	store := NewTemplateStore(t.TempDir())
+	for name, content := range map[string]string{
+		"layout": `{{ template "header" . }}{{ block "body" . }}{{ end }}`,
+		"header": `HEAD`,
+		"note":   `{{ template "layout" . }}{{ define "body" }}BODY{{ end }}`,
+	} {
+		if _, _, err := store.Put(name, content); err != nil {
+			t.Fatalf("put %s: %v", name, err)
+		}
+	}
+
+	render := func() string {
+		tmpl, err := store.Template("note")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		out, err := template.NewRenderer().Render(tmpl, nil)
+		if err != nil {
+			t.Fatalf("render failed: %v", err)
+		}
+		return string(out)
+	}
+	if out := render(); !strings.Contains(out, "HEADBODY") {
+		t.Fatalf("expected the header and body, got %q", out)
+	}
+
+	// Changing a partial invalidates the templates that use it
+	if _, _, err := store.Put("header", `NEW HEAD`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out := render(); !strings.Contains(out, "NEW HEADBODY") {
+		t.Fatalf("expected the new header, got %q", out)
+	}
+
+	if err := store.Delete("header"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var invalid *common.InvalidTemplateError
+	if _, err := store.Template("note"); !errors.As(err, &invalid) || !strings.Contains(err.Error(), `invokes "header"`) {
+		t.Fatalf("expected a missing partial error, got %v", err)
+	}
+}
+
+func TestShippedTemplatesResolve(t *testing.T) {
+	store := NewTemplateStore(filepath.Join("..", "..", "templates"))
+	templates, err := store.List()
+	if err != nil || len(templates) == 0 {
+		t.Fatalf("expected the shipped templates, got %v, %v", templates, err)
+	}
+	for _, info := range templates {
+		if _, err := store.Template(info.Name); err != nil {
+			t.Fatalf("template %s: %v", info.Name, err)
+		}
+		if info.HasSchema {
+			if _, err := store.parsedSchema(info.Name); err != nil {
+				t.Fatalf("schema of %s: %v", info.Name, err)
+			}
+		}
+	}
+}
+
+func TestTemplateStoreSchemas(t *testing.T) {
+	store := NewTemplateStore(t.TempDir())
+	schema := `{
+		"type": "object",
+		"required": ["title", "total"],
+		"properties": {
+			"title": {"type": "string"},
+			"total": {"type": "number", "minimum": 0},
+			"footer": {"type": "string", "default": "Thanks!"}
+		},
+		"additionalProperties": false
+	}`
+
+	var notFound *common.TemplateNotFoundError
+	if _, err := store.PutSchema("note", []byte(schema)); !errors.As(err, &notFound) {
+		t.Fatalf("expected TemplateNotFoundError without the template, got %v", err)
+	}
+	if _, _, err := store.Put("note", "{{ .title }} {{ .footer }}"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var noSchema *common.SchemaNotFoundError
+	if _, err := store.Schema("note"); !errors.As(err, &noSchema) {
+		t.Fatalf("expected SchemaNotFoundError, got %v", err)
+	}
+	// Without a schema any variables are accepted as they are
+	if variables, err := store.Variables("note", map[string]any{"x": 1}); err != nil || variables["x"] != 1 {
+		t.Fatalf("expected the variables unchanged, got %v, %v", variables, err)
+	}
+
+	var invalidSchema *common.InvalidSchemaError
+	if _, err := store.PutSchema("note", []byte(`{"type": "text"}`)); !errors.As(err, &invalidSchema) {
+		t.Fatalf("expected InvalidSchemaError, got %v", err)
+	}
+	if created, err := store.PutSchema("note", []byte(schema)); err != nil || !created {
+		t.Fatalf("expected the schema to be created, got %v, %v", created, err)
+	}
+	if data, err := store.Schema("note"); err != nil || string(data) != schema {
+		t.Fatalf("expected the stored schema, got %q, %v", data, err)
+	}
+	if info, _, err := store.Get("note"); err != nil || !info.HasSchema {
+		t.Fatalf("expected the template to report its schema, got %+v, %v", info, err)
+	}
+
+	variables, err := store.Variables("note", map[string]any{"title": "Order", "total": 12})
+	if err != nil || variables["footer"] != "Thanks!" {
+		t.Fatalf("expected the default footer, got %v, %v", variables, err)
+	}
+
+	var invalid *common.InvalidVariablesError
+	_, err = store.Variables("note", map[string]any{"total": "12", "color": "red"})
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidVariablesError, got %v", err)
+	}
+	want := []string{"title: is required", "color: is not allowed", "total: must be a number, got a string"}
+	if strings.Join(invalid.Problems, "; ") != strings.Join(want, "; ") {
+		t.Fatalf("expected problems %q, got %q", want, invalid.Problems)
+	}
+	if _, err := store.Variables("note", nil); !errors.As(err, &invalid) || len(invalid.Problems) != 2 {
+		t.Fatalf("expected missing variables to be reported, got %v", err)
+	}
+
+	// Edits on disk are picked up
+	relaxed := `{"properties": {"title": {"type": "string"}}}`
+	if err := os.WriteFile(filepath.Join(store.dir, "note.schema.json"), []byte(relaxed), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if _, err := store.Variables("note", map[string]any{"color": "red"}); err != nil {
+		t.Fatalf("expected the edited schema to apply, got %v", err)
+	}
+
+	if err := store.Delete("note"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(store.dir, "note.schema.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected the schema to be deleted with the template, got %v", err)
+	}
+}
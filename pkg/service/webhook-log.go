@@ -0,0 +1,188 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/common"
+)
+
+const defaultMaxRetainedDeliveries = 1000
+
+type WebhookDeliveryState string
+
+const (
+	WebhookPending   WebhookDeliveryState = "pending"
+	WebhookDelivered WebhookDeliveryState = "delivered"
+	WebhookFailed    WebhookDeliveryState = "failed"
+	// WebhookDropped: the webhook's queue was full, so the event was never sent
+	WebhookDropped WebhookDeliveryState = "dropped"
+)
+
+// WebhookDelivery is a snapshot of sending one event to one webhook.
+type WebhookDelivery struct {
+	ID        string
+	Webhook   string
+	EventID   uint64
+	EventType string
+	Printer   string
+	State     WebhookDeliveryState
+	Attempts  int
+	// StatusCode is the HTTP status of the last attempt, 0 when it got no
+	// response
+	StatusCode int
+	// Error is why the last attempt failed
+	Error         string
+	CreatedAt     time.Time
+	NextAttemptAt *time.Time
+	FinishedAt    *time.Time
+}
+
+// Finished reports whether the delivery will not be attempted again.
+func (d WebhookDelivery) Finished() bool {
+	return d.State != WebhookPending
+}
+
+// DeliveryLog keeps the recent webhook deliveries. Finished deliveries are
+// evicted oldest-first once more than maxDeliveries are retained.
+type DeliveryLog struct {
+	mu            sync.Mutex
+	deliveries    map[string]*WebhookDelivery
+	order         []string
+	maxDeliveries int
+	now           func() time.Time
+}
+
+func NewDeliveryLog(maxDeliveries int) *DeliveryLog {
+	if maxDeliveries <= 0 {
+		maxDeliveries = defaultMaxRetainedDeliveries
+	}
+	return &DeliveryLog{
+		deliveries:    make(map[string]*WebhookDelivery),
+		maxDeliveries: maxDeliveries,
+		now:           time.Now,
+	}
+}
+
+// Get returns a snapshot of the delivery with the given ID.
+func (l *DeliveryLog) Get(id string) (WebhookDelivery, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delivery, ok := l.deliveries[id]
+	if !ok {
+		return WebhookDelivery{}, &common.WebhookDeliveryNotFoundError{ID: id}
+	}
+	return *delivery, nil
+}
+
+// List returns the deliveries newest first, only those to webhook and in
+// state when they are set, and at most limit of them unless it is 0.
+func (l *DeliveryLog) List(webhook string, state WebhookDeliveryState, limit int) []WebhookDelivery {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	deliveries := []WebhookDelivery{}
+	for i := len(l.order) - 1; i >= 0; i-- {
+		delivery := l.deliveries[l.order[i]]
+		if webhook != "" && delivery.Webhook != webhook {
+			continue
+		}
+		if state != "" && delivery.State != state {
+			continue
+		}
+		deliveries = append(deliveries, *delivery)
+		if limit > 0 && len(deliveries) == limit {
+			break
+		}
+	}
+	return deliveries
+}
+
+// add registers a pending delivery of event to webhook.
+func (l *DeliveryLog) add(webhook string, event Event) WebhookDelivery {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delivery := &WebhookDelivery{
+		ID:        newJobID(),
+		Webhook:   webhook,
+		EventID:   event.ID,
+		EventType: event.Type,
+		Printer:   event.Printer,
+		State:     WebhookPending,
+		CreatedAt: l.now(),
+	}
+	l.deliveries[delivery.ID] = delivery
+	l.order = append(l.order, delivery.ID)
+	l.evict()
+
+	return *delivery
+}
+
+// attempted records the outcome of an attempt.
+func (l *DeliveryLog) attempted(id string, statusCode int, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delivery, ok := l.deliveries[id]
+	if !ok {
+		return
+	}
+	delivery.Attempts++
+	delivery.StatusCode = statusCode
+	delivery.NextAttemptAt = nil
+	delivery.Error = ""
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+}
+
+// retryAt records when the next attempt is due.
+func (l *DeliveryLog) retryAt(id string, at time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if delivery, ok := l.deliveries[id]; ok {
+		delivery.NextAttemptAt = &at
+	}
+}
+
+// finish records the final state of a delivery. A non-nil err replaces the
+// error of the last attempt.
+func (l *DeliveryLog) finish(id string, state WebhookDeliveryState, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delivery, ok := l.deliveries[id]
+	if !ok {
+		return
+	}
+	now := l.now()
+	delivery.State = state
+	delivery.NextAttemptAt = nil
+	delivery.FinishedAt = &now
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+}
+
+// evict drops the oldest finished deliveries while over capacity. Callers
+// must hold l.mu.
+func (l *DeliveryLog) evict() {
+	excess := len(l.order) - l.maxDeliveries
+	if excess <= 0 {
+		return
+	}
+
+	kept := l.order[:0]
+	for _, id := range l.order {
+		if excess > 0 && l.deliveries[id].Finished() {
+			delete(l.deliveries, id)
+			excess--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	l.order = kept
+}
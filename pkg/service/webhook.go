@@ -0,0 +1,320 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/escpos"
+	"github.com/jonasclaes/go-thermal-printer/pkg/model"
+)
+
+const (
+	// webhookQueueSize is how many deliveries may wait for each webhook
+	webhookQueueSize = 256
+	// maxWebhookResponse is how much of a response body is read before the
+	// connection is reused
+	maxWebhookResponse = 64 << 10
+)
+
+// Headers of every webhook request. The signature header is only sent when
+// the webhook has a secret.
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// SignWebhook returns the hex HMAC-SHA256 of "<timestamp>.<body>" under
+// secret, which is sent as "sha256=<signature>". Covering the timestamp
+// lets receivers reject replayed requests.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookDispatcher POSTs the events published on a bus to the configured
+// webhooks. Every webhook has its own queue and goroutine, so a slow or
+// failing endpoint only delays its own deliveries, which are sent in order.
+type WebhookDispatcher struct {
+	webhooks     []*webhookTarget
+	deliveries   *DeliveryLog
+	subscription *Subscription
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type webhookTarget struct {
+	config     model.WebhookConfig
+	client     *http.Client
+	encode     func(Event) any
+	deliveries *DeliveryLog
+	queue      chan webhookJob
+}
+
+type webhookJob struct {
+	delivery string
+	event    Event
+}
+
+// NewWebhookDispatcher starts sending the events of bus to webhooks. encode
+// returns the JSON payload of an event for webhooks of the json format.
+func NewWebhookDispatcher(webhooks []model.WebhookConfig, bus *EventBus, encode func(Event) any) *WebhookDispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &WebhookDispatcher{
+		deliveries: NewDeliveryLog(0),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+	if len(webhooks) == 0 {
+		return d
+	}
+
+	for _, config := range webhooks {
+		target := &webhookTarget{
+			config:     config,
+			client:     &http.Client{Timeout: time.Duration(config.TimeoutMs) * time.Millisecond},
+			encode:     encode,
+			deliveries: d.deliveries,
+			queue:      make(chan webhookJob, webhookQueueSize),
+		}
+		d.webhooks = append(d.webhooks, target)
+
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			target.run(ctx)
+		}()
+	}
+
+	d.subscription = bus.Subscribe(webhookQueueSize)
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		d.dispatch()
+	}()
+
+	return d
+}
+
+// Webhooks returns the configured webhooks.
+func (d *WebhookDispatcher) Webhooks() []model.WebhookConfig {
+	webhooks := make([]model.WebhookConfig, 0, len(d.webhooks))
+	for _, target := range d.webhooks {
+		webhooks = append(webhooks, target.config)
+	}
+	return webhooks
+}
+
+// Deliveries returns the log of recent deliveries.
+func (d *WebhookDispatcher) Deliveries() *DeliveryLog {
+	return d.deliveries
+}
+
+// Close stops sending events. Deliveries that wait for a retry fail.
+func (d *WebhookDispatcher) Close() error {
+	d.cancel()
+	if d.subscription != nil {
+		d.subscription.Close()
+	}
+	d.wg.Wait()
+	return nil
+}
+
+func (d *WebhookDispatcher) dispatch() {
+	for event := range d.subscription.Events() {
+		for _, target := range d.webhooks {
+			if !target.wants(event) {
+				continue
+			}
+
+			delivery := d.deliveries.add(target.config.Name, event)
+			select {
+			case target.queue <- webhookJob{delivery: delivery.ID, event: event}:
+			default:
+				log.Printf("webhooks: %s: queue is full; dropping %s event %d", target.config.Name, event.Type, event.ID)
+				d.deliveries.finish(delivery.ID, WebhookDropped, errors.New("webhook queue is full"))
+			}
+		}
+	}
+}
+
+func (t *webhookTarget) wants(event Event) bool {
+	if len(t.config.Printers) > 0 && !slices.Contains(t.config.Printers, event.Printer) {
+		return false
+	}
+	if len(t.config.Events) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(t.config.Events, func(filter string) bool {
+		return EventTypeMatches(event.Type, filter)
+	})
+}
+
+func (t *webhookTarget) run(ctx context.Context) {
+	for {
+		select {
+		case job := <-t.queue:
+			t.deliver(ctx, job)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// deliver POSTs the event until the webhook accepts it, a request fails in
+// a way that retrying cannot fix, or the attempts run out.
+func (t *webhookTarget) deliver(ctx context.Context, job webhookJob) {
+	body, err := t.payload(job.event)
+	if err != nil {
+		t.deliveries.finish(job.delivery, WebhookFailed, fmt.Errorf("encode payload: %w", err))
+		return
+	}
+
+	maxDelay := time.Duration(t.config.MaxRetryDelayMs) * time.Millisecond
+	delay := min(time.Duration(t.config.RetryDelayMs)*time.Millisecond, maxDelay)
+	for attempt := 1; ; attempt++ {
+		statusCode, err := t.post(ctx, job, body)
+		t.deliveries.attempted(job.delivery, statusCode, err)
+		if err == nil {
+			t.deliveries.finish(job.delivery, WebhookDelivered, nil)
+			return
+		}
+		if !retryableWebhookStatus(statusCode) || attempt >= t.config.MaxAttempts || ctx.Err() != nil {
+			log.Printf("webhooks: %s: giving up on %s event %d after %d attempt(s): %v", t.config.Name, job.event.Type, job.event.ID, attempt, err)
+			t.deliveries.finish(job.delivery, WebhookFailed, err)
+			return
+		}
+
+		t.deliveries.retryAt(job.delivery, time.Now().Add(delay))
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			t.deliveries.finish(job.delivery, WebhookFailed, errors.New("stopped before the next attempt"))
+			return
+		}
+		delay = min(delay*2, maxDelay)
+	}
+}
+
+// retryableWebhookStatus reports whether a request that got statusCode, or
+// no response when it is 0, may succeed when tried again.
+func retryableWebhookStatus(statusCode int) bool {
+	switch {
+	case statusCode == 0, statusCode == http.StatusRequestTimeout, statusCode == http.StatusTooManyRequests:
+		return true
+	}
+	return statusCode >= 500
+}
+
+// post sends one attempt and returns the response status, or 0 when there
+// was no response.
+func (t *webhookTarget) post(ctx context.Context, job webhookJob, body []byte) (int, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, t.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "go-thermal-printer")
+	request.Header.Set(WebhookEventHeader, job.event.Type)
+	request.Header.Set(WebhookDeliveryHeader, job.delivery)
+	request.Header.Set(WebhookTimestampHeader, timestamp)
+	if t.config.Secret != "" {
+		request.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhook(t.config.Secret, timestamp, body))
+	}
+
+	response, err := t.client.Do(request)
+	if err != nil {
+		// The error repeats the URL, whose path may hold a token
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = request.URL.Scheme + "://" + request.URL.Host
+		}
+		return 0, err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, maxWebhookResponse))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("webhook answered %s", response.Status)
+	}
+	return response.StatusCode, nil
+}
+
+func (t *webhookTarget) payload(event Event) ([]byte, error) {
+	if t.config.Format == model.WebhookFormatSlack {
+		return json.Marshal(map[string]string{"text": slackText(event)})
+	}
+	if t.encode == nil {
+		return json.Marshal(event)
+	}
+	return json.Marshal(t.encode(event))
+}
+
+// alertTexts describe the alert conditions in Slack messages.
+var alertTexts = map[StatusAlert]string{
+	AlertUnreachable:        "not answering",
+	AlertOffline:            "offline",
+	AlertCoverOpen:          "cover open",
+	AlertPaperNearEnd:       "paper running low",
+	AlertPaperOut:           "out of paper",
+	AlertAutocutterError:    "autocutter error",
+	AlertUnrecoverableError: "unrecoverable error",
+}
+
+// slackText describes an event in one line for a Slack message.
+func slackText(event Event) string {
+	switch data := event.Data.(type) {
+	case Job:
+		switch event.Type {
+		case EventJobFailed:
+			return fmt.Sprintf("Print job %s on %s failed: %s", data.ID, event.Printer, data.Error)
+		case EventJobCancelled:
+			return fmt.Sprintf("Print job %s on %s was cancelled", data.ID, event.Printer)
+		}
+		return fmt.Sprintf("Print job %s on %s is %s", data.ID, event.Printer, data.State)
+	case StatusAlertEvent:
+		text := alertTexts[data.Alert]
+		if text == "" {
+			text = string(data.Alert)
+		}
+		if !data.Active {
+			return fmt.Sprintf("Printer %s: %s (resolved)", event.Printer, text)
+		}
+		return fmt.Sprintf("Printer %s: %s", event.Printer, text)
+	case PrinterState:
+		if data.Error != "" {
+			return fmt.Sprintf("Printer %s is not answering: %s", event.Printer, data.Error)
+		}
+		messages := escpos.DecodeStatus(data.PrinterStatus, data.OfflineStatus, data.ErrorStatus, data.ContinuousPaperStatus).Messages()
+		if len(messages) == 0 {
+			return fmt.Sprintf("Printer %s is ready", event.Printer)
+		}
+		return fmt.Sprintf("Printer %s: %s", event.Printer, strings.Join(messages, "; "))
+	case QueueDepth:
+		return fmt.Sprintf("Printer %s has %d job(s) waiting", event.Printer, data.Queued)
+	}
+	return fmt.Sprintf("%s on %s", event.Type, event.Printer)
+}
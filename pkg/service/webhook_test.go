@@ -0,0 +1,221 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/model"
+)
+
+type webhookRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// webhookServer answers with the given statuses in turn, then 204.
+type webhookServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	requests []webhookRequest
+	received chan struct{}
+}
+
+func newWebhookServer(t *testing.T, statuses ...int) *webhookServer {
+	t.Helper()
+
+	s := &webhookServer{statuses: statuses, received: make(chan struct{}, 64)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		s.mu.Lock()
+		s.requests = append(s.requests, webhookRequest{header: r.Header.Clone(), body: body})
+		status := http.StatusNoContent
+		if len(s.statuses) > 0 {
+			status, s.statuses = s.statuses[0], s.statuses[1:]
+		}
+		s.mu.Unlock()
+
+		w.WriteHeader(status)
+		s.received <- struct{}{}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *webhookServer) request(i int) webhookRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[i]
+}
+
+func (s *webhookServer) wait(t *testing.T, n int) {
+	t.Helper()
+	for range n {
+		select {
+		case <-s.received:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("webhook was not called")
+		}
+	}
+}
+
+func testWebhook(name, url string) model.WebhookConfig {
+	return model.WebhookConfig{
+		Name:            name,
+		URL:             url,
+		Format:          model.WebhookFormatJSON,
+		TimeoutMs:       1000,
+		MaxAttempts:     3,
+		RetryDelayMs:    5,
+		MaxRetryDelayMs: 20,
+	}
+}
+
+// waitForDelivery waits until the only delivery to webhook is finished.
+func waitForDelivery(t *testing.T, d *WebhookDispatcher, webhook string) WebhookDelivery {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		deliveries := d.Deliveries().List(webhook, "", 0)
+		if len(deliveries) == 1 && deliveries[0].Finished() {
+			return deliveries[0]
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("delivery to %s did not finish: %+v", webhook, d.Deliveries().List(webhook, "", 0))
+	return WebhookDelivery{}
+}
+
+func TestWebhookDispatcherSignsDeliveries(t *testing.T) {
+	server := newWebhookServer(t)
+	webhook := testWebhook("orders", server.URL)
+	webhook.Secret = "s3cret"
+	webhook.Events = []string{"job"}
+	webhook.Printers = []string{"kitchen"}
+
+	bus := NewEventBus()
+	d := NewWebhookDispatcher([]model.WebhookConfig{webhook}, bus, func(event Event) any {
+		return map[string]any{"id": event.ID, "type": event.Type}
+	})
+	defer d.Close()
+
+	// Filtered out by printer and by type
+	bus.Publish(EventJobFailed, "bar", Job{ID: "other"})
+	bus.Publish(EventPrinterStatus, "kitchen", PrinterState{})
+	event := bus.Publish(EventJobFailed, "kitchen", Job{ID: "j1", Printer: "kitchen", Error: "paper out"})
+
+	server.wait(t, 1)
+	delivery := waitForDelivery(t, d, "orders")
+	if delivery.State != WebhookDelivered || delivery.Attempts != 1 || delivery.StatusCode != http.StatusNoContent ||
+		delivery.EventID != event.ID || delivery.EventType != EventJobFailed || delivery.Printer != "kitchen" {
+		t.Fatalf("unexpected delivery %+v", delivery)
+	}
+
+	request := server.request(0)
+	var payload map[string]any
+	if err := json.Unmarshal(request.body, &payload); err != nil || payload["type"] != EventJobFailed {
+		t.Fatalf("expected the encoded event, got %s (%v)", request.body, err)
+	}
+	if request.header.Get(WebhookEventHeader) != EventJobFailed || request.header.Get(WebhookDeliveryHeader) != delivery.ID {
+		t.Fatalf("unexpected headers %v", request.header)
+	}
+	timestamp := request.header.Get(WebhookTimestampHeader)
+	if want := "sha256=" + SignWebhook("s3cret", timestamp, request.body); request.header.Get(WebhookSignatureHeader) != want {
+		t.Fatalf("expected signature %s, got %s", want, request.header.Get(WebhookSignatureHeader))
+	}
+}
+
+func TestWebhookDispatcherRetries(t *testing.T) {
+	retried := newWebhookServer(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	exhausted := newWebhookServer(t, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+	rejected := newWebhookServer(t, http.StatusBadRequest)
+
+	bus := NewEventBus()
+	d := NewWebhookDispatcher([]model.WebhookConfig{
+		testWebhook("retried", retried.URL),
+		testWebhook("exhausted", exhausted.URL),
+		testWebhook("rejected", rejected.URL),
+	}, bus, nil)
+	defer d.Close()
+
+	bus.Publish(EventPrinterAlert, "kitchen", StatusAlertEvent{Alert: AlertPaperOut, Active: true})
+
+	if delivery := waitForDelivery(t, d, "retried"); delivery.State != WebhookDelivered || delivery.Attempts != 3 || delivery.Error != "" {
+		t.Fatalf("expected delivery on the third attempt, got %+v", delivery)
+	}
+	if delivery := waitForDelivery(t, d, "exhausted"); delivery.State != WebhookFailed || delivery.Attempts != 3 ||
+		delivery.StatusCode != http.StatusBadGateway || !strings.Contains(delivery.Error, "502") {
+		t.Fatalf("expected failure after max_attempts, got %+v", delivery)
+	}
+	if delivery := waitForDelivery(t, d, "rejected"); delivery.State != WebhookFailed || delivery.Attempts != 1 {
+		t.Fatalf("expected a client error not to be retried, got %+v", delivery)
+	}
+}
+
+func TestWebhookDispatcherHidesURLPathInErrors(t *testing.T) {
+	server := newWebhookServer(t)
+	server.Close()
+	webhook := testWebhook("gone", server.URL+"/hooks/secret-token")
+	webhook.MaxAttempts = 1
+
+	bus := NewEventBus()
+	d := NewWebhookDispatcher([]model.WebhookConfig{webhook}, bus, nil)
+	defer d.Close()
+
+	bus.Publish(EventQueueDepth, "kitchen", QueueDepth{Queued: 1})
+	delivery := waitForDelivery(t, d, "gone")
+	if delivery.State != WebhookFailed || delivery.StatusCode != 0 || delivery.Error == "" || strings.Contains(delivery.Error, "secret-token") {
+		t.Fatalf("expected a connection error without the URL path, got %+v", delivery)
+	}
+}
+
+func TestWebhookDispatcherSlackFormat(t *testing.T) {
+	server := newWebhookServer(t)
+	webhook := testWebhook("ops", server.URL)
+	webhook.Format = model.WebhookFormatSlack
+
+	bus := NewEventBus()
+	d := NewWebhookDispatcher([]model.WebhookConfig{webhook}, bus, nil)
+	defer d.Close()
+
+	bus.Publish(EventPrinterAlert, "kitchen", StatusAlertEvent{Alert: AlertPaperOut, Active: true})
+	if delivery := waitForDelivery(t, d, "ops"); delivery.State != WebhookDelivered {
+		t.Fatalf("expected the message to be delivered, got %+v", delivery)
+	}
+
+	var payload map[string]string
+	if err := json.Unmarshal(server.request(0).body, &payload); err != nil || payload["text"] != "Printer kitchen: out of paper" {
+		t.Fatalf("unexpected Slack payload %s (%v)", server.request(0).body, err)
+	}
+}
+
+func TestDeliveryLogEvictsFinishedDeliveries(t *testing.T) {
+	deliveries := NewDeliveryLog(2)
+	pending := deliveries.add("a", Event{ID: 1})
+	finished := deliveries.add("a", Event{ID: 2})
+	deliveries.finish(finished.ID, WebhookDelivered, nil)
+	newest := deliveries.add("b", Event{ID: 3})
+
+	if _, err := deliveries.Get(finished.ID); err == nil {
+		t.Fatalf("expected the finished delivery to be evicted")
+	}
+	if _, err := deliveries.Get(pending.ID); err != nil {
+		t.Fatalf("expected the pending delivery to be kept: %v", err)
+	}
+	list := deliveries.List("", "", 0)
+	if len(list) != 2 || list[0].ID != newest.ID || list[1].ID != pending.ID {
+		t.Fatalf("expected the deliveries newest first, got %+v", list)
+	}
+	if list := deliveries.List("a", WebhookPending, 1); len(list) != 1 || list[0].ID != pending.ID {
+		t.Fatalf("expected the filtered deliveries, got %+v", list)
+	}
+}
@@ -0,0 +1,152 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/escpos"
+)
+
+type barcodeOptions struct {
+	height   int
+	width    int
+	position escpos.HRIPosition
+	font     escpos.CharacterFont
+}
+
+// buildBarcode renders the barcode helper:
+//
+//	{{ barcode "EAN13" "590123412345" "height" 80 "width" 2 "hri" "below" "hriFont" "B" }}
+//
+// HRI and size settings only apply to this barcode; the printer defaults are
+// restored afterwards.
+func buildBarcode(kind string, data any, args ...any) (string, error) {
+	system, err := escpos.ParseBarcodeSystem(kind)
+	if err != nil {
+		return "", fmt.Errorf("barcode: %w", err)
+	}
+
+	var content string
+	switch v := data.(type) {
+	case string:
+		content = v
+	case int, int64, uint, uint64:
+		content = fmt.Sprint(v)
+	default:
+		return "", fmt.Errorf("barcode expects string data, got %T", data)
+	}
+
+	if system == escpos.BarcodeCODE128 && !hasCode128CodeSet(content) {
+		// Plain text is encoded with code set B, which covers printable ASCII
+		content = "{B" + strings.ReplaceAll(content, "{", "{{")
+	}
+
+	options := barcodeOptions{
+		height:   escpos.DefaultBarcodeHeight,
+		width:    escpos.DefaultBarcodeWidth,
+		position: escpos.HRIBelow,
+		font:     escpos.CharacterFontA,
+	}
+
+	if len(args)%2 != 0 {
+		return "", fmt.Errorf("barcode expects key/value option pairs")
+	}
+	for i := 0; i < len(args); i += 2 {
+		key, ok := args[i].(string)
+		if !ok {
+			return "", fmt.Errorf("barcode option keys must be strings, got %T", args[i])
+		}
+		value := args[i+1]
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "height":
+			height, err := toInt(value)
+			if err != nil {
+				return "", fmt.Errorf("barcode height: %w", err)
+			}
+			if height < 1 || height > 255 {
+				return "", fmt.Errorf("barcode height must be between 1 and 255 dots; got %d", height)
+			}
+			options.height = height
+
+		case "width", "module", "modulewidth":
+			width, err := toInt(value)
+			if err != nil {
+				return "", fmt.Errorf("barcode width: %w", err)
+			}
+			if width < 2 || width > 6 {
+				return "", fmt.Errorf("barcode width must be between 2 and 6 dots; got %d", width)
+			}
+			options.width = width
+
+		case "hri", "text", "position":
+			position, err := parseHRIPosition(value)
+			if err != nil {
+				return "", err
+			}
+			options.position = position
+
+		case "hrifont", "font":
+			font, err := parseFont(value)
+			if err != nil {
+				return "", fmt.Errorf("barcode hriFont: %w", err)
+			}
+			if font > 1 {
+				return "", fmt.Errorf("barcode hriFont must be A or B")
+			}
+			options.font = escpos.CharacterFont(font)
+
+		default:
+			return "", fmt.Errorf("barcode: unknown option %q", key)
+		}
+	}
+
+	command, err := escpos.BarcodeCommand(system, content)
+	if err != nil {
+		return "", fmt.Errorf("barcode: %w", err)
+	}
+
+	var builder strings.Builder
+	builder.Write([]byte{0x1D, 0x68, byte(options.height)})
+	builder.Write([]byte{0x1D, 0x77, byte(options.width)})
+	builder.Write([]byte{0x1D, 0x48, byte(options.position)})
+	builder.Write([]byte{0x1D, 0x66, byte(options.font)})
+	builder.Write(command)
+	builder.Write([]byte{
+		0x1D, 0x68, escpos.DefaultBarcodeHeight,
+		0x1D, 0x77, escpos.DefaultBarcodeWidth,
+		0x1D, 0x48, byte(escpos.HRINone),
+		0x1D, 0x66, byte(escpos.CharacterFontA),
+	})
+
+	return builder.String(), nil
+}
+
+func hasCode128CodeSet(data string) bool {
+	return len(data) >= 2 && data[0] == '{' && strings.ContainsRune("ABC", rune(data[1]))
+}
+
+func parseHRIPosition(value any) (escpos.HRIPosition, error) {
+	if enabled, ok := value.(bool); ok {
+		if enabled {
+			return escpos.HRIBelow, nil
+		}
+		return escpos.HRINone, nil
+	}
+
+	position, ok := value.(string)
+	if !ok {
+		return 0, fmt.Errorf("barcode hri expects none, above, below or both; got %T", value)
+	}
+	switch strings.ToLower(strings.TrimSpace(position)) {
+	case "none", "off", "false":
+		return escpos.HRINone, nil
+	case "above", "top":
+		return escpos.HRIAbove, nil
+	case "below", "bottom", "true":
+		return escpos.HRIBelow, nil
+	case "both":
+		return escpos.HRIBoth, nil
+	default:
+		return 0, fmt.Errorf("barcode hri expects none, above, below or both; got %q", position)
+	}
+}
@@ -0,0 +1,54 @@
+package template
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBarcodeTemplateFunc(t *testing.T) {
+	out, err := RenderToBytes(`{{ barcode "EAN8" "9638507" "height" 60 "hri" "both" "hriFont" "B" }}`, nil)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	want := "\x1Dh\x3C\x1Dw\x03\x1DH\x03\x1Df\x01\x1Dk\x44\x079638507" +
+		"\x1Dh\xA2\x1Dw\x03\x1DH\x00\x1Df\x00"
+	if !strings.Contains(string(out), want) {
+		t.Fatalf("expected barcode commands %q in %q", want, out)
+	}
+}
+
+func TestBarcodeTemplateFuncSelectsCode128CodeSet(t *testing.T) {
+	out, err := buildBarcode("code128", "a{b")
+	if err != nil {
+		t.Fatalf("barcode failed: %v", err)
+	}
+	if !strings.Contains(out, "\x1Dk\x49\x06{Ba{{b") {
+		t.Fatalf("expected code set B prefix and escaped brace, got %q", out)
+	}
+
+	out, err = buildBarcode("CODE128", "{C1234")
+	if err != nil {
+		t.Fatalf("barcode failed: %v", err)
+	}
+	if !strings.Contains(out, "\x1Dk\x49\x06{C1234") {
+		t.Fatalf("expected explicit code set to be kept, got %q", out)
+	}
+}
+
+func TestBarcodeTemplateFuncErrors(t *testing.T) {
+	cases := map[string]string{
+		`{{ barcode "EAN13" "123" }}`:                 "EAN13: expects 12 digits",
+		`{{ barcode "QR" "123" }}`:                    "unsupported barcode type",
+		`{{ barcode "CODE39" "A" "width" 9 }}`:        "width must be between 2 and 6",
+		`{{ barcode "CODE39" "A" "hri" "sideways" }}`: "hri expects none, above, below or both",
+		`{{ barcode "CODE39" "A" "color" "red" }}`:    "unknown option",
+		`{{ barcode "ITF" "12345" }}`:                 "even number of digits",
+	}
+	for tmpl, want := range cases {
+		_, err := RenderToBytes(tmpl, nil)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", tmpl, want, err)
+		}
+	}
+}
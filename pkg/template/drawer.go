@@ -0,0 +1,90 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/escpos"
+)
+
+// buildDrawer renders the drawer helper:
+//
+//	{{ drawer }}
+//	{{ drawer "pin" 5 "on" 100 "off" 200 }}
+//
+// It pulses the drawer kick-out connector (pin 2 by default) for "on" ms.
+func buildDrawer(args ...any) (string, error) {
+	pin := escpos.DrawerPin2
+	onMs, offMs := escpos.DefaultDrawerOnMs, escpos.DefaultDrawerOffMs
+
+	if len(args)%2 != 0 {
+		return "", fmt.Errorf("drawer expects key/value option pairs")
+	}
+	for i := 0; i < len(args); i += 2 {
+		key, ok := args[i].(string)
+		if !ok {
+			return "", fmt.Errorf("drawer option keys must be strings, got %T", args[i])
+		}
+		value, err := toInt(args[i+1])
+		if err != nil {
+			return "", fmt.Errorf("drawer %s: %w", key, err)
+		}
+
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "pin":
+			pin, err = escpos.ParseDrawerPin(value)
+			if err != nil {
+				return "", err
+			}
+		case "on", "onms":
+			onMs = value
+		case "off", "offms":
+			offMs = value
+		default:
+			return "", fmt.Errorf("drawer: unknown option %q", key)
+		}
+	}
+
+	command, err := escpos.DrawerKickCommand(pin, onMs, offMs)
+	if err != nil {
+		return "", err
+	}
+	return string(command), nil
+}
+
+// buildBeep renders the beep helper:
+//
+//	{{ beep }}
+//	{{ beep "times" 3 "duration" 200 }}
+func buildBeep(args ...any) (string, error) {
+	times, durationMs := 1, escpos.DefaultBeepDuration
+
+	if len(args)%2 != 0 {
+		return "", fmt.Errorf("beep expects key/value option pairs")
+	}
+	for i := 0; i < len(args); i += 2 {
+		key, ok := args[i].(string)
+		if !ok {
+			return "", fmt.Errorf("beep option keys must be strings, got %T", args[i])
+		}
+		value, err := toInt(args[i+1])
+		if err != nil {
+			return "", fmt.Errorf("beep %s: %w", key, err)
+		}
+
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "times", "count":
+			times = value
+		case "duration", "ms":
+			durationMs = value
+		default:
+			return "", fmt.Errorf("beep: unknown option %q", key)
+		}
+	}
+
+	command, err := escpos.BeepCommand(times, durationMs)
+	if err != nil {
+		return "", err
+	}
+	return string(command), nil
+}
@@ -0,0 +1,43 @@
+package template
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/profile"
+)
+
+func TestDrawerAndBeepTemplateFuncs(t *testing.T) {
+	out, err := RenderToBytes(`{{ drawer }}{{ drawer "pin" 5 "on" 100 "off" 200 }}{{ beep "times" 2 "duration" 150 }}`, nil)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	for _, want := range []string{"\x1Bp\x00\x19\xFA", "\x1Bp\x01\x32\x64", "\x1BB\x02\x03"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected %q in %q", want, out)
+		}
+	}
+}
+
+func TestDrawerAndBeepTemplateFuncErrors(t *testing.T) {
+	cases := map[string]string{
+		`{{ drawer "pin" 3 }}`:   "drawer pin must be 2 or 5",
+		`{{ drawer "on" 1000 }}`: "between 2 and 510 ms",
+		`{{ drawer "pin" }}`:     "key/value option pairs",
+		`{{ beep "times" 12 }}`:  "between 1 and 9",
+		`{{ beep "volume" 11 }}`: "unknown option",
+	}
+	for tmpl, want := range cases {
+		_, err := RenderToBytes(tmpl, nil)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", tmpl, want, err)
+		}
+	}
+
+	epson, _ := profile.Builtin("tm-t88")
+	_, err := RenderToBytesWithOptions(`{{ beep }}`, nil, RenderOptions{Profile: epson})
+	if err == nil || !strings.Contains(err.Error(), "no buzzer") {
+		t.Fatalf("expected unsupported error, got %v", err)
+	}
+}
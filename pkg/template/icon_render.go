@@ -10,6 +10,7 @@ import (
 	"golang.org/x/exp/shiny/iconvg"
 
 	"github.com/jonasclaes/go-thermal-printer/pkg/escpos"
+	"github.com/jonasclaes/go-thermal-printer/pkg/profile"
 )
 
 const (
@@ -67,7 +68,7 @@ func clamp(v, min, max int) int {
 	return v
 }
 
-func iconTemplateFunc(name string, args ...any) (string, error) {
+func iconTemplateFunc(printer profile.Profile, name string, args ...any) (string, error) {
 	data, canonical, err := lookupIconData(name)
 	if err != nil {
 		return "", err
@@ -77,23 +78,23 @@ func iconTemplateFunc(name string, args ...any) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	opts.width = min(opts.width, printer.DotsPerLine)
 
 	img, err := rasterizeIcon(data, opts.width)
 	if err != nil {
 		return "", fmt.Errorf("icon %s: %w", canonical, err)
 	}
 
-	raster, err := escpos.ImageToRasterBytes(img, opts.width)
+	// The raster is exactly as wide as the icon, so {{ align }} places it
+	raster, err := escpos.RasterImage(img, escpos.ImageOptions{
+		MaxWidthDots: opts.width,
+		BandHeight:   printer.RasterBandHeight,
+	})
 	if err != nil {
 		return "", fmt.Errorf("icon %s: %w", canonical, err)
 	}
 
-	if len(raster) >= 3 {
-		raster[len(raster)-1] = byte(opts.feed)
-	}
-	raster = append(raster, 0x1B, 0x74, byte(defaultCharacterCodePage))
-
-	return string(raster), nil
+	return string(appendFeed(raster, opts.feed)), nil
 }
 
 func rasterizeIcon(data []byte, width int) (image.Image, error) {
@@ -3,6 +3,8 @@ package template
 import (
 	"bytes"
 	"testing"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/profile"
 )
 
 func TestSanitizeIconKey(t *testing.T) {
@@ -34,31 +36,24 @@ func TestLookupIconData(t *testing.T) {
 }
 
 func TestIconTemplateFunc(t *testing.T) {
-	result, err := iconTemplateFunc("ActionFace")
+	result, err := iconTemplateFunc(profile.Default(), "ActionFace")
 	if err != nil {
 		t.Fatalf("iconTemplateFunc error: %v", err)
 	}
 	payload := []byte(result)
-	if len(payload) < 5 {
-		t.Fatalf("icon payload too small: %d", len(payload))
-	}
-	if payload[0] != 0x1B || payload[1] != 0x40 {
-		t.Fatalf("expected payload to start with ESC @, got % x", payload[:2])
-	}
-	if payload[len(payload)-3] != 0x1B || payload[len(payload)-2] != 0x74 {
-		t.Fatalf("expected payload to end with ESC t, got % x", payload[len(payload)-3:])
+	if !bytes.HasPrefix(payload, []byte{0x1D, 0x76, 0x30, 0x00}) {
+		t.Fatalf("expected payload to start with the GS v 0 raster, got % x", payload[:min(len(payload), 4)])
 	}
-	feed := payload[len(payload)-4]
-	if feed != 0x00 {
-		t.Fatalf("expected default feed 0, got %d", feed)
+	// 96 dots are 12 bytes per row, with no padding, reset or feed around it
+	if width, height := int(payload[4])|int(payload[5])<<8, int(payload[6])|int(payload[7])<<8; len(payload) != 8+width*height || width != 12 {
+		t.Fatalf("expected a bare 96 dot wide raster, got %d bytes of %d x %d", len(payload), width, height)
 	}
 
-	custom, err := iconTemplateFunc("ActionFace", 64, 3)
+	custom, err := iconTemplateFunc(profile.Default(), "ActionFace", 64, 3)
 	if err != nil {
 		t.Fatalf("iconTemplateFunc custom error: %v", err)
 	}
-	customPayload := []byte(custom)
-	if customPayload[len(customPayload)-4] != 0x03 {
-		t.Fatalf("expected custom feed 3, got %d", customPayload[len(customPayload)-4])
+	if !bytes.HasSuffix([]byte(custom), []byte{0x1B, 0x64, 0x03}) {
+		t.Fatalf("expected custom feed of 3 lines, got % x", []byte(custom)[len(custom)-3:])
 	}
 }
@@ -0,0 +1,165 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/escpos"
+	"github.com/jonasclaes/go-thermal-printer/pkg/profile"
+)
+
+// buildImage renders the image helper:
+//
+//	{{ image .logo }}
+//	{{ image .cover 384 }}
+//	{{ image .cover "dither" "atkinson" "brightness" 0.1 "contrast" 0.2 "gamma" 1.4 }}
+//	{{ image .logo 128 "align" "left" "feed" 1 }}
+//
+// A leading number is the maximum width in dots, which is clamped to the
+// paper width of the printer. Only the raster is emitted: printer settings
+// are left alone and paper is fed only when "feed" lines are requested.
+func buildImage(printer profile.Profile, data any, args ...any) (string, error) {
+	var encoded string
+	switch v := data.(type) {
+	case string:
+		encoded = v
+	case []byte:
+		encoded = string(v)
+	default:
+		return "", fmt.Errorf("image expects string or []byte, got %T", data)
+	}
+
+	options := escpos.ImageOptions{
+		MaxWidthDots: printer.DotsPerLine,
+		BandHeight:   printer.RasterBandHeight,
+		Align:        escpos.AlignCenter,
+	}
+	feed := 0
+	if len(args)%2 != 0 {
+		if _, ok := args[0].(string); ok {
+			return "", fmt.Errorf("image expects an optional width followed by key/value option pairs")
+		}
+		width, err := toInt(args[0])
+		if err != nil {
+			return "", fmt.Errorf("image maxWidth: %w", err)
+		}
+		if width > 0 {
+			options.MaxWidthDots = min(width, printer.DotsPerLine)
+		}
+		args = args[1:]
+	}
+
+	for i := 0; i < len(args); i += 2 {
+		key, ok := args[i].(string)
+		if !ok {
+			return "", fmt.Errorf("image option keys must be strings, got %T", args[i])
+		}
+		value := args[i+1]
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "maxwidth", "width":
+			width, err := toInt(value)
+			if err != nil {
+				return "", fmt.Errorf("image maxWidth: %w", err)
+			}
+			if width < 0 {
+				return "", fmt.Errorf("image maxWidth must be >= 0; got %d", width)
+			}
+			if width > 0 {
+				options.MaxWidthDots = min(width, printer.DotsPerLine)
+			}
+
+		case "dither":
+			name, ok := value.(string)
+			if !ok {
+				return "", fmt.Errorf("image dither expects a mode name, got %T", value)
+			}
+			mode, err := escpos.ParseDitherMode(name)
+			if err != nil {
+				return "", fmt.Errorf("image: %w", err)
+			}
+			options.Dither = mode
+
+		case "brightness":
+			brightness, err := toFloat(value)
+			if err != nil {
+				return "", fmt.Errorf("image brightness: %w", err)
+			}
+			options.Brightness = brightness
+
+		case "contrast":
+			contrast, err := toFloat(value)
+			if err != nil {
+				return "", fmt.Errorf("image contrast: %w", err)
+			}
+			options.Contrast = contrast
+
+		case "gamma":
+			gamma, err := toFloat(value)
+			if err != nil {
+				return "", fmt.Errorf("image gamma: %w", err)
+			}
+			options.Gamma = gamma
+
+		case "align":
+			align, err := parseRasterAlign("image", value)
+			if err != nil {
+				return "", err
+			}
+			options.Align = align
+
+		case "feed":
+			lines, err := parseFeedLines("image", value)
+			if err != nil {
+				return "", err
+			}
+			feed = lines
+
+		default:
+			return "", fmt.Errorf("image: unknown option %q", key)
+		}
+	}
+
+	if err := options.Validate(); err != nil {
+		return "", fmt.Errorf("image: %w", err)
+	}
+	img, err := escpos.DecodeImage(encoded)
+	if err != nil {
+		return "", fmt.Errorf("image render failed: %w", err)
+	}
+	raster, err := escpos.RasterImage(img, options)
+	if err != nil {
+		return "", fmt.Errorf("image render failed: %w", err)
+	}
+	return string(appendFeed(raster, feed)), nil
+}
+
+func parseRasterAlign(helper string, value any) (escpos.Alignment, error) {
+	name, ok := value.(string)
+	if !ok {
+		return 0, fmt.Errorf("%s align expects left, center or right; got %T", helper, value)
+	}
+	align, err := escpos.ParseAlignment(name)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", helper, err)
+	}
+	return align, nil
+}
+
+func parseFeedLines(helper string, value any) (int, error) {
+	lines, err := toInt(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s feed: %w", helper, err)
+	}
+	if lines < 0 || lines > 255 {
+		return 0, fmt.Errorf("%s feed expects 0-255 lines; got %d", helper, lines)
+	}
+	return lines, nil
+}
+
+// appendFeed adds ESC d to print and feed the given number of lines.
+func appendFeed(data []byte, lines int) []byte {
+	if lines == 0 {
+		return data
+	}
+	return append(data, 0x1B, 0x64, byte(lines))
+}
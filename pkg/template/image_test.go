@@ -0,0 +1,134 @@
+package template
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/profile"
+)
+
+// grayPNG returns a base64 PNG of a uniform gray square.
+func grayPNG(t *testing.T, size int, value uint8) string {
+	t.Helper()
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	for i := range img.Pix {
+		img.Pix[i] = value
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+// rasterData returns the GS v 0 raster payload of rendered template output.
+func rasterData(t *testing.T, out []byte) []byte {
+	t.Helper()
+	start := bytes.Index(out, []byte{0x1D, 0x76, 0x30, 0x00})
+	if start < 0 {
+		t.Fatalf("expected a GS v 0 raster in % X", out)
+	}
+	header := out[start+4 : start+8]
+	size := (int(header[0]) | int(header[1])<<8) * (int(header[2]) | int(header[3])<<8)
+	return out[start+8 : start+8+size]
+}
+
+func TestImageTemplateFuncOptions(t *testing.T) {
+	data := map[string]any{"img": grayPNG(t, 64, 100)}
+
+	plain, err := RenderToBytes(`{{ image .img }}`, data)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	// 64 black dots centered on the 384 dot line
+	want := bytes.Repeat(append(append(make([]byte, 20), bytes.Repeat([]byte{0xFF}, 8)...), make([]byte, 20)...), 64)
+	if raster := rasterData(t, plain); !bytes.Equal(raster, want) {
+		t.Fatalf("expected a solid black threshold raster, got % X", raster)
+	}
+
+	dithered, err := RenderToBytes(`{{ image .img 64 "dither" "floyd-steinberg" }}`, data)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	raster := rasterData(t, dithered)
+	if len(raster) != 64*8 {
+		t.Fatalf("expected a 64x64 raster, got %d bytes", len(raster))
+	}
+	if bytes.Count(raster, []byte{0xFF}) == len(raster) {
+		t.Fatalf("expected Floyd-Steinberg to mix black and white dots")
+	}
+
+	bright, err := RenderToBytes(`{{ image .img "maxWidth" 64 "brightness" 0.5 "contrast" 0.2 "gamma" "1.5" }}`, data)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if raster := rasterData(t, bright); bytes.Count(raster, []byte{0x00}) != len(raster) {
+		t.Fatalf("expected brightening to blank the raster, got % X", raster)
+	}
+}
+
+func TestImageTemplateFuncErrors(t *testing.T) {
+	data := map[string]any{"img": grayPNG(t, 8, 0)}
+	cases := map[string]string{
+		`{{ image .img "dither" "sierra" }}`: "unsupported dither mode",
+		`{{ image .img "gamma" 20 }}`:        "gamma must be between 0 and 10",
+		`{{ image .img "brightness" "x" }}`:  "image brightness",
+		`{{ image .img "sharpen" 1 }}`:       "unknown option",
+		`{{ image .img "dither" }}`:          "key/value option pairs",
+	}
+	for tmpl, want := range cases {
+		_, err := RenderToBytes(tmpl, data)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", tmpl, want, err)
+		}
+	}
+}
+
+func TestImageTemplateFuncUsesProfileBandHeight(t *testing.T) {
+	printer := profile.Default()
+	printer.RasterBandHeight = 16
+
+	out, err := RenderToBytesWithOptions(`{{ image .img }}`, map[string]any{"img": grayPNG(t, 40, 0)}, RenderOptions{Profile: printer})
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if bands := bytes.Count(out, []byte{0x1D, 0x76, 0x30, 0x00}); bands != 3 {
+		t.Fatalf("expected 40 rows in 3 bands of up to 16 rows, got %d bands", bands)
+	}
+}
+
+func TestImageTemplateFuncLeavesPrinterStateAlone(t *testing.T) {
+	data := map[string]any{"img": grayPNG(t, 16, 0)}
+
+	out, err := RenderToBytes(`{{ image .img "align" "left" "feed" 2 }}`, data)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	start := bytes.Index(out, []byte{0x1D, 0x76, 0x30, 0x00})
+	end := start + 8 + 48*16
+	if start < 0 || !bytes.HasPrefix(out[end:], []byte{0x1B, 0x64, 0x02}) {
+		t.Fatalf("expected the raster followed by a 2 line feed, got % X", out)
+	}
+	if out[start+8] != 0xFF || out[start+10] != 0x00 {
+		t.Fatalf("expected a left aligned raster, got % X", out[start:end])
+	}
+	if bytes.Contains(out[end:], []byte{0x1B, 0x40}) || bytes.Contains(out[end:], []byte{0x1B, 0x74}) {
+		t.Fatalf("expected no reset or code page selection after the image, got % X", out[end:])
+	}
+
+	out, err = RenderToBytes(`{{ qr "hello" "border" 0 "align" "right" "feed" 1 }}`, nil)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	start = bytes.Index(out, []byte{0x1D, 0x76, 0x30, 0x00})
+	if start < 0 || out[start+8] != 0x00 || out[start+8+47] == 0x00 {
+		t.Fatalf("expected a right aligned QR raster, got % X", out[start:start+8+48])
+	}
+	if !bytes.Contains(out, []byte{0x1B, 0x64, 0x01}) {
+		t.Fatalf("expected a 1 line feed after the QR code")
+	}
+}
@@ -0,0 +1,440 @@
+package template
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/escpos"
+	"github.com/jonasclaes/go-thermal-printer/pkg/profile"
+)
+
+// textLayout follows the character font and width selected by the helpers,
+// so that columns, wrap, row and table know how many characters fit on a
+// line. Like textEncoder it relies on helpers running in document order.
+type textLayout struct {
+	printer profile.Profile
+	font    escpos.CharacterFont
+	scale   int
+}
+
+func newTextLayout(printer profile.Profile) *textLayout {
+	return &textLayout{printer: printer, scale: 1}
+}
+
+// reset restores font A at normal width, as ESC @ does.
+func (l *textLayout) reset() {
+	l.font = escpos.CharacterFontA
+	l.scale = 1
+}
+
+// columns returns the characters per line in font at the current width.
+func (l *textLayout) columns(font escpos.CharacterFont) int {
+	return max(1, l.printer.Columns(font)/l.scale)
+}
+
+// layoutCell is a cell of a row or a column of a table.
+type layoutCell struct {
+	value  any
+	key    string
+	header string
+	format string
+	width  int
+	weight int
+	align  escpos.Alignment
+	// alignSet tells an explicit left alignment from the default
+	alignSet bool
+	leader   rune
+	truncate bool
+}
+
+// text formats value for the cell.
+func (c *layoutCell) text(value any) string {
+	if value == nil {
+		return ""
+	}
+	// Cells are laid out as plain text, without the styles of other helpers
+	if text, ok := value.(receiptText); ok {
+		value = text.plainText()
+	}
+	if c.format != "" {
+		return fmt.Sprintf(c.format, value)
+	}
+	return fmt.Sprint(value)
+}
+
+// buildCell renders the cell and column helpers:
+//
+//	{{ cell .name "weight" 2 }}
+//	{{ cell .price "width" 8 "align" "right" "format" "%.2f" }}
+//	{{ column "qty" "header" "Qty" "width" 3 "align" "right" }}
+//
+// A cell holds a value for row; a column names the map key, struct field or
+// index a table takes from each item. Options: width (fixed characters),
+// weight (share of the remaining line), align, leader (a character that
+// fills the padding on the first line), wrap (false cuts long text instead of
+// continuing it on the next lines), format (a printf verb for the value) and
+// header (table columns only).
+func buildCell(helper string, value any, args ...any) (*layoutCell, error) {
+	if len(args)%2 != 0 {
+		return nil, fmt.Errorf("%s expects key/value option pairs", helper)
+	}
+
+	cell := &layoutCell{value: value}
+	if helper == "column" {
+		cell.value = nil
+		cell.key = fmt.Sprint(value)
+	}
+
+	for i := 0; i < len(args); i += 2 {
+		key, ok := args[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("%s option keys must be strings, got %T", helper, args[i])
+		}
+		value := args[i+1]
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "width":
+			width, err := toInt(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s width: %w", helper, err)
+			}
+			if width < 1 {
+				return nil, fmt.Errorf("%s width must be positive; got %d", helper, width)
+			}
+			cell.width = width
+
+		case "weight":
+			weight, err := toInt(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s weight: %w", helper, err)
+			}
+			if weight < 1 {
+				return nil, fmt.Errorf("%s weight must be positive; got %d", helper, weight)
+			}
+			cell.weight = weight
+
+		case "align":
+			align, err := parseRasterAlign(helper, value)
+			if err != nil {
+				return nil, err
+			}
+			cell.align = align
+			cell.alignSet = true
+
+		case "leader":
+			leader, ok := value.(string)
+			if !ok || utf8.RuneCountInString(leader) != 1 {
+				return nil, fmt.Errorf("%s leader expects a single character, got %v", helper, value)
+			}
+			cell.leader, _ = utf8.DecodeRuneInString(leader)
+
+		case "wrap":
+			wrap, err := toBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s wrap: %w", helper, err)
+			}
+			cell.truncate = !wrap
+
+		case "format":
+			format, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s format expects a string, got %T", helper, value)
+			}
+			cell.format = format
+
+		case "header":
+			cell.header = fmt.Sprint(value)
+
+		default:
+			return nil, fmt.Errorf("%s: unknown option %q", helper, key)
+		}
+	}
+	return cell, nil
+}
+
+// buildRow renders the row helper:
+//
+//	{{ row .name .price }}
+//	{{ row (cell .qty "width" 3) (cell .name "weight" 1) (cell .price "format" "%.2f") }}
+//
+// Arguments are values or cells. See layoutRows for how widths are chosen.
+func buildRow(layout *textLayout, encoder *textEncoder, args ...any) (receiptText, error) {
+	if len(args) == 0 {
+		return receiptText{}, fmt.Errorf("row expects at least one cell")
+	}
+	cells := make([]*layoutCell, len(args))
+	values := make([]string, len(args))
+	for i, arg := range args {
+		cell, ok := arg.(*layoutCell)
+		if !ok {
+			cell = &layoutCell{value: arg}
+		}
+		cells[i] = cell
+		values[i] = encoder.substitute(cell.text(cell.value))
+	}
+
+	lines, err := layoutRows(cells, [][]string{values}, layout.columns(layout.font))
+	if err != nil {
+		return receiptText{}, fmt.Errorf("row: %w", err)
+	}
+	return encoder.text(strings.Join(lines[0], "\n")), nil
+}
+
+// buildTable renders the table helper:
+//
+//	{{ table .items (column "name" "header" "Item") (column "price" "header" "Price" "format" "%.2f") }}
+//
+// items is a slice of maps, structs or slices; each column reads its key
+// from every item (a slice index for slices). A header row and a rule are
+// printed when any column has a header.
+func buildTable(layout *textLayout, encoder *textEncoder, items any, args ...any) (receiptText, error) {
+	if len(args) == 0 {
+		return receiptText{}, fmt.Errorf("table expects at least one column")
+	}
+	columns := make([]*layoutCell, len(args))
+	hasHeader := false
+	for i, arg := range args {
+		column, ok := arg.(*layoutCell)
+		if !ok {
+			column = &layoutCell{key: fmt.Sprint(arg)}
+		}
+		columns[i] = column
+		hasHeader = hasHeader || column.header != ""
+	}
+
+	list := reflect.ValueOf(items)
+	for list.Kind() == reflect.Pointer || list.Kind() == reflect.Interface {
+		list = list.Elem()
+	}
+	if items != nil && list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+		return receiptText{}, fmt.Errorf("table expects a list of items, got %T", items)
+	}
+
+	var rows [][]string
+	if hasHeader {
+		header := make([]string, len(columns))
+		for i, column := range columns {
+			header[i] = encoder.substitute(column.header)
+		}
+		rows = append(rows, header)
+	}
+	for i := 0; items != nil && i < list.Len(); i++ {
+		row := make([]string, len(columns))
+		for j, column := range columns {
+			value, err := itemField(list.Index(i), column.key)
+			if err != nil {
+				return receiptText{}, fmt.Errorf("table row %d: %w", i+1, err)
+			}
+			row[j] = encoder.substitute(column.text(value))
+		}
+		rows = append(rows, row)
+	}
+
+	width := layout.columns(layout.font)
+	rowLines, err := layoutRows(columns, rows, width)
+	if err != nil {
+		return receiptText{}, fmt.Errorf("table: %w", err)
+	}
+	var lines []string
+	for i, row := range rowLines {
+		lines = append(lines, row...)
+		if i == 0 && hasHeader {
+			lines = append(lines, strings.Repeat("-", width))
+		}
+	}
+	return encoder.text(strings.Join(lines, "\n")), nil
+}
+
+// itemField returns the value of key in a map, struct or slice item.
+func itemField(item reflect.Value, key string) (any, error) {
+	for item.Kind() == reflect.Pointer || item.Kind() == reflect.Interface {
+		if item.IsNil() {
+			return nil, nil
+		}
+		item = item.Elem()
+	}
+	switch item.Kind() {
+	case reflect.Map:
+		value := item.MapIndex(reflect.ValueOf(key))
+		if !value.IsValid() {
+			return nil, nil
+		}
+		return value.Interface(), nil
+	case reflect.Struct:
+		value := item.FieldByName(key)
+		if !value.IsValid() || !value.CanInterface() {
+			return nil, fmt.Errorf("no field %q", key)
+		}
+		return value.Interface(), nil
+	case reflect.Slice, reflect.Array:
+		index, err := strconv.Atoi(key)
+		if err != nil {
+			return nil, fmt.Errorf("column %q must be an index for list items", key)
+		}
+		if index < 0 || index >= item.Len() {
+			return nil, nil
+		}
+		return item.Index(index).Interface(), nil
+	default:
+		return nil, fmt.Errorf("unsupported item type %s", item.Type())
+	}
+}
+
+// layoutRows lays out rows of cell text on lines of the given number of
+// characters, with one space between cells. Cells with a width keep it;
+// cells with a weight share what is left in proportion; other cells are as
+// wide as their longest text, up to half the line, except that the first of
+// them takes the remaining space when no cell has a weight. The last of
+// several cells is right aligned unless told otherwise. Text that does not
+// fit wraps onto continuation lines. It returns the lines of every row.
+func layoutRows(cells []*layoutCell, rows [][]string, columns int) ([][]string, error) {
+	widths := make([]int, len(cells))
+	weights := make([]int, len(cells))
+	// Without weights the first cell of no fixed width takes the free space
+	flexible := -1
+	for i, cell := range cells {
+		if cell.weight > 0 {
+			flexible = -1
+			break
+		}
+		if flexible < 0 && cell.width == 0 {
+			flexible = i
+		}
+	}
+
+	used := len(cells) - 1
+	totalWeight := 0
+	for i, cell := range cells {
+		switch {
+		case cell.width > 0:
+			widths[i] = cell.width
+		case cell.weight > 0:
+			weights[i] = cell.weight
+		case i == flexible:
+			weights[i] = 1
+		default:
+			for _, row := range rows {
+				for _, line := range strings.Split(row[i], "\n") {
+					widths[i] = max(widths[i], utf8.RuneCountInString(line))
+				}
+			}
+			widths[i] = max(1, min(widths[i], columns/2))
+		}
+		used += widths[i]
+		totalWeight += weights[i]
+	}
+
+	remaining := columns - used
+	if remaining < 0 || totalWeight > 0 && remaining < 1 {
+		return nil, fmt.Errorf("cells need more than the %d characters of the line", columns)
+	}
+	last := -1
+	for i := range cells {
+		if weights[i] > 0 {
+			widths[i] = remaining * weights[i] / totalWeight
+			last = i
+		}
+	}
+	if last >= 0 {
+		// Rounding leftovers go to the last weighted cell
+		for i := range cells {
+			remaining -= widths[i] * min(weights[i], 1)
+		}
+		widths[last] += remaining
+	}
+	for i, width := range widths {
+		if width < 1 {
+			return nil, fmt.Errorf("cell %d has no room on the %d character line", i+1, columns)
+		}
+	}
+
+	lines := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		var rowLines []string
+		cellLines := make([][]string, len(cells))
+		height := 1
+		for i, cell := range cells {
+			cellLines[i] = wrapCell(row[i], widths[i], cell.truncate)
+			height = max(height, len(cellLines[i]))
+		}
+		for n := 0; n < height; n++ {
+			var line strings.Builder
+			for i, cell := range cells {
+				if i > 0 {
+					line.WriteByte(' ')
+				}
+				text := ""
+				if n < len(cellLines[i]) {
+					text = cellLines[i][n]
+				}
+				pad := ' '
+				if n == 0 && cell.leader != 0 {
+					pad = cell.leader
+				}
+				align := cell.align
+				if !cell.alignSet && len(cells) > 1 && i == len(cells)-1 {
+					align = escpos.AlignRight
+				}
+				line.WriteString(alignCell(text, widths[i], align, pad))
+			}
+			rowLines = append(rowLines, strings.TrimRight(line.String(), " "))
+		}
+		lines = append(lines, rowLines)
+	}
+	return lines, nil
+}
+
+// wrapCell breaks text into lines of at most width characters, splitting
+// words that are longer than the cell. With truncate only the start of the
+// first line is kept.
+func wrapCell(text string, width int, truncate bool) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		for _, line := range wrapLine(paragraph, width) {
+			for utf8.RuneCountInString(line) > width {
+				cut := runeOffset(line, width)
+				lines = append(lines, line[:cut])
+				line = line[cut:]
+			}
+			lines = append(lines, line)
+		}
+	}
+	if truncate && len(lines) > 1 {
+		lines = lines[:1]
+	}
+	return lines
+}
+
+// runeOffset returns the byte offset of the nth rune of s. Bytes that are
+// not valid UTF-8 count as one rune each and are kept as they are.
+func runeOffset(s string, n int) int {
+	count := 0
+	for i := range s {
+		if count == n {
+			return i
+		}
+		count++
+	}
+	return len(s)
+}
+
+// alignCell pads text to width with pad characters.
+func alignCell(text string, width int, align escpos.Alignment, pad rune) string {
+	padding := width - utf8.RuneCountInString(text)
+	if padding <= 0 {
+		return text
+	}
+	fill := func(n int) string {
+		return strings.Repeat(string(pad), n)
+	}
+	switch align {
+	case escpos.AlignRight:
+		return fill(padding) + text
+	case escpos.AlignCenter:
+		return fill(padding/2) + text + fill(padding-padding/2)
+	default:
+		return text + fill(padding)
+	}
+}
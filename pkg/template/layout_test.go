@@ -0,0 +1,139 @@
+package template
+
+import (
+	"strings"
+	"testing"
+)
+
+// renderBody renders tmpl with the default profile and returns the output
+// between the initialization and the final feed.
+func renderBody(t *testing.T, tmpl string, data any) string {
+	t.Helper()
+	out, err := RenderToBytesWithOptions(tmpl, data, RenderOptions{})
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	bare, err := RenderToBytesWithOptions("", nil, RenderOptions{})
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	// An empty render is ESC @ and ESC t n followed by the final feed and cut
+	const prefix = 5
+	return string(out[prefix : len(out)-len(bare)+prefix])
+}
+
+func TestRowAlignsLastCellRight(t *testing.T) {
+	got := renderBody(t, `{{ row "Total" "12.50" }}`, nil)
+	want := "Total" + strings.Repeat(" ", 32-5-5) + "12.50"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	got = renderBody(t, `{{ row (cell "Coffee" "leader" ".") "3.20" }}`, nil)
+	want = "Coffee" + strings.Repeat(".", 32-6-5) + " 3.20"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestRowWidthsAndWrapping(t *testing.T) {
+	got := renderBody(t, `{{ row (cell 2 "width" 3 "align" "right") (cell "Large cappuccino with oat milk" "weight" 1) (cell 9.5 "format" "%.2f") }}`, nil)
+	want := "  2 Large cappuccino with   9.50\n    oat milk"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	got = renderBody(t, `{{ row (cell "Large cappuccino with oat milk" "wrap" false) (cell "center" "width" 10 "align" "center") }}`, nil)
+	want = "Large cappuccino with   center"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	got = renderBody(t, `{{ row (cell "a" "weight" 1) (cell "b" "weight" 3 "align" "left") }}`, nil)
+	want = "a" + strings.Repeat(" ", 7) + "b"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestRowFollowsCharacterWidth(t *testing.T) {
+	got := renderBody(t, `{{ fontOptions "width" 2 }}{{ row "Total" "9.99" }}{{ fontOptions "width" 1 }}`, nil)
+	want := "\x1D\x21\x10Total" + strings.Repeat(" ", 16-5-4) + "9.99\x1D\x21\x00"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	got = renderBody(t, `{{ fontOptions "font" "B" }}{{ columns }} {{ row "a" "b" }}{{ reset }}{{ columns }}`, nil)
+	if !strings.HasPrefix(got, "\x1B\x4D\x0142 a"+strings.Repeat(" ", 40)+"b") || !strings.HasSuffix(got, "32") {
+		t.Fatalf("expected font B columns before the reset, got %q", got)
+	}
+}
+
+func TestTable(t *testing.T) {
+	data := map[string]any{
+		"items": []map[string]any{
+			{"name": "Espresso", "qty": 2, "price": 5.0},
+			{"name": "Blueberry muffin", "qty": 1, "price": 3.25},
+		},
+	}
+	got := renderBody(t, `{{ table .items (column "qty" "width" 3 "align" "right" "header" "Qty") (column "name" "header" "Item") (column "price" "format" "%.2f" "header" "Price") }}`, data)
+	want := strings.Join([]string{
+		"Qty Item" + strings.Repeat(" ", 19) + "Price",
+		strings.Repeat("-", 32),
+		"  2 Espresso" + strings.Repeat(" ", 16) + "5.00",
+		"  1 Blueberry muffin" + strings.Repeat(" ", 8) + "3.25",
+	}, "\n")
+	if got != want {
+		t.Fatalf("expected\n%s\ngot\n%s", want, got)
+	}
+
+	type line struct {
+		Name  string
+		Total float64
+	}
+	got = renderBody(t, `{{ table .lines (column "Name") (column "Total" "format" "%.2f") }}`, map[string]any{"lines": []line{{"Tea", 2}}})
+	if want := "Tea" + strings.Repeat(" ", 25) + "2.00"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	got = renderBody(t, `{{ table .lines 0 1 }}`, map[string]any{"lines": [][]string{{"Tea", "2.00"}}})
+	if want := "Tea" + strings.Repeat(" ", 25) + "2.00"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestRowEncodesText(t *testing.T) {
+	got := renderBody(t, `{{ row "Café" "1™" }}`, nil)
+	want := "Caf\x82" + strings.Repeat(" ", 32-4-3) + "1TM"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	// Raw code page bytes in the template pass through untouched
+	got = renderBody(t, `{{ row "Tax" (printf "\xD5%.2f" 1.5) }}`, nil)
+	want = "Tax" + strings.Repeat(" ", 32-3-5) + "\xD51.50"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestLayoutErrors(t *testing.T) {
+	cases := map[string]string{
+		`{{ row }}`:                              "row expects at least one cell",
+		`{{ row (cell "a" "width" 40) }}`:        "more than the 32 characters",
+		`{{ row (cell "a" "width" 0) }}`:         "cell width must be positive",
+		`{{ row (cell "a" "leader" "..") }}`:     "single character",
+		`{{ row (cell "a" "color" "red") }}`:     `cell: unknown option "color"`,
+		`{{ row (cell "a" "width") }}`:           "key/value option pairs",
+		`{{ table "x" (column "a") }}`:           "table expects a list of items",
+		`{{ table .items (column "Missing") }}`:  `no field "Missing"`,
+		`{{ table .items }}`:                     "table expects at least one column",
+		`{{ row (cell "a" "align" "justify") }}`: "cell",
+	}
+	data := map[string]any{"items": []struct{ Name string }{{"Tea"}}}
+	for tmpl, want := range cases {
+		if _, err := RenderToBytesWithOptions(tmpl, data, RenderOptions{}); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%s: expected %q error, got %v", tmpl, want, err)
+		}
+	}
+}
@@ -0,0 +1,400 @@
+package template
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/common"
+	"github.com/jonasclaes/go-thermal-printer/pkg/escpos"
+)
+
+// Limits bound what a single render may do, so a template cannot hang the
+// print worker or print metres of paper. Zero fields are not limited.
+type Limits struct {
+	// Timeout stops the render when it takes longer
+	Timeout time.Duration
+	// MaxOutputBytes is the largest ESC/POS output a template may produce
+	MaxOutputBytes int
+	// MaxImages is how many image, textImage, icon, qr, pdf417 and barcode
+	// helper calls a template may make
+	MaxImages int
+	// MaxFeedLines bounds the estimated paper length, in lines of the default
+	// line spacing
+	MaxFeedLines int
+}
+
+// maxRangeLiteral is the largest integer a template may range over
+// literally, such as {{range 1000}}.
+const maxRangeLiteral = 1_000_000
+
+// renderCheckFunc is the helper guardLoops calls at the start of every range
+// iteration and template body. It is not meant to be used in templates.
+const renderCheckFunc = "renderCheck"
+
+// guardLoops rejects ranges over integer literals above maxRangeLiteral and
+// makes every range iteration and template invocation call renderCheckFunc.
+// text/template only hands control back to the render on output and helper
+// calls, so without it a loop that does neither, or a template that invokes
+// itself, could not be stopped and would keep running after the render gave
+// up. It must be called once, right after parsing.
+func guardLoops(tmpl *template.Template) error {
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil || t.Root == nil {
+			continue
+		}
+		if err := guardList(t.Root); err != nil {
+			return fmt.Errorf("template %q: %w", t.Name(), err)
+		}
+		// Empty trees do not replace other definitions; keep them empty
+		if parse.IsEmptyTree(t.Root) {
+			continue
+		}
+		t.Root.Nodes = append([]parse.Node{renderCheckNode(t.Root.Position())}, t.Root.Nodes...)
+	}
+	return nil
+}
+
+func guardList(list *parse.ListNode) error {
+	if list == nil {
+		return nil
+	}
+	for _, node := range list.Nodes {
+		switch n := node.(type) {
+		case *parse.IfNode:
+			if err := guardBranches(n.List, n.ElseList); err != nil {
+				return err
+			}
+		case *parse.WithNode:
+			if err := guardBranches(n.List, n.ElseList); err != nil {
+				return err
+			}
+		case *parse.RangeNode:
+			if count, ok := rangeLiteral(n.Pipe); ok && count > maxRangeLiteral {
+				return fmt.Errorf("range over %d is more than the %d iterations allowed", count, maxRangeLiteral)
+			}
+			if err := guardBranches(n.List, n.ElseList); err != nil {
+				return err
+			}
+			n.List.Nodes = append([]parse.Node{renderCheckNode(n.Position())}, n.List.Nodes...)
+		}
+	}
+	return nil
+}
+
+func guardBranches(list, elseList *parse.ListNode) error {
+	if err := guardList(list); err != nil {
+		return err
+	}
+	return guardList(elseList)
+}
+
+// rangeLiteral returns the integer a range pipeline consists of, if it is a
+// single integer constant.
+func rangeLiteral(pipe *parse.PipeNode) (int64, bool) {
+	if pipe == nil || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return 0, false
+	}
+	number, ok := pipe.Cmds[0].Args[0].(*parse.NumberNode)
+	if !ok || !number.IsInt {
+		return 0, false
+	}
+	return number.Int64, true
+}
+
+// renderCheckNode returns the action {{renderCheck}}.
+func renderCheckNode(pos parse.Pos) parse.Node {
+	return &parse.ActionNode{
+		NodeType: parse.NodeAction,
+		Pos:      pos,
+		Pipe: &parse.PipeNode{
+			NodeType: parse.NodePipe,
+			Pos:      pos,
+			Cmds: []*parse.CommandNode{{
+				NodeType: parse.NodeCommand,
+				Pos:      pos,
+				Args:     []parse.Node{parse.NewIdentifier(renderCheckFunc).SetPos(pos)},
+			}},
+		},
+	}
+}
+
+// defaultLineSpacing is the ESC 2 line spacing in dots, the unit of
+// Limits.MaxFeedLines.
+const defaultLineSpacing = 30
+
+// renderBudget enforces Limits during one render. It is the writer the
+// template executes into, so output and paper are checked as they are
+// produced. A nil budget has no limits.
+type renderBudget struct {
+	ctx    context.Context
+	limits Limits
+	out    io.Writer
+
+	written int
+	images  int
+	paper   paperMeter
+}
+
+func newRenderBudget(ctx context.Context, limits Limits, out io.Writer) *renderBudget {
+	return &renderBudget{
+		ctx:    ctx,
+		limits: limits,
+		out:    out,
+		paper:  paperMeter{lineSpacing: defaultLineSpacing, barcodeHeight: escpos.DefaultBarcodeHeight},
+	}
+}
+
+func (b *renderBudget) Write(p []byte) (int, error) {
+	if err := b.err(); err != nil {
+		return 0, err
+	}
+	if b.limits.MaxOutputBytes > 0 && b.written+len(p) > b.limits.MaxOutputBytes {
+		return 0, &common.RenderLimitError{Limit: "output size", Max: strconv.Itoa(b.limits.MaxOutputBytes) + " bytes"}
+	}
+	b.paper.write(p)
+	if b.limits.MaxFeedLines > 0 && b.paper.dots > b.limits.MaxFeedLines*defaultLineSpacing {
+		return 0, &common.RenderLimitError{Limit: "paper length", Max: strconv.Itoa(b.limits.MaxFeedLines) + " lines"}
+	}
+	b.written += len(p)
+	return b.out.Write(p)
+}
+
+// image counts a call of an image-like helper.
+func (b *renderBudget) image() error {
+	if b == nil {
+		return nil
+	}
+	if err := b.err(); err != nil {
+		return err
+	}
+	b.images++
+	if b.limits.MaxImages > 0 && b.images > b.limits.MaxImages {
+		return &common.RenderLimitError{Limit: "image count", Max: strconv.Itoa(b.limits.MaxImages)}
+	}
+	return nil
+}
+
+// check is the renderCheckFunc helper: it stops the render once it must
+// stop early.
+func (b *renderBudget) check() (string, error) {
+	if b == nil {
+		return "", nil
+	}
+	return "", b.err()
+}
+
+// err reports why the render must stop early, if it must.
+func (b *renderBudget) err() error {
+	err := b.ctx.Err()
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, context.DeadlineExceeded) && b.limits.Timeout > 0:
+		// The caller's own deadline may be the one that expired; either way
+		// the render took too long
+		return &common.RenderLimitError{Limit: "render time", Max: b.limits.Timeout.String()}
+	}
+	return fmt.Errorf("render stopped: %w", err)
+}
+
+// paperMeter estimates how far an ESC/POS stream feeds the paper: line
+// feeds at the current line spacing, ESC d and ESC J feeds, GS v 0 raster
+// images and GS k barcodes. Parameters of other commands are skipped so they
+// are not mistaken for line feeds. Commands may be split across writes.
+type paperMeter struct {
+	dots          int
+	lineSpacing   int
+	barcodeHeight int
+
+	// skip is the data of the last command that is still to come
+	skip int
+	// partial is the start of a command whose header is incomplete
+	partial []byte
+}
+
+func (m *paperMeter) write(p []byte) {
+	if m.skip > 0 {
+		n := min(m.skip, len(p))
+		m.skip -= n
+		p = p[n:]
+	}
+	if len(m.partial) > 0 {
+		p = append(m.partial, p...)
+		m.partial = nil
+	}
+
+	for len(p) > 0 {
+		n := m.command(p)
+		if n == 0 {
+			m.partial = append([]byte(nil), p...)
+			return
+		}
+		if n > len(p) {
+			m.skip = n - len(p)
+			return
+		}
+		p = p[n:]
+	}
+}
+
+// command measures the command at the start of b and returns its length,
+// which may exceed len(b), or 0 when its header is incomplete.
+func (m *paperMeter) command(b []byte) int {
+	switch b[0] {
+	case 0x0A, 0x0C:
+		m.dots += m.lineSpacing
+		return 1
+	case 0x1B:
+		return m.escCommand(b)
+	case 0x1D:
+		return m.gsCommand(b)
+	case 0x10:
+		// DLE EOT n, DLE ENQ n, DLE DC4 fn m t
+		if len(b) < 2 {
+			return 0
+		}
+		switch b[1] {
+		case 0x04, 0x05:
+			return 3
+		case 0x14:
+			return 5
+		}
+		return 1
+	case 0x1C:
+		if len(b) < 2 {
+			return 0
+		}
+		switch b[1] {
+		case 'p':
+			return 4
+		case 'C', '!', '-', 'W':
+			return 3
+		}
+		return 2
+	}
+	return 1
+}
+
+func (m *paperMeter) escCommand(b []byte) int {
+	if len(b) < 2 {
+		return 0
+	}
+	switch b[1] {
+	case '@', '2':
+		m.lineSpacing = defaultLineSpacing
+		return 2
+	case 'i', 'm', 'L', 'S':
+		return 2
+	case 'p':
+		return 5
+	case 'W':
+		return 10
+	case 'B', '$', '\\', 'c':
+		return 4
+	case 'D':
+		for i := 2; i < len(b); i++ {
+			if b[i] == 0 {
+				return i + 1
+			}
+		}
+		return 0
+	case '*':
+		// ESC * m nL nH d1...dk: one line of 8 or 24 dots
+		if len(b) < 5 {
+			return 0
+		}
+		columns := int(b[3]) | int(b[4])<<8
+		if b[2] >= 32 {
+			columns *= 3
+		}
+		return 5 + columns
+	}
+
+	if len(b) < 3 {
+		return 0
+	}
+	switch b[1] {
+	case '3':
+		m.lineSpacing = int(b[2])
+	case 'd':
+		m.dots += int(b[2]) * m.lineSpacing
+	case 'J':
+		m.dots += int(b[2])
+	}
+	return 3
+}
+
+func (m *paperMeter) gsCommand(b []byte) int {
+	if len(b) < 2 {
+		return 0
+	}
+	switch b[1] {
+	case 'v':
+		// GS v 0 m xL xH yL yH d1...dk
+		if len(b) < 8 {
+			return 0
+		}
+		width := int(b[4]) | int(b[5])<<8
+		height := int(b[6]) | int(b[7])<<8
+		m.dots += height
+		return 8 + width*height
+	case '(':
+		// GS ( fn pL pH d1...dk
+		if len(b) < 5 {
+			return 0
+		}
+		return 5 + (int(b[3]) | int(b[4])<<8)
+	case '8':
+		// GS 8 L p1 p2 p3 p4 d1...dk
+		if len(b) < 7 {
+			return 0
+		}
+		return 7 + (int(b[3]) | int(b[4])<<8 | int(b[5])<<16 | int(b[6])<<24)
+	case 'k':
+		// GS k m d1...dk NUL (m <= 6) or GS k m n d1...dn
+		size := 0
+		switch {
+		case len(b) < 3:
+		case b[2] <= 6:
+			if end := bytes.IndexByte(b[3:], 0); end >= 0 {
+				size = 4 + end
+			}
+		case len(b) >= 4:
+			size = 4 + int(b[3])
+		}
+		if size > 0 {
+			m.dots += m.barcodeHeight
+		}
+		return size
+	case 'L', 'W', 'P', '$', '\\':
+		return 4
+	}
+
+	if len(b) < 3 {
+		return 0
+	}
+	switch b[1] {
+	case 'h':
+		if b[2] > 0 {
+			m.barcodeHeight = int(b[2])
+		}
+	case 'V':
+		switch b[2] {
+		case 65, 66, 97, 98, 103, 104:
+			// Feed n dots, then cut
+			if len(b) < 4 {
+				return 0
+			}
+			m.dots += int(b[3])
+			return 4
+		}
+	}
+	return 3
+}
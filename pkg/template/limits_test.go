@@ -0,0 +1,145 @@
+package template
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/common"
+)
+
+func renderWithLimits(tmpl string, limits Limits) ([]byte, error) {
+	return RenderToBytesWithOptions(tmpl, nil, RenderOptions{Limits: limits})
+}
+
+func expectLimit(t *testing.T, err error, limit string) {
+	t.Helper()
+	var limitErr *common.RenderLimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != limit {
+		t.Fatalf("expected the %s limit to be exceeded, got %v", limit, err)
+	}
+	if limitErr.HttpStatusCode() != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status 422, got %d", limitErr.HttpStatusCode())
+	}
+}
+
+func TestRenderLimitsOutputSize(t *testing.T) {
+	_, err := renderWithLimits(`{{ range 1000000 }}0123456789{{ end }}`, Limits{MaxOutputBytes: 1000})
+	expectLimit(t, err, "output size")
+	if !strings.Contains(err.Error(), "output size limit of 1000 bytes") {
+		t.Fatalf("expected the limit in the message, got %v", err)
+	}
+
+	if _, err := renderWithLimits(`{{ range 10 }}0123456789{{ end }}`, Limits{MaxOutputBytes: 1000}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRenderLimitsImages(t *testing.T) {
+	_, err := renderWithLimits(`{{ range 5 }}{{ qr "https://example.com" }}{{ end }}`, Limits{MaxImages: 3})
+	expectLimit(t, err, "image count")
+
+	if _, err := renderWithLimits(`{{ qr "a" }}{{ icon "ActionFace" }}{{ qr "b" }}`, Limits{MaxImages: 3}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRenderLimitsPaperLength(t *testing.T) {
+	cases := []string{
+		`{{ range 200 }}line
+{{ end }}`,
+		`{{ feed 150 }}`,
+		`{{ lineSpacing 60 }}{{ range 60 }}
+{{ end }}`,
+	}
+	for _, tmpl := range cases {
+		_, err := renderWithLimits(tmpl, Limits{MaxFeedLines: 100})
+		expectLimit(t, err, "paper length")
+	}
+
+	// The final feed and cut are not part of the template
+	if _, err := renderWithLimits(`{{ range 90 }}line
+{{ end }}`, Limits{MaxFeedLines: 100}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRenderLimitsTime(t *testing.T) {
+	start := time.Now()
+	_, err := renderWithLimits(`{{ range 1000 }}{{ range 1000000 }}{{ bold "x" }}{{ end }}{{ end }}`, Limits{Timeout: 50 * time.Millisecond})
+	expectLimit(t, err, "render time")
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected the render to stop soon after the timeout, took %v", elapsed)
+	}
+
+	// Cancelling the caller's context is not a limit
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tmpl, err := NewTemplate(`{{ range 1000 }}{{ range 1000000 }}x{{ end }}{{ end }}`)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	var limitErr *common.RenderLimitError
+	if _, err := NewRenderer().RenderContext(ctx, tmpl, nil, RenderOptions{}); !errors.Is(err, context.Canceled) || errors.As(err, &limitErr) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestRenderLimitsStopLoopsWithoutOutput(t *testing.T) {
+	cases := map[string]any{
+		`{{ range .n }}{{ end }}`:                                  map[string]any{"n": 1_000_000_000_000},
+		`{{ range 1000000 }}{{ range 1000000 }}{{ end }}{{ end }}`: nil,
+		`{{ define "loop" }}{{ template "loop" . }}{{ template "loop" . }}{{ end }}{{ template "loop" . }}`: nil,
+	}
+	before := runtime.NumGoroutine()
+	for source, data := range cases {
+		tmpl, err := NewTemplate(source)
+		if err != nil {
+			t.Fatalf("parse failed: %v", err)
+		}
+		_, err = NewRenderer().RenderContext(context.Background(), tmpl, data, RenderOptions{Limits: Limits{Timeout: 20 * time.Millisecond}})
+		expectLimit(t, err, "render time")
+	}
+
+	// The abandoned renders stop at their next iteration
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the renders to stop, %d goroutines are left of %d", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestNewTemplateRejectsHugeRangeLiterals(t *testing.T) {
+	for _, source := range []string{
+		`{{ range 1000000000000 }}{{ end }}`,
+		`{{ define "x" }}{{ if true }}{{ range $i := 2000000 }}{{ end }}{{ end }}{{ end }}`,
+	} {
+		if _, err := NewTemplate(source); err == nil || !strings.Contains(err.Error(), "iterations allowed") {
+			t.Fatalf("expected %s to be rejected, got %v", source, err)
+		}
+	}
+	if _, err := NewTemplate(`{{ range 1000000 }}{{ end }}`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestPaperMeter(t *testing.T) {
+	// Raster data and command parameters are not line feeds
+	raster := []byte{0x1D, 0x76, 0x30, 0x00, 0x02, 0x00, 0x0A, 0x00}
+	raster = append(raster, []byte(strings.Repeat("\n", 20))...)
+	meter := paperMeter{lineSpacing: defaultLineSpacing}
+	meter.write([]byte("\x1B\x33\x0Aa\n"))
+	meter.write(raster[:5])
+	meter.write(raster[5:12])
+	meter.write(raster[12:])
+	meter.write([]byte("\x1D\x56\x41\x0A"))
+	if want := 10 + 10 + 10; meter.dots != want {
+		t.Fatalf("expected %d dots, got %d", want, meter.dots)
+	}
+}
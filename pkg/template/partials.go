@@ -0,0 +1,155 @@
+package template
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// PartialLookup returns the source of the named template in a template
+// library. found is false when the library has no such template.
+type PartialLookup func(name string) (content string, found bool, err error)
+
+// NewTemplateWithPartials parses the template name and every template it
+// invokes with {{template}} that it does not define itself, looked up by name
+// with lookup, recursively. Library templates are parsed before the ones
+// that use them, so a template that invokes a layout can override the
+// layout's {{block}} defaults with {{define}}:
+//
+//	base:    {{ template "header" . }}{{ block "body" . }}{{ end }}{{ cut }}
+//	receipt: {{ template "base" . }}{{ define "body" }}Total: {{ .total }}{{ end }}
+//
+// Library templates that invoke each other in a cycle are rejected.
+func NewTemplateWithPartials(name, content string, lookup PartialLookup) (*Template, error) {
+	loader := &partialLoader{
+		lookup:  lookup,
+		parsed:  make(map[string]*template.Template),
+		missing: make(map[string]string),
+	}
+	if err := loader.load(name, content); err != nil {
+		return nil, err
+	}
+
+	// Dependencies come first in loader.order and the named template last,
+	// so later definitions replace the defaults of earlier blocks
+	tmpl := template.New(name).Funcs(getTemplateFuncs(RenderOptions{}, nil))
+	for _, file := range loader.order {
+		for _, t := range loader.parsed[file].Templates() {
+			if t.Tree == nil {
+				continue
+			}
+			if _, err := tmpl.AddParseTree(t.Name(), t.Tree); err != nil {
+				return nil, fmt.Errorf("failed to parse template: %w", err)
+			}
+		}
+	}
+
+	for _, ref := range slices.Sorted(maps.Keys(loader.missing)) {
+		if tmpl.Lookup(ref) == nil {
+			return nil, fmt.Errorf("failed to parse template: template %q invokes %q, which it does not define and the template library does not have", loader.missing[ref], ref)
+		}
+	}
+
+	partials := slices.DeleteFunc(slices.Clone(loader.order), func(file string) bool {
+		return file == name
+	})
+	return &Template{tmpl: tmpl, partials: partials}, nil
+}
+
+// Partials returns the names of the library templates the template uses.
+func (t *Template) Partials() []string {
+	return slices.Clone(t.partials)
+}
+
+// partialLoader parses a template and its library dependencies depth first.
+type partialLoader struct {
+	lookup PartialLookup
+	parsed map[string]*template.Template
+	// order lists the parsed templates, each after its dependencies
+	order []string
+	// stack holds the templates being loaded, to detect cycles
+	stack []string
+	// missing maps names the library does not have to the template invoking
+	// them; another template may still define them
+	missing map[string]string
+}
+
+func (l *partialLoader) load(name, content string) error {
+	tmpl, err := template.New(name).Funcs(getTemplateFuncs(RenderOptions{}, nil)).Parse(content)
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+	if err := guardLoops(tmpl); err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	l.stack = append(l.stack, name)
+	for _, ref := range invokedTemplates(tmpl) {
+		if i := slices.Index(l.stack, ref); i >= 0 {
+			cycle := append(slices.Clone(l.stack[i:]), ref)
+			return fmt.Errorf("failed to parse template: templates invoke each other in a cycle: %s", strings.Join(cycle, " -> "))
+		}
+		if _, ok := l.parsed[ref]; ok {
+			continue
+		}
+		partial, found, err := l.lookup(ref)
+		if err != nil {
+			return fmt.Errorf("failed to load template %q invoked by %q: %w", ref, name, err)
+		}
+		if !found {
+			if _, ok := l.missing[ref]; !ok {
+				l.missing[ref] = name
+			}
+			continue
+		}
+		if err := l.load(ref, partial); err != nil {
+			return err
+		}
+	}
+	l.stack = l.stack[:len(l.stack)-1]
+
+	l.parsed[name] = tmpl
+	l.order = append(l.order, name)
+	return nil
+}
+
+// invokedTemplates returns the sorted names tmpl invokes with {{template}}
+// without defining them.
+func invokedTemplates(tmpl *template.Template) []string {
+	var names []string
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.IfNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			if tmpl.Lookup(n.Name) == nil && !slices.Contains(names, n.Name) {
+				names = append(names, n.Name)
+			}
+		}
+	}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			walk(t.Tree.Root)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
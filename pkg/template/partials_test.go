@@ -0,0 +1,101 @@
+package template
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// library returns a PartialLookup over templates, recording the lookups.
+func library(templates map[string]string, lookups *[]string) PartialLookup {
+	return func(name string) (string, bool, error) {
+		if lookups != nil {
+			*lookups = append(*lookups, name)
+		}
+		content, ok := templates[name]
+		return content, ok, nil
+	}
+}
+
+func renderPartials(t *testing.T, tmpl *Template, data any) string {
+	t.Helper()
+	out, err := NewRenderer().RenderWithOptions(tmpl, data, RenderOptions{})
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	return string(out)
+}
+
+func TestTemplateWithPartialsExtendsLayout(t *testing.T) {
+	templates := map[string]string{
+		"layout": `[{{ template "header" . }}|{{ block "body" . }}default body{{ end }}|{{ block "footer" . }}footer{{ end }}]`,
+		"header": `Store {{ .store }}`,
+	}
+	var lookups []string
+	tmpl, err := NewTemplateWithPartials("receipt", `{{ template "layout" . }}{{ define "body" }}Total {{ .total }}{{ end }}`, library(templates, &lookups))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	out := renderPartials(t, tmpl, map[string]any{"store": "Corner", "total": 9})
+	if !strings.Contains(out, "[Store Corner|Total 9|footer]") {
+		t.Fatalf("expected the layout with the overridden body, got %q", out)
+	}
+	if partials := tmpl.Partials(); len(partials) != 2 || partials[0] != "header" || partials[1] != "layout" {
+		t.Fatalf("expected header and layout as partials, got %v", partials)
+	}
+	// body is defined by the receipt, so the library is not asked for it
+	for _, name := range lookups {
+		if name == "body" {
+			t.Fatalf("did not expect a lookup of body, got %v", lookups)
+		}
+	}
+
+	// The layout on its own prints its defaults
+	layout, err := NewTemplateWithPartials("layout", templates["layout"], library(templates, nil))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if out := renderPartials(t, layout, map[string]any{"store": "Corner"}); !strings.Contains(out, "[Store Corner|default body|footer]") {
+		t.Fatalf("expected the block defaults, got %q", out)
+	}
+}
+
+func TestTemplateWithPartialsErrors(t *testing.T) {
+	templates := map[string]string{
+		"a":      `{{ template "b" . }}`,
+		"b":      `{{ template "c" . }}`,
+		"c":      `{{ template "a" . }}`,
+		"broken": `{{ .x `,
+		"uses":   `{{ template "nowhere" . }}`,
+	}
+	cases := map[string]string{
+		`{{ template "a" . }}`:                         "cycle: a -> b -> c -> a",
+		`{{ template "broken" . }}`:                    "template: broken:1",
+		`{{ template "uses" . }}`:                      `template "uses" invokes "nowhere"`,
+		`{{ if .x }}{{ template "missing" }}{{ end }}`: `template "main" invokes "missing"`,
+	}
+	for content, want := range cases {
+		if _, err := NewTemplateWithPartials("main", content, library(templates, nil)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%s: expected %q error, got %v", content, want, err)
+		}
+	}
+
+	failing := func(name string) (string, bool, error) {
+		return "", false, errors.New("disk on fire")
+	}
+	if _, err := NewTemplateWithPartials("main", `{{ template "a" }}`, failing); err == nil || !strings.Contains(err.Error(), `"a" invoked by "main": disk on fire`) {
+		t.Fatalf("expected the lookup error, got %v", err)
+	}
+}
+
+func TestTemplateWithPartialsAllowsRecursionWithinATemplate(t *testing.T) {
+	content := `{{ define "count" }}{{ if . }}{{ len . }}{{ template "count" (slice . 1) }}{{ end }}{{ end }}{{ template "count" .items }}`
+	tmpl, err := NewTemplateWithPartials("main", content, library(nil, nil))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if out := renderPartials(t, tmpl, map[string]any{"items": []int{1, 2, 3}}); !strings.Contains(out, "321") {
+		t.Fatalf("expected the recursive template to run, got %q", out)
+	}
+}
@@ -0,0 +1,185 @@
+package template
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/escpos"
+	"github.com/jonasclaes/go-thermal-printer/pkg/profile"
+)
+
+func renderForProfile(t *testing.T, name, content string, mutate ...func(*profile.Profile)) []byte {
+	t.Helper()
+
+	p, ok := profile.Builtin(name)
+	if !ok {
+		t.Fatalf("unknown profile %q", name)
+	}
+	for _, m := range mutate {
+		m(&p)
+	}
+
+	out, err := RenderToBytesWithOptions(content, nil, RenderOptions{Profile: p})
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	return out
+}
+
+func TestWrapAndColumnsFollowProfile(t *testing.T) {
+	out := renderForProfile(t, "tm-t88", `{{ columns }}/{{ columns "B" }}`)
+	if !bytes.Contains(out, []byte("42/56")) {
+		t.Fatalf("expected TM-T88 columns in %q", out)
+	}
+
+	// 9 words of 4 letters fill 44 columns, so TM-T88 lines hold 8 of them
+	out = renderForProfile(t, "tm-t88", `{{ wrap "`+strings.Repeat("word ", 9)+`" }}`)
+	if !bytes.Contains(out, []byte(strings.Repeat("word ", 7)+"word\nword")) {
+		t.Fatalf("expected wrapping at 42 columns, got %q", out)
+	}
+
+	out, err := RenderToBytesWithOptions(`{{ wrap "`+strings.Repeat("word ", 9)+`" }}`, nil, RenderOptions{})
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if !bytes.Contains(out, []byte(strings.Repeat("word ", 5)+"word\nword")) {
+		t.Fatalf("expected the default profile to wrap at 32 columns, got %q", out)
+	}
+}
+
+func TestCutFollowsProfileCutter(t *testing.T) {
+	out := renderForProfile(t, "tm-t88", `{{ cut "full" }}`)
+	if !bytes.Contains(out, []byte{0x1D, 0x56, 0x01}) {
+		t.Fatalf("expected a partial cut on a partial-only cutter, got %q", out)
+	}
+
+	out = renderForProfile(t, "pos-5890", `{{ cut }}`)
+	if bytes.Contains(out, []byte{0x1D, 0x56}) || bytes.Contains(out, []byte{0x1B, 0x6D}) {
+		t.Fatalf("expected no cut commands without a cutter, got %q", out)
+	}
+}
+
+func TestFinalCutFollowsProfileCutter(t *testing.T) {
+	feed := []byte{0x1B, 0x64, 9}
+	cases := map[string][]byte{
+		"tm-t20":   {0x1D, 0x56, 0x01},
+		"tm-m30":   {0x1D, 0x56, 0x01},
+		"tm-t88":   {0x1D, 0x56, 0x01},
+		"default":  {0x1B, 0x6D},
+		"pos-5890": nil,
+	}
+	for name, cut := range cases {
+		out := renderForProfile(t, name, `receipt`)
+		if want := append(slices.Clone(feed), cut...); !bytes.HasSuffix(out, want) {
+			t.Fatalf("%s: expected the receipt to end with % X, got % X", name, want, out[max(0, len(out)-8):])
+		}
+	}
+}
+
+func TestBarcodeRequiresProfileSupport(t *testing.T) {
+	p := profile.Default()
+	p.Barcodes = false
+
+	_, err := RenderToBytesWithOptions(`{{ barcode "EAN8" "9638507" }}`, nil, RenderOptions{Profile: p})
+	if err == nil || !strings.Contains(err.Error(), "no native barcode support") {
+		t.Fatalf("expected unsupported error, got %v", err)
+	}
+}
+
+func TestRasterHelpersFitProfileWidth(t *testing.T) {
+	raster, err := iconTemplateFunc(profile.Default(), "ActionFace", 1000)
+	if err != nil {
+		t.Fatalf("icon failed: %v", err)
+	}
+	// GS v 0 m xL xH: 384 dots are 48 bytes per row
+	if !strings.Contains(raster, "\x1Dv0\x00\x30\x00") {
+		t.Fatal("expected the icon to be clamped to 384 dots")
+	}
+
+	out := renderForProfile(t, "tm-t88", `{{ qr "hello" "native" false }}`)
+	header := bytes.Index(out, []byte("\x1Dv0"))
+	if header < 0 {
+		t.Fatal("expected a raster QR code")
+	}
+	// 512 dots are 64 bytes per row
+	if width := int(out[header+4]) | int(out[header+5])<<8; width != 64 {
+		t.Fatalf("expected the QR raster to span the 512 dot line, got %d bytes", width)
+	}
+}
+
+func TestTextUsesProfileCodePage(t *testing.T) {
+	out := renderForProfile(t, profile.DefaultName, `{{ bold "Äß" }}{{ reset }}`, func(p *profile.Profile) {
+		p.CodePages = []escpos.CharacterCodePage{escpos.CharacterCodePageWPC1252}
+	})
+	if !bytes.HasPrefix(out, []byte{0x1B, 0x40, 0x1B, 0x74, 16}) {
+		t.Fatalf("expected the profile's code page to be selected, got % X", out[:5])
+	}
+	if !bytes.Contains(out, []byte("\xC4\xDF")) || !bytes.Contains(out, []byte{0x1B, 0x40, 0x1B, 0x74, 16}) {
+		t.Fatalf("expected text in Windows-1252, got %q", out)
+	}
+
+	out = renderForProfile(t, profile.DefaultName, `{{ bold "Äß" }}`, func(p *profile.Profile) {
+		p.CodePages = []escpos.CharacterCodePage{escpos.CharacterCodePageKatakana}
+	})
+	if !bytes.Contains(out, []byte("\x1BE\x01A?")) {
+		t.Fatalf("expected an ASCII fallback for a code page without a mapping, got %q", out)
+	}
+}
+
+func TestTextSwitchesCodePages(t *testing.T) {
+	out := renderForProfile(t, "tm-t88", `{{ bold "Привет café" }}`)
+	want := []byte("\x1BE\x01\x1Bt\x11\x8F\xE0\xA8\xA2\xA5\xE2 caf\x1Bt\x00\x82\x1BE\x00")
+	if !bytes.Contains(out, want) {
+		t.Fatalf("expected a switch to PC866 and back to PC437, got % X", out)
+	}
+
+	// Ã is in PC850 and Windows-1252, but only the latter also has Š
+	out = renderForProfile(t, profile.DefaultName, `{{ bold "ÃŠ" }}`, func(p *profile.Profile) {
+		p.CodePages = []escpos.CharacterCodePage{escpos.CharacterCodePagePC437, escpos.CharacterCodePagePC850, escpos.CharacterCodePageWPC1252}
+	})
+	if !bytes.Contains(out, []byte("\x1BE\x01\x1Bt\x10\xC3\x8A\x1BE\x00")) {
+		t.Fatalf("expected a single switch to Windows-1252, got % X", out)
+	}
+
+	// The switch is remembered until reset selects the default page again
+	out = renderForProfile(t, "tm-t88", `{{ bold "Д" }}{{ bold "Ж" }}{{ reset }}{{ bold "Д" }}`)
+	if count := bytes.Count(out, []byte("\x1Bt\x11")); count != 2 {
+		t.Fatalf("expected two switches to PC866, got %d in % X", count, out)
+	}
+}
+
+func TestNestedTextHelpersEncodeOnce(t *testing.T) {
+	out := renderForProfile(t, "tm-t88", `{{ center (wrap "Привет café") }}`)
+	want := []byte("\x1Ba\x01\x1Bt\x11\x8F\xE0\xA8\xA2\xA5\xE2 caf\x1Bt\x00\x82\x1Ba\x00")
+	if !bytes.Contains(out, want) {
+		t.Fatalf("expected the wrapped text encoded once, got % X", out)
+	}
+
+	// The code page follows the order in which text is printed
+	out = renderForProfile(t, "tm-t88", `{{ $name := bold "Д" }}{{ bold "é" }}{{ $name }}`)
+	want = []byte("\x1BE\x01\x82\x1BE\x00\x1BE\x01\x1Bt\x11\x84\x1BE\x00")
+	if !bytes.Contains(out, want) {
+		t.Fatalf("expected a switch to PC866 where the name is printed, got % X", out)
+	}
+
+	out = renderForProfile(t, "tm-t88", `{{ row (bold "Д") "é" }}`)
+	if !bytes.Contains(out, []byte("\x1Bt\x11\x84")) || bytes.Contains(out, []byte("\x1BE")) {
+		t.Fatalf("expected row cells as plain text, got % X", out)
+	}
+}
+
+func TestTextReplacementsOnlyWithoutCodePage(t *testing.T) {
+	out := renderForProfile(t, profile.DefaultName, `{{ bold "“Hi” – ok" }}`, func(p *profile.Profile) {
+		p.CodePages = []escpos.CharacterCodePage{escpos.CharacterCodePagePC437, escpos.CharacterCodePageWPC1252}
+	})
+	if !bytes.Contains(out, []byte("\x1Bt\x10\x93Hi\x94 \x96 ok")) {
+		t.Fatalf("expected typographic characters from Windows-1252, got % X", out)
+	}
+
+	out = renderForProfile(t, profile.DefaultName, `{{ bold "“Hi” – né" }}`)
+	if !bytes.Contains(out, []byte("\x1BE\x01\"Hi\" - n\x82\x1BE\x00")) {
+		t.Fatalf("expected ASCII replacements only where PC437 has no character, got %q", out)
+	}
+}
@@ -0,0 +1,91 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+)
+
+// receiptText is what the text helpers return: ESC/POS commands around text
+// that is still UTF-8. The text is converted to the printer's code pages
+// when the template prints the value, so nested helpers such as
+// {{ center (wrap .text) }} encode it once and the code page switches follow
+// the order in which text is printed, not the order in which helpers run.
+type receiptText struct {
+	encoder *textEncoder
+	parts   []textPart
+}
+
+// textPart is either raw ESC/POS bytes or UTF-8 text.
+type textPart struct {
+	value string
+	raw   bool
+}
+
+// String encodes the text parts; text/template calls it to print the value.
+func (t receiptText) String() string {
+	var builder strings.Builder
+	for _, part := range t.parts {
+		if part.raw {
+			builder.WriteString(part.value)
+			continue
+		}
+		builder.WriteString(t.encoder.encode(part.value))
+	}
+	return builder.String()
+}
+
+// plainText returns the text parts without the commands between them.
+func (t receiptText) plainText() string {
+	var builder strings.Builder
+	for _, part := range t.parts {
+		if !part.raw {
+			builder.WriteString(part.value)
+		}
+	}
+	return builder.String()
+}
+
+// text returns value as receipt text. The result of another helper is kept
+// as it is; anything else is formatted as text.
+func (e *textEncoder) text(value any) receiptText {
+	switch value := value.(type) {
+	case receiptText:
+		return value
+	case nil:
+		return receiptText{encoder: e}
+	case string:
+		return receiptText{encoder: e, parts: []textPart{{value: value}}}
+	default:
+		return receiptText{encoder: e, parts: []textPart{{value: fmt.Sprint(value)}}}
+	}
+}
+
+// raw returns data as receipt text that is printed unchanged.
+func (e *textEncoder) raw(data string) receiptText {
+	return receiptText{encoder: e, parts: []textPart{{value: data, raw: true}}}
+}
+
+// styled wraps value in the prefix and suffix commands.
+func (e *textEncoder) styled(prefix string, value any, suffix string) receiptText {
+	text := e.text(value)
+	parts := make([]textPart, 0, len(text.parts)+2)
+	if prefix != "" {
+		parts = append(parts, textPart{value: prefix, raw: true})
+	}
+	parts = append(parts, text.parts...)
+	if suffix != "" {
+		parts = append(parts, textPart{value: suffix, raw: true})
+	}
+	return receiptText{encoder: e, parts: parts}
+}
+
+// plain returns value as a string when it holds no commands.
+func (e *textEncoder) plain(value any) (string, bool) {
+	text := e.text(value)
+	for _, part := range text.parts {
+		if part.raw {
+			return "", false
+		}
+	}
+	return text.plainText(), true
+}
@@ -0,0 +1,120 @@
+package template
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/profile"
+)
+
+func nativeOptions() RenderOptions {
+	p, _ := profile.Builtin("tm-t88")
+	return RenderOptions{Profile: p}
+}
+
+func TestQRTemplateFuncNative(t *testing.T) {
+	out, err := RenderToBytesWithOptions(`{{ qr "hello" "size" 5 "error" "H" }}`, nil, nativeOptions())
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"\x1D(k\x03\x001C\x05",   // module size
+		"\x1D(k\x03\x001E3",      // error correction H
+		"\x1D(k\x08\x001P0hello", // store
+		"\x1D(k\x03\x001Q0",      // print
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected %q in native output %q", want, out)
+		}
+	}
+	if strings.Contains(string(out), "\x1Dv0") {
+		t.Error("expected no raster image in native output")
+	}
+}
+
+func TestQRTemplateFuncRasterFallback(t *testing.T) {
+	for name, render := range map[string]func() ([]byte, error){
+		"opted out": func() ([]byte, error) {
+			return RenderToBytesWithOptions(`{{ qr "hello" "native" false }}`, nil, nativeOptions())
+		},
+		"unsupported": func() ([]byte, error) {
+			return RenderToBytes(`{{ qr "hello" "native" true }}`, nil)
+		},
+	} {
+		out, err := render()
+		if err != nil {
+			t.Fatalf("%s: render failed: %v", name, err)
+		}
+		if !strings.Contains(string(out), "\x1Dv0") || strings.Contains(string(out), "\x1D(k") {
+			t.Errorf("%s: expected a raster image instead of GS ( k", name)
+		}
+	}
+}
+
+func TestPDF417TemplateFunc(t *testing.T) {
+	out, err := RenderToBytesWithOptions(`{{ pdf417 "hello" "columns" 4 "error" 3 }}`, nil, nativeOptions())
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	for _, want := range []string{"\x1D(k\x03\x000A\x04", "\x1D(k\x04\x000E03", "\x1D(k\x08\x000P0hello"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected %q in output %q", want, out)
+		}
+	}
+
+	cases := map[string]string{
+		`{{ pdf417 "x" "rows" 2 }}`:       "rows must be 0 (auto) or between 3 and 90",
+		`{{ pdf417 "x" "colour" "red" }}`: "unknown option",
+	}
+	for tmpl, want := range cases {
+		_, err := RenderToBytesWithOptions(tmpl, nil, nativeOptions())
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", tmpl, want, err)
+		}
+	}
+
+	_, err = RenderToBytes(`{{ pdf417 "x" }}`, nil)
+	if err == nil || !strings.Contains(err.Error(), "no native PDF417 support") {
+		t.Fatalf("expected unsupported error, got %v", err)
+	}
+}
+
+func TestPDF417IsNativeOnly(t *testing.T) {
+	for _, name := range profile.BuiltinNames() {
+		p, _ := profile.Builtin(name)
+		out, err := RenderToBytesWithOptions(`{{ pdf417 "hello" }}`, nil, RenderOptions{Profile: p})
+		if p.PDF417 {
+			if err != nil || !strings.Contains(string(out), "\x1D(k") {
+				t.Errorf("%s: expected a native PDF417 code, got %q (%v)", name, out, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), "cannot be printed as an image") {
+			t.Errorf("%s: expected an error instead of a raster, got %q (%v)", name, out, err)
+		}
+	}
+
+	for _, name := range []string{"pos-5890", "generic-80mm"} {
+		if p, _ := profile.Builtin(name); p.PDF417 {
+			t.Errorf("%s: the README names it as a printer without PDF417", name)
+		}
+	}
+}
+
+func TestRenderWithOptionsKeepsTemplateReusable(t *testing.T) {
+	tmpl, err := NewTemplate(`{{ qr "hello" }}`)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	out, err := NewRenderer().RenderWithOptions(tmpl, nil, nativeOptions())
+	if err != nil || !strings.Contains(string(out), "\x1D(k") {
+		t.Fatalf("expected native output, got %q (%v)", out, err)
+	}
+
+	out, err = NewRenderer().Render(tmpl, nil)
+	if err != nil || strings.Contains(string(out), "\x1D(k") {
+		t.Fatalf("expected raster output after a native render, got %q (%v)", out, err)
+	}
+}
@@ -2,7 +2,7 @@ package template
 
 import (
 	"bytes"
-	"encoding/base64"
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -13,67 +13,79 @@ import (
 	"unicode/utf8"
 
 	qrcode "github.com/skip2/go-qrcode"
-	"golang.org/x/text/encoding/charmap"
 	"golang.org/x/text/unicode/norm"
 
 	"github.com/jonasclaes/go-thermal-printer/pkg/escpos"
+	"github.com/jonasclaes/go-thermal-printer/pkg/profile"
+	"github.com/jonasclaes/go-thermal-printer/pkg/typeset"
 )
 
-const (
-	defaultCharactersPerLine = 32
-	defaultCharacterCodePage = escpos.CharacterCodePageDefault
-)
-
-var textReplacer = strings.NewReplacer(
-	"\u2018", "'",
-	"\u2019", "'",
-	"\u201C", "\"",
-	"\u201D", "\"",
-	"\u2013", "-",
-	"\u2014", "-",
-	"\u2026", "...",
-	"\u2022", "*",
-	"\u00B7", "*",
-	"\u2122", "TM",
-	"\u00AE", "(R)",
-	"\u00A9", "(C)",
-	"\u00F1", "n",
-	"\u00D1", "N",
-	"\u00E9", "e",
-	"\u00E8", "e",
-	"\u00EA", "e",
-	"\u00EB", "e",
-	"\u00E1", "a",
-	"\u00E0", "a",
-	"\u00E2", "a",
-	"\u00E3", "a",
-	"\u00F3", "o",
-	"\u00F2", "o",
-	"\u00F4", "o",
-	"\u00F5", "o",
-	"\u00FA", "u",
-	"\u00F9", "u",
-	"\u00FB", "u",
-	"\u00FC", "u",
-	"\u00FF", "y",
-	"\u00FD", "y",
-	"\u266A", "*",
-	"\u266B", "*",
-	"\u2605", "*",
-	"\u2606", "*",
-)
+const defaultCharacterCodePage = escpos.CharacterCodePageDefault
+
+// textReplacements are the ASCII approximations of common typographic
+// characters, used when none of the printer's code pages has them. Other
+// runes fall back to asciiFallback.
+var textReplacements = map[rune]string{
+	'\u2018': "'",
+	'\u2019': "'",
+	'\u201C': "\"",
+	'\u201D': "\"",
+	'\u2013': "-",
+	'\u2014': "-",
+	'\u2026': "...",
+	'\u2022': "*",
+	'\u00B7': "*",
+	'\u2122': "TM",
+	'\u00AE': "(R)",
+	'\u00A9': "(C)",
+	'\u266A': "*",
+	'\u266B': "*",
+	'\u2605': "*",
+	'\u2606': "*",
+}
 
 // Template represents a thermal printer template
 type Template struct {
 	tmpl *template.Template
+	// partials are the library templates loaded by NewTemplateWithPartials
+	partials []string
+}
+
+// RenderOptions describe the printer a template is rendered for.
+type RenderOptions struct {
+	// Profile sets the paper width, columns, code page, cutter and symbol
+	// support the helpers work with. The zero value selects profile.Default.
+	Profile profile.Profile
+	// Typesetter shapes text with TrueType/OpenType fonts for the textImage
+	// helper. Nil disables it.
+	Typesetter *typeset.Typesetter
+	// TextSize is the default textImage size in dots; zero selects
+	// typeset.DefaultSize.
+	TextSize int
+	// TextFallback makes the bold, left, center, right and double size
+	// helpers print text the code page cannot encode with Typesetter, instead
+	// of replacing the runes with ASCII.
+	TextFallback bool
+	// Limits bound the time, output, images and paper of a render.
+	Limits Limits
+}
+
+func (o RenderOptions) profile() profile.Profile {
+	if o.Profile.DotsPerLine == 0 {
+		return profile.Default()
+	}
+	return o.Profile
 }
 
 // NewTemplate creates a new template
 func NewTemplate(content string) (*Template, error) {
-	tmpl, err := template.New("thermal").Funcs(getTemplateFuncs()).Parse(content)
+	tmpl, err := template.New("thermal").Funcs(getTemplateFuncs(RenderOptions{}, nil)).Parse(content)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse template: %w", err)
 	}
+	if err := guardLoops(tmpl); err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
 
 	return &Template{
 		tmpl: tmpl,
@@ -96,84 +108,164 @@ func NewRenderer() *Renderer {
 	}
 }
 
-// getTemplateFuncs returns the custom functions available in templates
-func getTemplateFuncs() template.FuncMap {
+// getTemplateFuncs returns the custom functions available in templates.
+// Image-like helpers count against budget, which is nil when parsing.
+func getTemplateFuncs(options RenderOptions, budget *renderBudget) template.FuncMap {
+	printer := options.profile()
+	// One encoder per render follows the code page switches through the
+	// document; text helpers leave the encoding to it when they are printed
+	encoder := newTextEncoder(printer.CodePages)
+	styled := encoder.styled
+	// layout follows the font and character width for columns, wrap and row
+	layout := newTextLayout(printer)
+	// textFallback prints text as a raster when the automatic fallback is
+	// enabled and none of the code pages can encode it. ok is false when the text
+	// should be sent as characters.
+	textFallback := func(value any, style typeset.Options, scaleX, scaleY int, fullWidth bool) (raster receiptText, ok bool) {
+		if !options.TextFallback || options.Typesetter == nil {
+			return receiptText{}, false
+		}
+		// Text other helpers already styled is sent as it is
+		text, plain := encoder.plain(value)
+		if !plain || encoder.canEncode(text) {
+			return receiptText{}, false
+		}
+		// A raster of glyphs the font lacks is worse than ASCII look-alikes
+		if !options.Typesetter.Covers(text) {
+			log.Printf("template text fallback: the font has no glyphs for some of %q, printing it as text", text)
+			return receiptText{}, false
+		}
+		if style.Size == 0 {
+			style.Size = options.TextSize
+		}
+		data, err := rasterText(printer, options.Typesetter, text, style, scaleX, scaleY, fullWidth)
+		if err != nil {
+			log.Printf("template text fallback: %v", err)
+			return receiptText{}, false
+		}
+		return encoder.raw(string(data)), true
+	}
+
 	return template.FuncMap{
-		"bold": func(text string) string {
-			text = encodeToCodePage(text)
-			return fmt.Sprintf("\x1B\x45\x01%s\x1B\x45\x00", text)
+		renderCheckFunc: budget.check,
+		"bold": func(text any) receiptText {
+			if raster, ok := textFallback(text, typeset.Options{Bold: true}, 1, 1, false); ok {
+				return raster
+			}
+			return styled("\x1B\x45\x01", text, "\x1B\x45\x00")
 		},
-		"underline": func(text string) string {
-			text = encodeToCodePage(text)
-			return fmt.Sprintf("\x1B\x2D\x01%s\x1B\x2D\x00", text)
+		"underline": func(text any) receiptText {
+			return styled("\x1B\x2D\x01", text, "\x1B\x2D\x00")
 		},
-		"italic": func(text string) string {
-			text = encodeToCodePage(text)
-			return fmt.Sprintf("\x1B\x34\x01%s\x1B\x34\x00", text)
+		"italic": func(text any) receiptText {
+			return styled("\x1B\x34\x01", text, "\x1B\x34\x00")
 		},
-		"italics": func(text string) string {
-			text = encodeToCodePage(text)
-			return fmt.Sprintf("\x1B\x34\x01%s\x1B\x34\x00", text)
+		"italics": func(text any) receiptText {
+			return styled("\x1B\x34\x01", text, "\x1B\x34\x00")
 		},
-		"fontb": func(text string) string {
-			text = encodeToCodePage(text)
-			return fmt.Sprintf("\x1B\x4D\x01%s\x1B\x4D\x00", text)
+		"fontb": func(text any) receiptText {
+			layout.font = escpos.CharacterFontA
+			return styled("\x1B\x4D\x01", text, "\x1B\x4D\x00")
 		},
-		"center": func(text string) string {
-			text = encodeToCodePage(text)
-			return fmt.Sprintf("\x1B\x61\x01%s\x1B\x61\x00", text)
+		"center": func(text any) receiptText {
+			if raster, ok := textFallback(text, typeset.Options{Align: escpos.AlignCenter}, 1, 1, true); ok {
+				return raster
+			}
+			return styled("\x1B\x61\x01", text, "\x1B\x61\x00")
 		},
-		"left": func(text string) string {
-			text = encodeToCodePage(text)
-			return fmt.Sprintf("\x1B\x61\x00%s", text)
+		"left": func(text any) receiptText {
+			if raster, ok := textFallback(text, typeset.Options{}, 1, 1, true); ok {
+				return raster
+			}
+			return styled("\x1B\x61\x00", text, "")
 		},
-		"right": func(text string) string {
-			text = encodeToCodePage(text)
-			return fmt.Sprintf("\x1B\x61\x02%s\x1B\x61\x00", text)
+		"right": func(text any) receiptText {
+			if raster, ok := textFallback(text, typeset.Options{Align: escpos.AlignRight}, 1, 1, true); ok {
+				return raster
+			}
+			return styled("\x1B\x61\x02", text, "\x1B\x61\x00")
 		},
-		"rotate90": func(text string) string {
-			text = encodeToCodePage(text)
-			return fmt.Sprintf("\x1B\x56\x01%s\x1B\x56\x00", text)
+		"rotate90": func(text any) receiptText {
+			return styled("\x1B\x56\x01", text, "\x1B\x56\x00")
 		},
-		"wrap": func(text string, maxWidth ...int) string {
-			width := defaultCharactersPerLine
+		"wrap": func(text any, maxWidth ...int) receiptText {
+			width := layout.columns(layout.font)
 			if len(maxWidth) > 0 && maxWidth[0] > 0 {
 				width = maxWidth[0]
 			}
-			return wrapText(text, width)
+			return wrapText(encoder.text(text), width)
 		},
-		"fontOptions": func(args ...any) (string, error) {
-			return buildFontOptions(args...)
-		},
-		"image": func(data any, maxWidth ...int) (string, error) {
-			var encoded string
-			switch v := data.(type) {
-			case string:
-				encoded = v
-			case []byte:
-				encoded = string(v)
-			default:
-				return "", fmt.Errorf("image expects string or []byte, got %T", data)
-			}
-			width := 0
-			if len(maxWidth) > 0 {
-				width = maxWidth[0]
+		"columns": func(font ...any) (int, error) {
+			if len(font) == 0 {
+				return layout.columns(layout.font), nil
 			}
-			bytes, err := escpos.EncodeImageToRasterBytes(encoded, width)
+			value, err := parseFont(font[0])
 			if err != nil {
-				return "", fmt.Errorf("image render failed: %w", err)
+				return 0, fmt.Errorf("columns: %w", err)
 			}
-			bytes = append(bytes, 0x1B, 0x74, byte(defaultCharacterCodePage))
-			return string(bytes), nil
+			return layout.columns(escpos.CharacterFont(value)), nil
+		},
+		"cell": func(value any, args ...any) (*layoutCell, error) {
+			return buildCell("cell", value, args...)
+		},
+		"column": func(key any, args ...any) (*layoutCell, error) {
+			return buildCell("column", key, args...)
+		},
+		"row": func(cells ...any) (receiptText, error) {
+			return buildRow(layout, encoder, cells...)
+		},
+		"table": func(items any, columns ...any) (receiptText, error) {
+			return buildTable(layout, encoder, items, columns...)
+		},
+		"fontOptions": func(args ...any) (string, error) {
+			return buildFontOptions(layout, args...)
+		},
+		"textImage": func(text string, args ...any) (string, error) {
+			if err := budget.image(); err != nil {
+				return "", err
+			}
+			return buildTextImage(printer, options, text, args...)
+		},
+		"image": func(data any, args ...any) (string, error) {
+			if err := budget.image(); err != nil {
+				return "", err
+			}
+			return buildImage(printer, data, args...)
 		},
 		"qr": func(data string, args ...any) (string, error) {
-			qrBytes, err := buildQRCode(data, args...)
+			if err := budget.image(); err != nil {
+				return "", err
+			}
+			qrBytes, err := buildQRCode(printer, data, args...)
 			if err != nil {
 				return "", err
 			}
-			qrBytes = append(qrBytes, 0x1B, 0x74, byte(defaultCharacterCodePage))
 			return string(qrBytes), nil
 		},
+		"pdf417": func(data string, args ...any) (string, error) {
+			if err := budget.image(); err != nil {
+				return "", err
+			}
+			return buildPDF417(printer, data, args...)
+		},
+		"barcode": func(kind string, data any, opts ...any) (string, error) {
+			if !printer.Barcodes {
+				return "", fmt.Errorf("barcode: the printer has no native barcode support")
+			}
+			if err := budget.image(); err != nil {
+				return "", err
+			}
+			return buildBarcode(kind, data, opts...)
+		},
+		"drawer": func(opts ...any) (string, error) {
+			return buildDrawer(opts...)
+		},
+		"beep": func(opts ...any) (string, error) {
+			if !printer.Buzzer {
+				return "", fmt.Errorf("beep: the printer has no buzzer")
+			}
+			return buildBeep(opts...)
+		},
 		"align": func(position string) (string, error) {
 			switch strings.ToLower(strings.TrimSpace(position)) {
 			case "left":
@@ -199,7 +291,10 @@ func getTemplateFuncs() template.FuncMap {
 			return string([]byte{0x1B, 0x4A, byte(dots)}), nil
 		},
 		"icon": func(name string, opts ...any) (string, error) {
-			return iconTemplateFunc(name, opts...)
+			if err := budget.image(); err != nil {
+				return "", err
+			}
+			return iconTemplateFunc(printer, name, opts...)
 		},
 		"cut": func(mode ...string) (string, error) {
 			cutMode := byte(0x00)
@@ -213,23 +308,38 @@ func getTemplateFuncs() template.FuncMap {
 					return "", fmt.Errorf("cut expects 'full' or 'partial'; got %s", mode[0])
 				}
 			}
+			switch printer.Cutter {
+			case profile.CutterNone:
+				// Leave the paper for the operator to tear off
+				return "", nil
+			case profile.CutterPartial:
+				cutMode = 0x01
+			}
 			return string([]byte{0x1D, 0x56, cutMode}), nil
 		},
-		"doubleWidth": func(text string) string {
-			text = encodeToCodePage(text)
-			return fmt.Sprintf("\x1D\x21\x10%s\x1D\x21\x00", text)
+		"doubleWidth": func(text any) receiptText {
+			if raster, ok := textFallback(text, typeset.Options{}, 2, 1, false); ok {
+				return raster
+			}
+			layout.scale = 1
+			return styled("\x1D\x21\x10", text, "\x1D\x21\x00")
 		},
-		"doubleHeight": func(text string) string {
-			text = encodeToCodePage(text)
-			return fmt.Sprintf("\x1D\x21\x01%s\x1D\x21\x00", text)
+		"doubleHeight": func(text any) receiptText {
+			if raster, ok := textFallback(text, typeset.Options{}, 1, 2, false); ok {
+				return raster
+			}
+			layout.scale = 1
+			return styled("\x1D\x21\x01", text, "\x1D\x21\x00")
 		},
-		"doubleSize": func(text string) string {
-			text = encodeToCodePage(text)
-			return fmt.Sprintf("\x1D\x21\x11%s\x1D\x21\x00", text)
+		"doubleSize": func(text any) receiptText {
+			if raster, ok := textFallback(text, typeset.Options{}, 2, 2, false); ok {
+				return raster
+			}
+			layout.scale = 1
+			return styled("\x1D\x21\x11", text, "\x1D\x21\x00")
 		},
-		"invert": func(text string) string {
-			text = encodeToCodePage(text)
-			return fmt.Sprintf("\x1D\x42\x01%s\x1D\x42\x00", text)
+		"invert": func(text any) receiptText {
+			return styled("\x1D\x42\x01", text, "\x1D\x42\x00")
 		},
 		"lineSpacing": func(dots int) (string, error) {
 			if dots < 0 || dots > 255 {
@@ -238,16 +348,32 @@ func getTemplateFuncs() template.FuncMap {
 			return string([]byte{0x1B, 0x33, byte(dots)}), nil
 		},
 		"reset": func() string {
-			return string([]byte{0x1B, 0x40, 0x1B, 0x74, byte(defaultCharacterCodePage)})
+			encoder.reset()
+			layout.reset()
+			return string([]byte{0x1B, 0x40, 0x1B, 0x74, byte(encoder.current)})
 		},
 	}
 }
 
-func wrapText(text string, width int) string {
+// wrapText wraps each run of text in text to width columns; the commands of
+// other helpers between them are kept as they are.
+func wrapText(text receiptText, width int) receiptText {
 	if width <= 0 {
-		return encodeToCodePage(text)
+		return text
 	}
 
+	wrapped := receiptText{encoder: text.encoder, parts: make([]textPart, len(text.parts))}
+	for i, part := range text.parts {
+		if part.raw {
+			wrapped.parts[i] = part
+			continue
+		}
+		wrapped.parts[i] = textPart{value: wrapLines(part.value, width)}
+	}
+	return wrapped
+}
+
+func wrapLines(text string, width int) string {
 	lines := strings.Split(text, "\n")
 	var builder strings.Builder
 
@@ -264,7 +390,7 @@ func wrapText(text string, width int) string {
 		}
 	}
 
-	return encodeToCodePage(builder.String())
+	return builder.String()
 }
 
 func wrapLine(line string, width int) []string {
@@ -415,7 +541,7 @@ func formatReplacementSummary(order []rune, counts map[rune]int) string {
 			builder.WriteString(", ")
 		}
 		r := order[i]
-		builder.WriteString(fmt.Sprintf("%q→%q(x%d)", r, asciiFallback(r), counts[r]))
+		builder.WriteString(fmt.Sprintf("%q→%q(x%d)", r, lossyFallback(r), counts[r]))
 	}
 	if len(order) > max {
 		builder.WriteString(", …")
@@ -424,6 +550,58 @@ func formatReplacementSummary(order []rune, counts map[rune]int) string {
 }
 
 func encodeToCodePage(text string) string {
+	return encodeText(text, defaultCharacterCodePage)
+}
+
+// encodeText converts UTF-8 text to the given code page only. Runes the code
+// page cannot represent are replaced with an ASCII approximation.
+func encodeText(text string, codePage escpos.CharacterCodePage) string {
+	return newTextEncoder([]escpos.CharacterCodePage{codePage}).encode(text)
+}
+
+// textEncoder converts UTF-8 text for a printer with several code pages. Each
+// rune the active page cannot represent switches, with ESC t, to the allowed
+// page that covers the longest stretch of the following text. The active page
+// carries over between calls, so text must be encoded in document order;
+// receiptText does so by encoding when the template prints it.
+type textEncoder struct {
+	pages   []escpos.CharacterCodePage
+	current escpos.CharacterCodePage
+}
+
+// newTextEncoder allows the given code pages in order of preference; the
+// first one is active after ESC @.
+func newTextEncoder(pages []escpos.CharacterCodePage) *textEncoder {
+	e := &textEncoder{pages: pages}
+	e.reset()
+	return e
+}
+
+// reset selects the default code page again, as ESC @ ESC t does.
+func (e *textEncoder) reset() {
+	e.current = defaultCharacterCodePage
+	if len(e.pages) > 0 {
+		e.current = e.pages[0]
+	}
+}
+
+// canEncode reports whether every rune of text is in one of the code pages
+// or has a typographic replacement.
+func (e *textEncoder) canEncode(text string) bool {
+	for _, r := range text {
+		if _, ok := textReplacements[r]; ok {
+			continue
+		}
+		if r >= 0x80 && !e.hasRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// encode converts text, inserting code page switches as needed. Runes no
+// page can represent are replaced with an ASCII approximation.
+func (e *textEncoder) encode(text string) string {
 	if text == "" {
 		return ""
 	}
@@ -433,33 +611,40 @@ func encodeToCodePage(text string) string {
 		return text
 	}
 
-	normalized := textReplacer.Replace(text)
-	encoder := charmap.CodePage437.NewEncoder()
+	runes := []rune(text)
 	var buf bytes.Buffer
-	buf.Grow(len(normalized))
+	buf.Grow(len(text))
 	replacements := make(map[rune]int)
 	replacementOrder := make([]rune, 0, 8)
+	// Filled on the first switch, so text the active page covers is not scanned twice
+	var coverage [][]int
 
-	var scratch [4]byte
-
-	for _, r := range normalized {
-		if r == '\r' || r == '\n' || r == '\t' {
-			buf.WriteRune(r)
+	for i, r := range runes {
+		// ASCII is shared by all code pages
+		if r < 0x80 {
+			buf.WriteByte(byte(r))
 			continue
 		}
 
-		runeBytes := []byte(string(r))
-		encoder.Reset()
-		nDst, _, err := encoder.Transform(scratch[:], runeBytes, true)
-		if err != nil {
-			replacements[r]++
-			if replacements[r] == 1 {
-				replacementOrder = append(replacementOrder, r)
-			}
-			buf.WriteString(asciiFallback(r))
+		if b, ok := encodeRune(e.current, r); ok {
+			buf.WriteByte(b)
 			continue
 		}
-		buf.Write(scratch[:nDst])
+		if coverage == nil {
+			coverage = e.coverage(runes)
+		}
+		if page := e.pageAt(coverage, i); page >= 0 {
+			e.current = page
+			b, _ := encodeRune(page, r)
+			buf.Write([]byte{0x1B, 0x74, byte(page), b})
+			continue
+		}
+
+		replacements[r]++
+		if replacements[r] == 1 {
+			replacementOrder = append(replacementOrder, r)
+		}
+		buf.WriteString(lossyFallback(r))
 	}
 
 	if len(replacements) > 0 {
@@ -470,7 +655,87 @@ func encodeToCodePage(text string) string {
 	return buf.String()
 }
 
-func buildFontOptions(args ...any) (string, error) {
+// substitute replaces the runes no code page can represent with their ASCII
+// approximation, leaving text that encode prints one character per rune.
+func (e *textEncoder) substitute(text string) string {
+	if !utf8.ValidString(text) {
+		// encode passes such text through unchanged
+		return text
+	}
+	var builder strings.Builder
+	for _, r := range text {
+		if r < 0x80 || e.hasRune(r) {
+			builder.WriteRune(r)
+			continue
+		}
+		builder.WriteString(lossyFallback(r))
+	}
+	return builder.String()
+}
+
+// hasRune reports whether one of the code pages has r.
+func (e *textEncoder) hasRune(r rune) bool {
+	for _, page := range e.pages {
+		if _, ok := encodeRune(page, r); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// coverage returns, for each code page, how many runes from each position on
+// the page covers, in one backward pass over runes.
+func (e *textEncoder) coverage(runes []rune) [][]int {
+	coverage := make([][]int, len(e.pages))
+	for p, page := range e.pages {
+		covered := make([]int, len(runes)+1)
+		for i := len(runes) - 1; i >= 0; i-- {
+			if r := runes[i]; r >= 0x80 {
+				if _, ok := encodeRune(page, r); !ok {
+					continue
+				}
+			}
+			covered[i] = covered[i+1] + 1
+		}
+		coverage[p] = covered
+	}
+	return coverage
+}
+
+// pageAt picks the code page for the rune at i: of the pages that have it,
+// the one that also covers the most following runes, preferring earlier pages
+// on a tie. It returns -1 when no page has the rune.
+func (e *textEncoder) pageAt(coverage [][]int, i int) escpos.CharacterCodePage {
+	best, bestLength := escpos.CharacterCodePage(-1), 0
+	for p, page := range e.pages {
+		if length := coverage[p][i]; length > bestLength {
+			best, bestLength = page, length
+		}
+	}
+	return best
+}
+
+// encodeRune returns the byte of r in codePage. Code pages without a known
+// mapping have no characters beyond ASCII.
+func encodeRune(codePage escpos.CharacterCodePage, r rune) (byte, bool) {
+	table := codePage.Charmap()
+	if table == nil {
+		return 0, false
+	}
+	return table.EncodeRune(r)
+}
+
+// lossyFallback approximates a rune no code page can represent.
+func lossyFallback(r rune) string {
+	if replacement, ok := textReplacements[r]; ok {
+		return replacement
+	}
+	return asciiFallback(r)
+}
+
+// buildFontOptions renders the fontOptions helper and records the selected
+// font and character width in layout.
+func buildFontOptions(layout *textLayout, args ...any) (string, error) {
 	if len(args)%2 != 0 {
 		return "", fmt.Errorf("fontOptions expects key/value pairs")
 	}
@@ -483,6 +748,7 @@ func buildFontOptions(args ...any) (string, error) {
 	}
 
 	settings := fontSize{width: 1, height: 1}
+	font := layout.font
 	var builder strings.Builder
 
 	for i := 0; i < len(args); i += 2 {
@@ -499,6 +765,7 @@ func buildFontOptions(args ...any) (string, error) {
 				return "", err
 			}
 			builder.Write([]byte{0x1B, 0x4D, fontValue})
+			font = escpos.CharacterFont(fontValue)
 
 		case "width", "charwidth", "scalewidth":
 			width, err := toInt(value)
@@ -602,12 +869,14 @@ func buildFontOptions(args ...any) (string, error) {
 		height := settings.height
 		charSize := byte(((width - 1) << 4) | (height - 1))
 		builder.Write([]byte{0x1D, 0x21, charSize})
+		layout.scale = width
 	}
+	layout.font = font
 
 	return builder.String(), nil
 }
 
-func buildQRCode(data string, args ...any) ([]byte, error) {
+func buildQRCode(printer profile.Profile, data string, args ...any) ([]byte, error) {
 	if data == "" {
 		return nil, fmt.Errorf("qr: data is required")
 	}
@@ -616,7 +885,9 @@ func buildQRCode(data string, args ...any) ([]byte, error) {
 		scale:         8,
 		errorLevel:    qrcode.Medium,
 		disableBorder: false,
-		maxWidth:      0,
+		maxWidth:      printer.DotsPerLine,
+		native:        printer.QRCode,
+		align:         escpos.AlignCenter,
 	}
 
 	if len(args) > 0 {
@@ -654,6 +925,16 @@ func buildQRCode(data string, args ...any) ([]byte, error) {
 				}
 				options.disableBorder = border <= 0
 
+			case "native":
+				native, err := toBool(value)
+				if err != nil {
+					return nil, fmt.Errorf("qr native: %w", err)
+				}
+				if native && !printer.QRCode {
+					log.Printf("template qr: printer has no native QR support, falling back to a raster image")
+				}
+				options.native = native && printer.QRCode
+
 			case "maxwidth", "width":
 				maxWidth, err := toInt(value)
 				if err != nil {
@@ -662,7 +943,23 @@ func buildQRCode(data string, args ...any) ([]byte, error) {
 				if maxWidth < 0 {
 					return nil, fmt.Errorf("qr maxWidth must be >= 0; got %d", maxWidth)
 				}
-				options.maxWidth = maxWidth
+				if maxWidth > 0 {
+					options.maxWidth = min(maxWidth, printer.DotsPerLine)
+				}
+
+			case "align":
+				align, err := parseRasterAlign("qr", value)
+				if err != nil {
+					return nil, err
+				}
+				options.align = align
+
+			case "feed":
+				lines, err := parseFeedLines("qr", value)
+				if err != nil {
+					return nil, err
+				}
+				options.feed = lines
 
 			default:
 				return nil, fmt.Errorf("qr: unknown option %q", key)
@@ -670,6 +967,14 @@ func buildQRCode(data string, args ...any) ([]byte, error) {
 		}
 	}
 
+	if options.native {
+		command, err := escpos.QRCodeCommand(data, min(options.scale, 16), nativeQRErrorCorrection(options.errorLevel))
+		if err != nil {
+			return nil, err
+		}
+		return appendFeed(command, options.feed), nil
+	}
+
 	qrCode, err := qrcode.New(data, options.errorLevel)
 	if err != nil {
 		return nil, fmt.Errorf("qr: failed to encode data: %w", err)
@@ -680,18 +985,16 @@ func buildQRCode(data string, args ...any) ([]byte, error) {
 		options.scale = 8
 	}
 
-	pngBytes, err := qrCode.PNG(-options.scale)
-	if err != nil {
-		return nil, fmt.Errorf("qr: failed to render image: %w", err)
-	}
-
-	encoded := base64.StdEncoding.EncodeToString(pngBytes)
-	imgBytes, err := escpos.EncodeImageToRasterBytes(encoded, options.maxWidth)
+	raster, err := escpos.RasterImage(qrCode.Image(-options.scale), escpos.ImageOptions{
+		MaxWidthDots: options.maxWidth,
+		BandHeight:   printer.RasterBandHeight,
+		Align:        options.align,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("qr: failed to convert image: %w", err)
 	}
 
-	return imgBytes, nil
+	return appendFeed(raster, options.feed), nil
 }
 
 type qrOptions struct {
@@ -699,6 +1002,78 @@ type qrOptions struct {
 	errorLevel    qrcode.RecoveryLevel
 	disableBorder bool
 	maxWidth      int
+	native        bool
+	align         escpos.Alignment
+	feed          int
+}
+
+func nativeQRErrorCorrection(level qrcode.RecoveryLevel) escpos.QRErrorCorrection {
+	switch level {
+	case qrcode.Low:
+		return escpos.QRErrorCorrectionL
+	case qrcode.High:
+		return escpos.QRErrorCorrectionQ
+	case qrcode.Highest:
+		return escpos.QRErrorCorrectionH
+	default:
+		return escpos.QRErrorCorrectionM
+	}
+}
+
+// buildPDF417 renders the pdf417 helper with GS ( k. Unlike qr it has no
+// raster fallback, as there is no PDF417 encoder to draw the symbol with, so
+// it fails on printers without native 2D code support.
+func buildPDF417(printer profile.Profile, data string, args ...any) (string, error) {
+	if !printer.PDF417 {
+		return "", fmt.Errorf("pdf417: the printer has no native PDF417 support and PDF417 codes cannot be printed as an image; use qr or set native_2d_codes")
+	}
+	if len(args)%2 != 0 {
+		return "", fmt.Errorf("pdf417 expects key/value option pairs")
+	}
+
+	var options escpos.PDF417Options
+	for i := 0; i < len(args); i += 2 {
+		key, ok := args[i].(string)
+		if !ok {
+			return "", fmt.Errorf("pdf417 option keys must be strings, got %T", args[i])
+		}
+		value := args[i+1]
+
+		var target *int
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "columns":
+			target = &options.Columns
+		case "rows":
+			target = &options.Rows
+		case "width", "module", "modulewidth":
+			target = &options.ModuleWidth
+		case "height", "rowheight":
+			target = &options.RowHeight
+		case "error", "errorlevel", "ecc":
+			target = &options.ErrorLevel
+		case "truncated":
+			truncated, err := toBool(value)
+			if err != nil {
+				return "", fmt.Errorf("pdf417 truncated: %w", err)
+			}
+			options.Truncated = truncated
+			continue
+		default:
+			return "", fmt.Errorf("pdf417: unknown option %q", key)
+		}
+
+		n, err := toInt(value)
+		if err != nil {
+			return "", fmt.Errorf("pdf417 %s: %w", key, err)
+		}
+		*target = n
+	}
+
+	command, err := escpos.PDF417Command(data, options)
+	if err != nil {
+		return "", err
+	}
+	return string(command), nil
 }
 
 func parseFont(value any) (byte, error) {
@@ -811,6 +1186,27 @@ func toInt(value any) (int, error) {
 	}
 }
 
+func toFloat(value any) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %q", v)
+		}
+		return parsed, nil
+	default:
+		number, err := toInt(value)
+		if err != nil {
+			return 0, fmt.Errorf("cannot convert %T to float", value)
+		}
+		return float64(number), nil
+	}
+}
+
 func toBool(value any) (bool, error) {
 	switch v := value.(type) {
 	case bool:
@@ -878,21 +1274,70 @@ func toBoolOrInt(value any) (byte, error) {
 
 // Render processes the template and returns the ESCPOS byte array
 func (r *Renderer) Render(template *Template, data any) ([]byte, error) {
+	return r.RenderWithOptions(template, data, RenderOptions{})
+}
+
+// RenderWithOptions renders the template for a printer described by options
+func (r *Renderer) RenderWithOptions(template *Template, data any, options RenderOptions) ([]byte, error) {
+	return r.RenderContext(context.Background(), template, data, options)
+}
+
+// RenderContext renders the template like RenderWithOptions and gives up
+// when ctx is done or the render exceeds options.Limits, returning a
+// common.RenderLimitError for the limit. Every range iteration checks the
+// limits (see guardLoops), so a render that is given up on stops soon after;
+// it is not waited for, as a helper may be in the middle of slow work.
+func (r *Renderer) RenderContext(ctx context.Context, template *Template, data any, options RenderOptions) ([]byte, error) {
 	r.buffer.Reset()
 
+	printer := options.profile()
+	if options.Limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Limits.Timeout)
+		defer cancel()
+	}
+
 	r.escpos.Initialize()
-	r.escpos.SelectCharacterCodePage(defaultCharacterCodePage)
+	r.escpos.SelectCharacterCodePage(printer.DefaultCodePage())
 
-	// Execute template to a temporary buffer
+	// Bind the helpers to this render's options without touching the parsed template
+	tmpl, err := template.tmpl.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare template: %w", err)
+	}
 	tempBuffer := &bytes.Buffer{}
-	if err := template.tmpl.Execute(tempBuffer, data); err != nil {
+	budget := newRenderBudget(ctx, options.Limits, tempBuffer)
+	tmpl.Funcs(getTemplateFuncs(options, budget))
+
+	// Execute template to a temporary buffer
+	done := make(chan error, 1)
+	go func() {
+		done <- tmpl.Execute(budget, data)
+	}()
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		select {
+		case err = <-done:
+		default:
+			err = budget.err()
+		}
+	}
+	if err != nil {
 		return nil, fmt.Errorf("failed to execute template: %w", err)
 	}
 
 	r.escpos.Write(tempBuffer.Bytes())
 
 	r.escpos.PrintAndFeedPaperNLines(9)
-	r.escpos.FullCut()
+	switch printer.Cutter {
+	case profile.CutterNone:
+		// Leave the paper for the operator to tear off
+	case profile.CutterPartial:
+		r.escpos.SelectCutModeAndCutPaper(escpos.CutModePartial)
+	default:
+		r.escpos.FullCut()
+	}
 
 	return r.buffer.Bytes(), nil
 }
@@ -908,14 +1353,30 @@ func RenderToBytes(templateContent string, data any) ([]byte, error) {
 	return renderer.Render(template, data)
 }
 
+// RenderToBytesWithOptions renders template content for the printer described by options
+func RenderToBytesWithOptions(templateContent string, data any, options RenderOptions) ([]byte, error) {
+	template, err := NewTemplate(templateContent)
+	if err != nil {
+		return nil, err
+	}
+
+	renderer := NewRenderer()
+	return renderer.RenderWithOptions(template, data, options)
+}
+
 // RenderTemplateFileWithVariables reads a template file and renders it with variable substitution
 func RenderTemplateFileWithVariables(filePath string, variables map[string]any) ([]byte, error) {
+	return RenderTemplateFileWithOptions(filePath, variables, RenderOptions{})
+}
+
+// RenderTemplateFileWithOptions reads a template file and renders it for the printer described by options
+func RenderTemplateFileWithOptions(filePath string, variables map[string]any, options RenderOptions) ([]byte, error) {
 	content, err := os.ReadFile(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read template file: %w", err)
 	}
 
-	return RenderToBytesWithVariables(string(content), variables)
+	return RenderToBytesWithOptions(string(content), variables, options)
 }
 
 // RenderToBytesWithVariables renders template content with variable substitution
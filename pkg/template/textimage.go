@@ -0,0 +1,156 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/escpos"
+	"github.com/jonasclaes/go-thermal-printer/pkg/profile"
+	"github.com/jonasclaes/go-thermal-printer/pkg/typeset"
+)
+
+// buildTextImage renders the textImage helper:
+//
+//	{{ textImage .name }}
+//	{{ textImage .name "bold" true "size" 32 "align" "center" "feed" 1 }}
+//
+// The text is shaped with the configured font and printed as a raster line,
+// so any script the font covers prints regardless of the code page. Lines
+// wrap at the paper width. Right-to-left text is right aligned unless an
+// alignment is given.
+func buildTextImage(printer profile.Profile, options RenderOptions, text string, args ...any) (string, error) {
+	if options.Typesetter == nil {
+		return "", errors.New("textImage: no font is configured; set regular in the [font] section")
+	}
+	if len(args)%2 != 0 {
+		return "", fmt.Errorf("textImage expects key/value option pairs")
+	}
+
+	style := typeset.Options{Size: options.TextSize}
+	if typeset.RightToLeft(text) {
+		style.Align = escpos.AlignRight
+	}
+	feed := 0
+	for i := 0; i < len(args); i += 2 {
+		key, ok := args[i].(string)
+		if !ok {
+			return "", fmt.Errorf("textImage option keys must be strings, got %T", args[i])
+		}
+		value := args[i+1]
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "bold":
+			bold, err := toBool(value)
+			if err != nil {
+				return "", fmt.Errorf("textImage bold: %w", err)
+			}
+			style.Bold = bold
+
+		case "size":
+			size, err := toInt(value)
+			if err != nil {
+				return "", fmt.Errorf("textImage size: %w", err)
+			}
+			if size < 1 || size > typeset.MaxSize {
+				return "", fmt.Errorf("textImage size expects 1-%d dots; got %d", typeset.MaxSize, size)
+			}
+			style.Size = size
+
+		case "align":
+			align, err := parseRasterAlign("textImage", value)
+			if err != nil {
+				return "", err
+			}
+			style.Align = align
+
+		case "feed":
+			lines, err := parseFeedLines("textImage", value)
+			if err != nil {
+				return "", err
+			}
+			feed = lines
+
+		default:
+			return "", fmt.Errorf("textImage: unknown option %q", key)
+		}
+	}
+
+	if strings.TrimSpace(text) == "" {
+		return string(appendFeed(nil, feed)), nil
+	}
+	raster, err := rasterText(printer, options.Typesetter, text, style, 1, 1, true)
+	if err != nil {
+		return "", fmt.Errorf("textImage render failed: %w", err)
+	}
+	return string(appendFeed(raster, feed)), nil
+}
+
+// rasterText shapes text and converts it to GS v 0 raster commands. scaleX
+// and scaleY enlarge the rendered text like the printer's double width and
+// height modes. A fullWidth raster spans the paper and is placed according
+// to style.Align; otherwise it is only as wide as the text, so the printer's
+// own justification applies. One trailing newline is dropped, as the raster
+// already ends the line.
+func rasterText(printer profile.Profile, typesetter *typeset.Typesetter, text string, style typeset.Options, scaleX, scaleY int, fullWidth bool) ([]byte, error) {
+	text = strings.TrimSuffix(strings.TrimSuffix(text, "\n"), "\r")
+	style.MaxWidth = printer.DotsPerLine / scaleX
+	img, err := typesetter.Render(text, style)
+	if err != nil {
+		return nil, err
+	}
+	img = padToByte(stretchGray(img, scaleX, scaleY), style.Align)
+
+	options := escpos.ImageOptions{
+		MaxWidthDots: printer.DotsPerLine,
+		BandHeight:   printer.RasterBandHeight,
+		Align:        style.Align,
+	}
+	if !fullWidth {
+		options.MaxWidthDots = min(img.Bounds().Dx(), printer.DotsPerLine)
+	}
+	return escpos.RasterImage(img, options)
+}
+
+// stretchGray enlarges img by whole factors, repeating every dot.
+func stretchGray(img *image.Gray, scaleX, scaleY int) *image.Gray {
+	if scaleX == 1 && scaleY == 1 {
+		return img
+	}
+	b := img.Bounds()
+	out := image.NewGray(image.Rect(0, 0, b.Dx()*scaleX, b.Dy()*scaleY))
+	for y := 0; y < out.Rect.Dy(); y++ {
+		src := img.Pix[(y/scaleY)*img.Stride:]
+		dst := out.Pix[y*out.Stride : (y+1)*out.Stride]
+		for x := range dst {
+			dst[x] = src[x/scaleX]
+		}
+	}
+	return out
+}
+
+// padToByte widens img with white to a multiple of 8 dots, so the raster
+// encoder does not have to rescale it. The padding goes on the side away
+// from the alignment.
+func padToByte(img *image.Gray, align escpos.Alignment) *image.Gray {
+	b := img.Bounds()
+	width := (b.Dx() + 7) &^ 7
+	if width == b.Dx() {
+		return img
+	}
+	offset := 0
+	switch align {
+	case escpos.AlignCenter:
+		offset = (width - b.Dx()) / 2
+	case escpos.AlignRight:
+		offset = width - b.Dx()
+	}
+	out := image.NewGray(image.Rect(0, 0, width, b.Dy()))
+	for i := range out.Pix {
+		out.Pix[i] = 0xFF
+	}
+	for y := 0; y < b.Dy(); y++ {
+		copy(out.Pix[y*out.Stride+offset:], img.Pix[y*img.Stride:y*img.Stride+b.Dx()])
+	}
+	return out
+}
@@ -0,0 +1,194 @@
+package template
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/typeset"
+)
+
+func textRenderOptions(t *testing.T, fallback bool) RenderOptions {
+	t.Helper()
+	typesetter, err := typeset.New(goregular.TTF, nil)
+	if err != nil {
+		t.Fatalf("load font: %v", err)
+	}
+	return RenderOptions{Typesetter: typesetter, TextFallback: fallback}
+}
+
+// rasterWidth returns the width in bytes of the first GS v 0 raster in out.
+func rasterWidth(t *testing.T, out []byte) int {
+	t.Helper()
+	start := bytes.Index(out, []byte{0x1D, 0x76, 0x30, 0x00})
+	if start < 0 {
+		t.Fatalf("expected a GS v 0 raster in % X", out)
+	}
+	return int(out[start+4]) | int(out[start+5])<<8
+}
+
+func TestTextImageTemplateFunc(t *testing.T) {
+	options := textRenderOptions(t, false)
+
+	out, err := RenderToBytesWithOptions(`{{ textImage "Καλημέρα" }}`, nil, options)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if width := rasterWidth(t, out); width != 48 {
+		t.Fatalf("expected a raster spanning the 384 dot line, got %d bytes", width)
+	}
+	raster := rasterData(t, out)
+	if rows := len(raster) / 48; rows < 20 || rows > 40 {
+		t.Fatalf("expected one line of text, got %d rows", rows)
+	}
+	if bytes.Count(raster, []byte{0}) == len(raster) {
+		t.Fatalf("expected the text to be drawn")
+	}
+
+	small := out
+	out, err = RenderToBytesWithOptions(`{{ textImage "Καλημέρα" "size" 48 "bold" true "feed" 2 }}`, nil, options)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	smallRows, largeRows := len(rasterData(t, small))/48, len(rasterData(t, out))/48
+	if largeRows < 2*smallRows-2 {
+		t.Fatalf("expected size 48 to be twice as tall as %d rows, got %d", smallRows, largeRows)
+	}
+	raster = rasterData(t, out)
+	if end := bytes.Index(out, raster) + len(raster); !bytes.HasPrefix(out[end:], []byte{0x1B, 0x64, 0x02}) {
+		t.Fatalf("expected a feed after the text, got % X", out[end:])
+	}
+}
+
+func TestTextImageAlignsRightToLeftTextRight(t *testing.T) {
+	options := textRenderOptions(t, false)
+
+	out, err := RenderToBytesWithOptions(`{{ textImage "שלום" }}`, nil, options)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	raster := rasterData(t, out)
+	left, right := 0, 0
+	for row := 0; row+48 <= len(raster); row += 48 {
+		for i, b := range raster[row : row+48] {
+			if b == 0 {
+				continue
+			}
+			if i < 24 {
+				left++
+			} else {
+				right++
+			}
+		}
+	}
+	if left != 0 || right == 0 {
+		t.Fatalf("expected right-to-left text on the right, got %d left and %d right", left, right)
+	}
+}
+
+func TestTextImageTemplateFuncErrors(t *testing.T) {
+	cases := map[string]string{
+		`{{ textImage "x" }}`:               "no font is configured",
+		`{{ textImage "x" "size" 0 }}`:      "size expects 1-512 dots",
+		`{{ textImage "x" "italic" true }}`: `unknown option "italic"`,
+		`{{ textImage "x" "bold" }}`:        "key/value option pairs",
+	}
+	for tmpl, want := range cases {
+		options := textRenderOptions(t, false)
+		if want == "no font is configured" {
+			options = RenderOptions{}
+		}
+		if _, err := RenderToBytesWithOptions(tmpl, nil, options); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%s: expected %q error, got %v", tmpl, want, err)
+		}
+	}
+}
+
+func TestTextFallbackRastersUnencodableText(t *testing.T) {
+	options := textRenderOptions(t, true)
+
+	out, err := RenderToBytesWithOptions(`{{ bold "Hello" }}`, nil, options)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if !bytes.Contains(out, []byte("\x1B\x45\x01Hello\x1B\x45\x00")) {
+		t.Fatalf("expected encodable text to stay text, got % X", out)
+	}
+
+	out, err = RenderToBytesWithOptions(`{{ bold "Здравствуйте" }}`, nil, options)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if bytes.Contains(out, []byte{0x1B, 0x45, 0x01}) {
+		t.Fatalf("expected a raster instead of bold text, got % X", out)
+	}
+	if width := rasterWidth(t, out); width >= 48 {
+		t.Fatalf("expected a raster only as wide as the text, got %d bytes", width)
+	}
+
+	out, err = RenderToBytesWithOptions(`{{ center "Здравствуйте\n" }}`, nil, options)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if width := rasterWidth(t, out); width != 48 {
+		t.Fatalf("expected a centered raster across the line, got %d bytes", width)
+	}
+
+	// The Go font has no CJK glyphs, so the text is printed as it would be without a font
+	out, err = RenderToBytesWithOptions(`{{ bold "日本語" }}`, nil, options)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if !bytes.Contains(out, []byte("\x1B\x45\x01???\x1B\x45\x00")) {
+		t.Fatalf("expected text instead of a raster of missing glyphs, got % X", out)
+	}
+
+	// A raster from a nested helper is printed as it is
+	out, err = RenderToBytesWithOptions(`{{ center (bold "Здравствуйте") }}`, nil, options)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if !bytes.Contains(out, []byte("\x1Ba\x01")) {
+		t.Fatalf("expected the bold raster centered, got % X", out)
+	}
+	if width := rasterWidth(t, out); width >= 48 {
+		t.Fatalf("expected the raster only as wide as the text, got %d bytes", width)
+	}
+
+	plain := textRenderOptions(t, false)
+	out, err = RenderToBytesWithOptions(`{{ bold "Здравствуйте" }}`, nil, plain)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if !bytes.Contains(out, []byte{0x1B, 0x45, 0x01}) {
+		t.Fatalf("expected the ASCII fallback without auto mode, got % X", out)
+	}
+}
+
+func TestTextFallbackDoubleSize(t *testing.T) {
+	options := textRenderOptions(t, true)
+
+	normal, err := RenderToBytesWithOptions(`{{ bold "Ωμέγα" }}`, nil, options)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	wide, err := RenderToBytesWithOptions(`{{ doubleWidth "Ωμέγα" }}`, nil, options)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	tall, err := RenderToBytesWithOptions(`{{ doubleHeight "Ωμέγα" }}`, nil, options)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	normalWidth := rasterWidth(t, normal)
+	if width := rasterWidth(t, wide); width < 2*normalWidth-1 {
+		t.Fatalf("expected double width, got %d bytes for %d", width, normalWidth)
+	}
+	normalRows := len(rasterData(t, normal)) / normalWidth
+	if rows := len(rasterData(t, tall)) / rasterWidth(t, tall); rows != 2*normalRows {
+		t.Fatalf("expected %d rows, got %d", 2*normalRows, rows)
+	}
+}
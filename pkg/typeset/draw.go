@@ -0,0 +1,134 @@
+package typeset
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/go-text/typesetting/font"
+	ot "github.com/go-text/typesetting/font/opentype"
+	"github.com/go-text/typesetting/shaping"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// canvas accumulates glyph outlines in a rasterizer and colour bitmaps in a
+// grayscale image, then combines both.
+type canvas struct {
+	raster *vector.Rasterizer
+	gray   *image.Gray
+}
+
+func newCanvas(width, height int) *canvas {
+	gray := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range gray.Pix {
+		gray.Pix[i] = 0xFF
+	}
+	return &canvas{raster: vector.NewRasterizer(width, height), gray: gray}
+}
+
+// drawRun draws the glyphs of run starting at dot on the given baseline.
+// Outline glyphs are drawn embolden extra times, each one dot further right.
+func (c *canvas) drawRun(run shaping.Output, dot fixed.Int26_6, baseline, embolden int) {
+	scale := float32(run.Size) / 64 / float32(run.Face.Upem())
+	for _, g := range run.Glyphs {
+		x := float32(dot+g.XOffset) / 64
+		y := float32(baseline) - float32(g.YOffset)/64
+
+		var outline *font.GlyphOutline
+		switch data := run.Face.GlyphData(g.GlyphID).(type) {
+		case font.GlyphOutline:
+			outline = &data
+		case font.GlyphSVG:
+			outline = &data.Outline
+		case font.GlyphBitmap:
+			if data.Format == font.PNG || data.Format == font.JPG {
+				c.drawBitmap(data, image.Rect(
+					int(x+float32(g.XBearing)/64),
+					int(y-float32(g.YBearing)/64),
+					int(x+float32(g.XBearing+g.Width)/64+0.5),
+					int(y-float32(g.YBearing+g.Height)/64+0.5),
+				))
+			} else {
+				outline = data.Outline
+			}
+		}
+		if outline != nil {
+			for dx := 0; dx <= embolden; dx++ {
+				c.addOutline(outline, x+float32(dx), y, scale)
+			}
+		}
+		dot += g.XAdvance
+	}
+}
+
+// addOutline adds a glyph outline in font units, with its origin at (x, y).
+func (c *canvas) addOutline(outline *font.GlyphOutline, x, y, scale float32) {
+	open := false
+	for _, s := range outline.Segments {
+		p := s.Args
+		switch s.Op {
+		case ot.SegmentOpMoveTo:
+			if open {
+				c.raster.ClosePath()
+			}
+			c.raster.MoveTo(x+p[0].X*scale, y-p[0].Y*scale)
+			open = true
+		case ot.SegmentOpLineTo:
+			c.raster.LineTo(x+p[0].X*scale, y-p[0].Y*scale)
+		case ot.SegmentOpQuadTo:
+			c.raster.QuadTo(x+p[0].X*scale, y-p[0].Y*scale, x+p[1].X*scale, y-p[1].Y*scale)
+		case ot.SegmentOpCubeTo:
+			c.raster.CubeTo(x+p[0].X*scale, y-p[0].Y*scale, x+p[1].X*scale, y-p[1].Y*scale, x+p[2].X*scale, y-p[2].Y*scale)
+		}
+	}
+	if open {
+		c.raster.ClosePath()
+	}
+}
+
+// drawBitmap scales a PNG or JPEG glyph, such as a colour emoji, into r and
+// darkens the canvas with it.
+func (c *canvas) drawBitmap(bitmap font.GlyphBitmap, r image.Rectangle) {
+	r = r.Canon()
+	if r.Empty() {
+		return
+	}
+	src, _, err := image.Decode(bytes.NewReader(bitmap.Data))
+	if err != nil {
+		return
+	}
+
+	// Flatten transparency onto white before converting to gray
+	scaled := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	xdraw.Draw(scaled, scaled.Bounds(), image.White, image.Point{}, xdraw.Src)
+	xdraw.ApproxBiLinear.Scale(scaled, scaled.Bounds(), src, src.Bounds(), xdraw.Over, nil)
+
+	for y := 0; y < r.Dy(); y++ {
+		for x := 0; x < r.Dx(); x++ {
+			p := image.Pt(r.Min.X+x, r.Min.Y+y)
+			if !p.In(c.gray.Rect) {
+				continue
+			}
+			v := color.GrayModel.Convert(scaled.At(x, y)).(color.Gray).Y
+			if i := c.gray.PixOffset(p.X, p.Y); v < c.gray.Pix[i] {
+				c.gray.Pix[i] = v
+			}
+		}
+	}
+}
+
+// image returns the canvas with all outlines drawn in black.
+func (c *canvas) image() *image.Gray {
+	mask := image.NewAlpha(c.gray.Rect)
+	c.raster.Draw(mask, mask.Bounds(), image.Opaque, image.Point{})
+	for i, a := range mask.Pix {
+		if v := 0xFF - a; v < c.gray.Pix[i] {
+			c.gray.Pix[i] = v
+		}
+	}
+	return c.gray
+}
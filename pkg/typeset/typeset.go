@@ -0,0 +1,316 @@
+// Package typeset shapes text with TrueType and OpenType fonts and renders it
+// to grayscale images. It is used to print scripts the printer's character
+// code pages cannot represent, such as Greek, Cyrillic, CJK, Arabic or emoji.
+package typeset
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/go-text/typesetting/di"
+	"github.com/go-text/typesetting/font"
+	"github.com/go-text/typesetting/shaping"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/escpos"
+)
+
+const (
+	// DefaultSize is the em size in dots used when Options.Size is zero. It
+	// matches the height of the printer's font A.
+	DefaultSize = 24
+	// MaxSize is the largest supported em size in dots.
+	MaxSize = 512
+)
+
+// Options control how Render lays out text.
+type Options struct {
+	// Size is the em size in dots. Zero selects DefaultSize.
+	Size int
+	// Bold selects the bold font, or emboldens the regular font when no bold
+	// font is loaded.
+	Bold bool
+	// MaxWidth wraps lines wider than this many dots. Zero disables wrapping.
+	MaxWidth int
+	// Align places lines that are narrower than the widest line.
+	Align escpos.Alignment
+}
+
+// Validate reports options that are out of range.
+func (o Options) Validate() error {
+	if o.Size < 0 || o.Size > MaxSize {
+		return fmt.Errorf("size must be between 1 and %d dots; got %d", MaxSize, o.Size)
+	}
+	if o.MaxWidth < 0 {
+		return fmt.Errorf("max width must be >= 0; got %d", o.MaxWidth)
+	}
+	if o.Align > escpos.AlignRight {
+		return fmt.Errorf("unsupported alignment %d", byte(o.Align))
+	}
+	return nil
+}
+
+// Typesetter renders text with a regular font, an optional bold font and a
+// chain of fallback fonts for runes the main font does not cover. It is safe
+// for concurrent use.
+type Typesetter struct {
+	regular fontmap
+	bold    fontmap
+
+	mu        sync.Mutex
+	shaper    shaping.HarfbuzzShaper
+	segmenter shaping.Segmenter
+	wrapper   shaping.LineWrapper
+}
+
+// New parses the given font files. regular is required; bold may be nil, in
+// which case bold text is emboldened synthetically. Fallback fonts are tried
+// in order for runes the regular or bold font lacks.
+func New(regular, bold []byte, fallbacks ...[]byte) (*Typesetter, error) {
+	if len(regular) == 0 {
+		return nil, errors.New("a regular font is required")
+	}
+	regularFace, err := parseFace(regular)
+	if err != nil {
+		return nil, fmt.Errorf("regular font: %w", err)
+	}
+
+	var fallbackFaces []*font.Face
+	for i, data := range fallbacks {
+		face, err := parseFace(data)
+		if err != nil {
+			return nil, fmt.Errorf("fallback font %d: %w", i+1, err)
+		}
+		fallbackFaces = append(fallbackFaces, face)
+	}
+
+	t := &Typesetter{regular: append(fontmap{regularFace}, fallbackFaces...)}
+	if len(bold) > 0 {
+		boldFace, err := parseFace(bold)
+		if err != nil {
+			return nil, fmt.Errorf("bold font: %w", err)
+		}
+		t.bold = append(fontmap{boldFace}, fallbackFaces...)
+	}
+	return t, nil
+}
+
+// Load reads and parses font files from disk, see New. An empty boldPath
+// selects synthetic bold.
+func Load(regularPath, boldPath string, fallbackPaths ...string) (*Typesetter, error) {
+	read := func(path string) ([]byte, error) {
+		if path == "" {
+			return nil, nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read font %s: %w", path, err)
+		}
+		return data, nil
+	}
+
+	regular, err := read(regularPath)
+	if err != nil {
+		return nil, err
+	}
+	bold, err := read(boldPath)
+	if err != nil {
+		return nil, err
+	}
+	fallbacks := make([][]byte, 0, len(fallbackPaths))
+	for _, path := range fallbackPaths {
+		data, err := read(path)
+		if err != nil {
+			return nil, err
+		}
+		fallbacks = append(fallbacks, data)
+	}
+	return New(regular, bold, fallbacks...)
+}
+
+func parseFace(data []byte) (*font.Face, error) {
+	face, err := font.ParseTTF(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse font: %w", err)
+	}
+	return face, nil
+}
+
+// fontmap resolves every rune to the first face that has a glyph for it,
+// falling back to the first face.
+type fontmap []*font.Face
+
+func (m fontmap) ResolveFace(r rune) *font.Face {
+	for _, face := range m {
+		if _, ok := face.NominalGlyph(r); ok {
+			return face
+		}
+	}
+	return m[0]
+}
+
+// Covers reports whether the loaded fonts have a glyph for every rune of
+// text, ignoring whitespace and control characters.
+func (t *Typesetter) Covers(text string) bool {
+	for _, r := range text {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			continue
+		}
+		face := t.regular.ResolveFace(r)
+		if _, ok := face.NominalGlyph(r); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// RightToLeft reports whether the first strong character of text belongs to
+// a right-to-left script. Such text is laid out from right to left.
+func RightToLeft(text string) bool {
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Arabic, unicode.Hebrew, unicode.Syriac, unicode.Thaana, unicode.Nko, unicode.Samaritan, unicode.Mandaic, unicode.Adlam):
+			return true
+		case unicode.IsLetter(r):
+			return false
+		}
+	}
+	return false
+}
+
+// line is one laid out line of text, with its runs in visual order.
+type line struct {
+	runs    []shaping.Output
+	width   int
+	ascent  int
+	descent int
+}
+
+// Render shapes text and draws it black on white. Lines are split at '\n'
+// and wrapped at options.MaxWidth; each line follows the direction of its
+// first strong character, with mixed-direction runs in bidi order.
+func (t *Typesetter) Render(text string, options Options) (*image.Gray, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	size := options.Size
+	if size == 0 {
+		size = DefaultSize
+	}
+	faces := t.regular
+	embolden := 0
+	if options.Bold {
+		if t.bold != nil {
+			faces = t.bold
+		} else {
+			// Overprint each glyph shifted right, about 1 dot per 16 dots of size
+			embolden = max(1, size/16)
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var lines []line
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		lines = append(lines, t.layout([]rune(paragraph), faces, size, options.MaxWidth)...)
+	}
+
+	width, height := 1, 0
+	for _, l := range lines {
+		width = max(width, l.width+embolden)
+		height += l.ascent + l.descent
+	}
+	height = max(height, 1)
+
+	c := newCanvas(width, height)
+	top := 0
+	for _, l := range lines {
+		x := 0
+		switch options.Align {
+		case escpos.AlignCenter:
+			x = (width - l.width - embolden) / 2
+		case escpos.AlignRight:
+			x = width - l.width - embolden
+		}
+		baseline := top + l.ascent
+		dot := fixed.I(x)
+		for _, run := range l.runs {
+			c.drawRun(run, dot, baseline, embolden)
+			dot += run.Advance
+		}
+		top += l.ascent + l.descent
+	}
+	return c.image(), nil
+}
+
+// layout shapes and wraps one paragraph.
+func (t *Typesetter) layout(paragraph []rune, faces fontmap, size, maxWidth int) []line {
+	if len(paragraph) == 0 {
+		return []line{emptyLine(faces[0], size)}
+	}
+
+	direction := di.DirectionLTR
+	if RightToLeft(string(paragraph)) {
+		direction = di.DirectionRTL
+	}
+	input := shaping.Input{
+		Text:      paragraph,
+		RunStart:  0,
+		RunEnd:    len(paragraph),
+		Direction: direction,
+		Face:      faces[0],
+		Size:      fixed.I(size),
+	}
+
+	var outputs []shaping.Output
+	for _, run := range t.segmenter.Split(input, faces) {
+		outputs = append(outputs, t.shaper.Shape(run))
+	}
+
+	if maxWidth <= 0 {
+		maxWidth = int(^uint32(0) >> 2)
+	}
+	wrapped, _ := t.wrapper.WrapParagraph(shaping.WrapConfig{Direction: direction}, maxWidth, paragraph, shaping.NewSliceIterator(outputs))
+
+	lines := make([]line, 0, len(wrapped))
+	for _, runs := range wrapped {
+		// The wrapper reuses its lines, so keep a sorted copy
+		ordered := slices.Clone(runs)
+		slices.SortFunc(ordered, func(a, b shaping.Output) int {
+			return int(a.VisualIndex - b.VisualIndex)
+		})
+
+		var l line
+		var advance fixed.Int26_6
+		for _, run := range ordered {
+			advance += run.Advance
+			l.ascent = max(l.ascent, run.LineBounds.Ascent.Ceil())
+			l.descent = max(l.descent, (-run.LineBounds.Descent).Ceil())
+		}
+		l.runs = ordered
+		l.width = advance.Ceil()
+		lines = append(lines, l)
+	}
+	return lines
+}
+
+// emptyLine is the height of a blank line in face.
+func emptyLine(face *font.Face, size int) line {
+	scale := float32(size) / float32(face.Upem())
+	extents, ok := face.FontHExtents()
+	if !ok {
+		return line{ascent: size}
+	}
+	return line{
+		ascent:  fixed.Int26_6(extents.Ascender * scale * 64).Ceil(),
+		descent: fixed.Int26_6(-extents.Descender * scale * 64).Ceil(),
+	}
+}
@@ -0,0 +1,191 @@
+package typeset
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+
+	"github.com/jonasclaes/go-thermal-printer/pkg/escpos"
+)
+
+func newTestTypesetter(t *testing.T, bold bool) *Typesetter {
+	t.Helper()
+	var boldFont []byte
+	if bold {
+		boldFont = gobold.TTF
+	}
+	ts, err := New(goregular.TTF, boldFont)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return ts
+}
+
+func inkColumns(img *image.Gray) (first, last, total int) {
+	first, last = -1, -1
+	b := img.Bounds()
+	for x := b.Min.X; x < b.Max.X; x++ {
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			if img.GrayAt(x, y).Y < 128 {
+				if first < 0 {
+					first = x
+				}
+				last = x
+				total++
+			}
+		}
+	}
+	return first, last, total
+}
+
+func TestRenderGreekAndCyrillic(t *testing.T) {
+	ts := newTestTypesetter(t, false)
+	if !ts.Covers("Καλημέρα Здравствуйте") {
+		t.Fatalf("expected the Go font to cover Greek and Cyrillic")
+	}
+
+	img, err := ts.Render("Καλημέρα Здравствуйте", Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h := img.Bounds().Dy(); h < DefaultSize || h > 2*DefaultSize {
+		t.Fatalf("expected a single line about %d dots tall, got %d", DefaultSize, h)
+	}
+	if _, _, ink := inkColumns(img); ink == 0 {
+		t.Fatalf("expected the text to be drawn")
+	}
+}
+
+func TestRenderSizeAndWrapping(t *testing.T) {
+	ts := newTestTypesetter(t, false)
+
+	small, err := ts.Render("Hello", Options{Size: 20})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	large, err := ts.Render("Hello", Options{Size: 40})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if large.Bounds().Dx() < 2*small.Bounds().Dx()-2 || large.Bounds().Dy() < 2*small.Bounds().Dy()-2 {
+		t.Fatalf("expected size 40 to be twice size 20: %v vs %v", large.Bounds(), small.Bounds())
+	}
+
+	wrapped, err := ts.Render("one two three four five six", Options{Size: 20, MaxWidth: 80})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if wrapped.Bounds().Dx() > 80 {
+		t.Fatalf("expected lines of at most 80 dots, got %d", wrapped.Bounds().Dx())
+	}
+	if wrapped.Bounds().Dy() < 3*small.Bounds().Dy() {
+		t.Fatalf("expected at least three lines, got height %d", wrapped.Bounds().Dy())
+	}
+
+	lines, err := ts.Render("one\n\ntwo", Options{Size: 20})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lines.Bounds().Dy() != 3*small.Bounds().Dy() {
+		t.Fatalf("expected three lines of %d dots, got height %d", small.Bounds().Dy(), lines.Bounds().Dy())
+	}
+}
+
+func TestRenderBold(t *testing.T) {
+	for _, withBoldFont := range []bool{true, false} {
+		ts := newTestTypesetter(t, withBoldFont)
+		regular, err := ts.Render("Bold", Options{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		bold, err := ts.Render("Bold", Options{Bold: true})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_, _, regularInk := inkColumns(regular)
+		_, _, boldInk := inkColumns(bold)
+		if boldInk <= regularInk {
+			t.Fatalf("bold font %v: expected more ink than %d, got %d", withBoldFont, regularInk, boldInk)
+		}
+	}
+}
+
+func TestRenderRightToLeftOrder(t *testing.T) {
+	ts := newTestTypesetter(t, false)
+	if !RightToLeft("שלום abc") || RightToLeft("abc שלום") || RightToLeft("123") {
+		t.Fatalf("unexpected base direction")
+	}
+
+	latin, err := ts.Render("abc", Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, latinLast, _ := inkColumns(latin)
+
+	// The Hebrew word comes first in logical order but is drawn to the right
+	// of the Latin one in a right-to-left paragraph
+	mixed, err := ts.Render("אב abc", Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for x := 0; x <= latinLast; x++ {
+		for y := 0; y < latin.Bounds().Dy(); y++ {
+			if latin.GrayAt(x, y).Y != mixed.GrayAt(x, y).Y {
+				t.Fatalf("expected the Latin run at the left edge, differs at %d,%d", x, y)
+			}
+		}
+	}
+	if _, mixedLast, _ := inkColumns(mixed); mixedLast <= latinLast+DefaultSize/2 {
+		t.Fatalf("expected the Hebrew run right of the Latin run")
+	}
+}
+
+func TestRenderAlignment(t *testing.T) {
+	ts := newTestTypesetter(t, false)
+
+	left, err := ts.Render("i\nwide line", Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	right, err := ts.Render("i\nwide line", Options{Align: escpos.AlignRight})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	firstLine := func(img *image.Gray) *image.Gray {
+		return img.SubImage(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()/2)).(*image.Gray)
+	}
+	leftFirst, _, _ := inkColumns(firstLine(left))
+	rightFirst, _, _ := inkColumns(firstLine(right))
+	if leftFirst > 4 || rightFirst < left.Bounds().Dx()-DefaultSize {
+		t.Fatalf("expected the short line at the left and right edge, got %d and %d", leftFirst, rightFirst)
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	ts := newTestTypesetter(t, false)
+	cases := map[string]Options{
+		"size must be between":    {Size: MaxSize + 1},
+		"max width must be >= 0":  {MaxWidth: -1},
+		"unsupported alignment 3": {Align: 3},
+	}
+	for want, options := range cases {
+		if _, err := ts.Render("x", options); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q error, got %v", want, err)
+		}
+	}
+}
+
+func TestNewRequiresValidFonts(t *testing.T) {
+	if _, err := New(nil, nil); err == nil {
+		t.Fatalf("expected an error without a regular font")
+	}
+	if _, err := New(goregular.TTF, nil, []byte("not a font")); err == nil || !strings.Contains(err.Error(), "fallback font 1") {
+		t.Fatalf("expected a fallback font error, got %v", err)
+	}
+	if _, err := Load("missing.ttf", ""); err == nil || !strings.Contains(err.Error(), "missing.ttf") {
+		t.Fatalf("expected a read error, got %v", err)
+	}
+}